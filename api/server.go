@@ -0,0 +1,244 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"go.uber.org/zap"
+)
+
+const defaultDepthLevels = 10
+
+// Server answers read-only JSON queries about books. Every handler reads through
+// orderbook.Book's snapshot methods so it never races with the feed handler.
+type Server struct {
+	books        map[string]*orderbook.Book
+	gaps         map[string]GapSource
+	heartbeats   map[string]HeartbeatSource
+	consolidated map[string]*orderbook.Consolidated
+}
+
+// HeartbeatSource reports the heartbeat channel of a product's feed. gdax.Handler implements it.
+type HeartbeatSource interface {
+	Heartbeat() gdax.Heartbeat
+}
+
+// GapSource reports the sequence gaps of a product's feed. gdax.Handler implements it.
+type GapSource interface {
+	GapStats() gdax.GapStats
+}
+
+func NewServer(books ...*orderbook.Book) *Server {
+	server := &Server{
+		books:        map[string]*orderbook.Book{},
+		gaps:         map[string]GapSource{},
+		heartbeats:   map[string]HeartbeatSource{},
+		consolidated: map[string]*orderbook.Consolidated{},
+	}
+	for _, book := range books {
+		server.books[book.ID] = book
+	}
+	return server
+}
+
+// AddGapSource serves /books/{product}/gaps from source.
+func (server *Server) AddGapSource(productID string, source GapSource) {
+	server.gaps[productID] = source
+}
+
+// AddHeartbeatSource makes /health report the product's last heartbeat and fail while it is stale.
+func (server *Server) AddHeartbeatSource(productID string, source HeartbeatSource) {
+	server.heartbeats[productID] = source
+}
+
+// AddConsolidated serves /consolidated/{product} from a multi-venue book.
+func (server *Server) AddConsolidated(consolidated *orderbook.Consolidated) {
+	server.consolidated[consolidated.Book().ID] = consolidated
+}
+
+func (server *Server) ListenAndServe(addr string) error {
+	zap.L().Info("Starting API server", zap.String("addr", addr))
+	return http.ListenAndServe(addr, server)
+}
+
+// ServeHTTP routes /health, /books, /books/{product}/{top,depth,orders/{id},trades,gaps} and
+// /consolidated/{product}.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "health":
+		server.health(w)
+	case len(parts) == 1 && parts[0] == "books":
+		server.listBooks(w)
+	case len(parts) == 2 && parts[0] == "consolidated":
+		consolidated, found := server.consolidated[parts[1]]
+		if !found {
+			writeError(w, http.StatusNotFound, "no consolidated book for "+parts[1])
+			return
+		}
+		server.consolidatedDepth(w, r, consolidated)
+	case len(parts) >= 3 && parts[0] == "books":
+		book, found := server.books[parts[1]]
+		if !found {
+			writeError(w, http.StatusNotFound, "unknown product "+parts[1])
+			return
+		}
+		switch {
+		case len(parts) == 3 && parts[2] == "top":
+			server.top(w, book)
+		case len(parts) == 3 && parts[2] == "depth":
+			server.depth(w, r, book)
+		case len(parts) == 3 && parts[2] == "trades":
+			server.trades(w, book)
+		case len(parts) == 3 && parts[2] == "gaps":
+			server.gapStats(w, book)
+		case len(parts) == 4 && parts[2] == "orders":
+			server.order(w, book, parts[3])
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (server *Server) sortedBooks() []*orderbook.Book {
+	books := make([]*orderbook.Book, 0, len(server.books))
+	for _, book := range server.books {
+		books = append(books, book)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books
+}
+
+func (server *Server) health(w http.ResponseWriter) {
+	health := Health{Status: "ok", Books: []BookHealth{}}
+	for _, book := range server.sortedBooks() {
+		summary := newBookSummary(book.Snapshot(1))
+		bookHealth := BookHealth{BookSummary: summary}
+		if summary.Sequence == 0 {
+			health.Status = "syncing"
+		}
+		if source, found := server.heartbeats[book.ID]; found {
+			heartbeat := source.Heartbeat()
+			if !heartbeat.Last.IsZero() {
+				bookHealth.LastHeartbeat = &heartbeat.Last
+			}
+			if heartbeat.Stale && health.Status == "ok" {
+				health.Status = "stale"
+			}
+		}
+		health.Books = append(health.Books, bookHealth)
+	}
+	status := http.StatusOK
+	if health.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+func (server *Server) listBooks(w http.ResponseWriter) {
+	summaries := []BookSummary{}
+	for _, book := range server.sortedBooks() {
+		summaries = append(summaries, newBookSummary(book.Snapshot(1)))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (server *Server) top(w http.ResponseWriter, book *orderbook.Book) {
+	snapshot := book.Snapshot(1)
+	top := Top{BookSummary: newBookSummary(snapshot)}
+	if bids := newLevels(snapshot.Bids); len(bids) > 0 {
+		top.Bid = &bids[0]
+	}
+	if asks := newLevels(snapshot.Asks); len(asks) > 0 {
+		top.Ask = &asks[0]
+	}
+	if top.Bid != nil && top.Ask != nil {
+		spread := top.Ask.Price.Sub(top.Bid.Price)
+		top.Spread = &spread
+	}
+	writeJSON(w, http.StatusOK, top)
+}
+
+func (server *Server) depth(w http.ResponseWriter, r *http.Request, book *orderbook.Book) {
+	levels, ok := depthLevels(w, r)
+	if !ok {
+		return
+	}
+	snapshot := book.Snapshot(levels)
+	writeJSON(w, http.StatusOK, Depth{
+		BookSummary: newBookSummary(snapshot),
+		Bids:        newLevels(snapshot.Bids),
+		Asks:        newLevels(snapshot.Asks),
+	})
+}
+
+func (server *Server) consolidatedDepth(w http.ResponseWriter, r *http.Request, consolidated *orderbook.Consolidated) {
+	levels, ok := depthLevels(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newConsolidated(consolidated.Snapshot(levels)))
+}
+
+// depthLevels reads the levels query parameter, writing the error when it is invalid.
+func depthLevels(w http.ResponseWriter, r *http.Request) (int, bool) {
+	param := r.URL.Query().Get("levels")
+	if param == "" {
+		return defaultDepthLevels, true
+	}
+	levels, err := strconv.Atoi(param)
+	if err != nil || levels < 0 {
+		writeError(w, http.StatusBadRequest, "levels must be a non-negative integer")
+		return 0, false
+	}
+	return levels, true
+}
+
+func (server *Server) order(w http.ResponseWriter, book *orderbook.Book, id string) {
+	order, found := book.SnapshotOrder(id)
+	if !found {
+		writeError(w, http.StatusNotFound, "order "+id+" is not resting on the book")
+		return
+	}
+	writeJSON(w, http.StatusOK, newOrder(order))
+}
+
+func (server *Server) trades(w http.ResponseWriter, book *orderbook.Book) {
+	trades := []Trade{}
+	for _, trade := range book.SnapshotTrades() {
+		trades = append(trades, newTrade(trade))
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
+
+func (server *Server) gapStats(w http.ResponseWriter, book *orderbook.Book) {
+	source, found := server.gaps[book.ID]
+	if !found {
+		writeError(w, http.StatusNotFound, "no gap statistics for "+book.ID)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGapStats(book.ID, source.GapStats()))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		zap.L().Error("Could not write API response", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, Error{Error: message})
+}
@@ -0,0 +1,234 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/api"
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+type gapSource gdax.GapStats
+
+func (source gapSource) GapStats() gdax.GapStats {
+	return gdax.GapStats(source)
+}
+
+type heartbeatSource gdax.Heartbeat
+
+func (source heartbeatSource) Heartbeat() gdax.Heartbeat {
+	return gdax.Heartbeat(source)
+}
+
+// newTestServer serves a BTC-USD book with bids of 3 at 100.00 and 1 at 99.00, asks of 0.5 at
+// 101.00 after a fill and 3 at 102.00, and a consolidated BTC-USD book over two venues.
+func newTestServer(t *testing.T) *api.Server {
+	book := orderbook.NewBook("BTC-USD", nil)
+	handler := gdax.NewHandler(nil, book, nil)
+	messages := []gdaxClient.Message{
+		{Type: "open", OrderId: "b1", RemainingSize: "1", Price: "100.00", Side: "buy"},
+		{Type: "open", OrderId: "b2", RemainingSize: "2", Price: "100.00", Side: "buy"},
+		{Type: "open", OrderId: "b3", RemainingSize: "1", Price: "99.00", Side: "buy"},
+		{Type: "open", OrderId: "a1", RemainingSize: "1", Price: "101.00", Side: "sell"},
+		{Type: "open", OrderId: "a2", RemainingSize: "3", Price: "102.00", Side: "sell"},
+		{Type: "match", TradeId: 7, MakerOrderId: "a1", TakerOrderId: "t1", Size: "0.5", Price: "101.00", Side: "sell"},
+	}
+	for i, message := range messages {
+		message.Sequence, message.ProductId = int64(i+1), "BTC-USD"
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	consolidated := orderbook.NewConsolidated("BTC-USD")
+	for _, event := range []orderbook.Event{
+		{Venue: "coinbase", Type: orderbook.EventOpen, OrderID: "c1", Side: common.BidSide, Price: decimal.New(100, 0), Size: decimal.New(1, 0)},
+		{Venue: "binance", Type: orderbook.EventOpen, OrderID: "n1", Side: common.BidSide, Price: decimal.New(100, 0), Size: decimal.New(2, 0)},
+		{Venue: "binance", Type: orderbook.EventOpen, OrderID: "n2", Side: common.AskSide, Price: decimal.New(101, 0), Size: decimal.New(1, 0)},
+	} {
+		consolidated.Event(event)
+	}
+
+	server := api.NewServer(book)
+	server.AddConsolidated(consolidated)
+	server.AddHeartbeatSource("BTC-USD", heartbeatSource{Last: time.Now(), Sequence: 6})
+	first, second := time.Now().Add(-time.Minute), time.Now().Add(-time.Second)
+	server.AddGapSource("BTC-USD", gapSource{Policy: gdax.ResyncReorder, Gaps: 2, Recovered: 1, Resyncs: 1, LastGap: second,
+		Recent: []gdax.Gap{
+			{Time: first, Expected: 3, Received: 5, Size: 2, Recovered: true},
+			{Time: second, Expected: 9, Received: 10, Size: 1, Resynced: true},
+		}})
+	return server
+}
+
+func decode(t *testing.T, body []byte, into interface{}) {
+	if err := json.Unmarshal(body, into); err != nil {
+		t.Fatalf("could not decode %s: %v", body, err)
+	}
+}
+
+func equal(value decimal.Decimal, want string) bool {
+	return value.Equal(decimal.RequireFromString(want))
+}
+
+func TestServer(t *testing.T) {
+	server := newTestServer(t)
+	tests := []struct {
+		method string
+		path   string
+		status int
+		check  func(t *testing.T, body []byte)
+	}{
+		{path: "/health", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			health := api.Health{}
+			decode(t, body, &health)
+			if health.Status != "ok" || len(health.Books) != 1 || health.Books[0].LastHeartbeat == nil {
+				t.Fatalf("health is %+v, want ok with the heartbeat of one book", health)
+			}
+		}},
+		{path: "/books", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			books := []api.BookSummary{}
+			decode(t, body, &books)
+			if len(books) != 1 || books[0].Product != "BTC-USD" || books[0].Sequence != 6 {
+				t.Fatalf("books are %+v, want BTC-USD at sequence 6", books)
+			}
+		}},
+		{path: "/books/BTC-USD/top", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			top := api.Top{}
+			decode(t, body, &top)
+			if top.Bid == nil || top.Ask == nil || top.Spread == nil {
+				t.Fatalf("top is %s, want a bid, an ask and a spread", body)
+			}
+			if !equal(top.Bid.Price, "100") || !equal(top.Bid.Size, "3") || top.Bid.NumOrders != 2 ||
+				!equal(top.Ask.Price, "101") || !equal(top.Ask.Size, "0.5") || !equal(*top.Spread, "1") {
+				t.Fatalf("top is %s, want 3 at 100.00 and 0.5 at 101.00", body)
+			}
+		}},
+		{path: "/books/BTC-USD/depth", status: http.StatusOK, check: depthLevels(2)},
+		{path: "/books/BTC-USD/depth?levels=1", status: http.StatusOK, check: depthLevels(1)},
+		{path: "/books/BTC-USD/depth?levels=0", status: http.StatusOK, check: depthLevels(2)},
+		{path: "/books/BTC-USD/depth?levels=-1", status: http.StatusBadRequest},
+		{path: "/books/BTC-USD/depth?levels=ten", status: http.StatusBadRequest},
+		{path: "/books/BTC-USD/trades", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			trades := []api.Trade{}
+			decode(t, body, &trades)
+			if len(trades) != 1 || trades[0].TradeID != 7 || trades[0].MakerOrderID != "a1" || !equal(trades[0].Size, "0.5") {
+				t.Fatalf("trades are %s, want trade 7 of 0.5 against a1", body)
+			}
+		}},
+		{path: "/books/BTC-USD/gaps", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			gaps := api.GapStats{}
+			decode(t, body, &gaps)
+			if gaps.Policy != "reorder" || gaps.Gaps != 2 || gaps.LastGap == nil || gaps.SecondsSinceLastGap == nil {
+				t.Fatalf("gap stats are %s, want two gaps under the reorder policy", body)
+			}
+			if len(gaps.Recent) != 2 || gaps.Recent[0].Expected != 9 || !gaps.Recent[0].Resynced || gaps.Recent[1].Expected != 3 {
+				t.Fatalf("recent gaps are %+v, want the newest first", gaps.Recent)
+			}
+		}},
+		{path: "/books/BTC-USD/orders/b2", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			order := api.Order{}
+			decode(t, body, &order)
+			if order.ID != "b2" || !equal(order.Size, "2") || !equal(order.Price, "100") || order.Side != common.ToString(common.BidSide) {
+				t.Fatalf("order is %s, want b2 bidding 2 at 100.00", body)
+			}
+		}},
+		{path: "/books/BTC-USD/orders/a1", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			order := api.Order{}
+			decode(t, body, &order)
+			if !equal(order.Size, "0.5") {
+				t.Fatalf("order is %s, want the 0.5 left after the fill", body)
+			}
+		}},
+		{path: "/books/BTC-USD/orders/missing", status: http.StatusNotFound},
+		{path: "/books/BTC-USD/unknown", status: http.StatusNotFound},
+		{path: "/books/ETH-USD/top", status: http.StatusNotFound, check: func(t *testing.T, body []byte) {
+			apiError := api.Error{}
+			decode(t, body, &apiError)
+			if apiError.Error != "unknown product ETH-USD" {
+				t.Fatalf("error is %q, want the unknown product named", apiError.Error)
+			}
+		}},
+		{path: "/books/ETH-USD/depth?levels=1", status: http.StatusNotFound},
+		{path: "/consolidated/BTC-USD", status: http.StatusOK, check: func(t *testing.T, body []byte) {
+			consolidated := api.Consolidated{}
+			decode(t, body, &consolidated)
+			if consolidated.Bid == nil || !equal(consolidated.Bid.Size, "3") || len(consolidated.Bid.Venues) != 2 ||
+				consolidated.Ask == nil || !equal(consolidated.Ask.Price, "101") || len(consolidated.Venues) != 2 {
+				t.Fatalf("consolidated book is %s, want 3 bid at 100 over two venues", body)
+			}
+		}},
+		{path: "/consolidated/BTC-USD?levels=bad", status: http.StatusBadRequest},
+		{path: "/consolidated/ETH-USD", status: http.StatusNotFound},
+		{path: "/unknown", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/books", status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		method := test.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		t.Run(method+" "+test.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(method, test.path, nil))
+			if recorder.Code != test.status {
+				t.Fatalf("status %d with %s, want %d", recorder.Code, recorder.Body.Bytes(), test.status)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Fatalf("content type %q, want application/json", contentType)
+			}
+			if test.check != nil {
+				test.check(t, recorder.Body.Bytes())
+			}
+		})
+	}
+}
+
+func depthLevels(levels int) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		depth := api.Depth{}
+		decode(t, body, &depth)
+		if len(depth.Bids) != levels || len(depth.Asks) != levels {
+			t.Fatalf("depth has %d bids and %d asks, want %d of each", len(depth.Bids), len(depth.Asks), levels)
+		}
+		if !equal(depth.Bids[0].Price, "100") || !equal(depth.Asks[0].Price, "101") {
+			t.Fatalf("depth is %s, want the best prices first", body)
+		}
+	}
+}
+
+func TestHealthIsUnavailableUntilEveryBookIsLive(t *testing.T) {
+	tests := []struct {
+		name      string
+		heartbeat gdax.Heartbeat
+		synced    bool
+		status    string
+	}{
+		{name: "syncing", heartbeat: gdax.Heartbeat{Last: time.Now()}, status: "syncing"},
+		{name: "stale", heartbeat: gdax.Heartbeat{Last: time.Now().Add(-time.Minute), Stale: true}, synced: true, status: "stale"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			book := orderbook.NewBook("BTC-USD", nil)
+			if test.synced {
+				book.Sequence = 1
+			}
+			server := api.NewServer(book)
+			server.AddHeartbeatSource("BTC-USD", heartbeatSource(test.heartbeat))
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+			health := api.Health{}
+			decode(t, recorder.Body.Bytes(), &health)
+			if recorder.Code != http.StatusServiceUnavailable || health.Status != test.status {
+				t.Fatalf("health is %d %q, want 503 %q", recorder.Code, health.Status, test.status)
+			}
+		})
+	}
+}
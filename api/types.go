@@ -0,0 +1,217 @@
+package api
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+type Level struct {
+	Price     decimal.Decimal `json:"price"`
+	Size      decimal.Decimal `json:"size"`
+	NumOrders int             `json:"num_orders"`
+	OwnSize   decimal.Decimal `json:"own_size"`
+}
+
+type BookSummary struct {
+	Product  string    `json:"product"`
+	Sequence int64     `json:"sequence"`
+	Updated  time.Time `json:"updated"`
+}
+
+type Top struct {
+	BookSummary
+	Bid    *Level           `json:"bid"`
+	Ask    *Level           `json:"ask"`
+	Spread *decimal.Decimal `json:"spread"`
+}
+
+type Depth struct {
+	BookSummary
+	Bids []Level `json:"bids"`
+	Asks []Level `json:"asks"`
+}
+
+type Order struct {
+	ID    string          `json:"order_id"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+	Side  string          `json:"side"`
+	Own   bool            `json:"own"`
+}
+
+type Trade struct {
+	TradeID      int             `json:"trade_id"`
+	Sequence     int64           `json:"sequence"`
+	MakerOrderID string          `json:"maker_order_id"`
+	TakerOrderID string          `json:"taker_order_id"`
+	Time         time.Time       `json:"time"`
+	Price        decimal.Decimal `json:"price"`
+	Size         decimal.Decimal `json:"size"`
+	Side         string          `json:"side"`
+}
+
+type Gap struct {
+	Time      time.Time `json:"time"`
+	Expected  int64     `json:"expected"`
+	Received  int64     `json:"received"`
+	Size      int64     `json:"size"`
+	Recovered bool      `json:"recovered"`
+	Resynced  bool      `json:"resynced"`
+}
+
+type GapStats struct {
+	Product             string     `json:"product"`
+	Policy              string     `json:"policy"`
+	Gaps                int64      `json:"gaps"`
+	Recovered           int64      `json:"recovered"`
+	Duplicates          int64      `json:"duplicates"`
+	Resyncs             int64      `json:"resyncs"`
+	Stale               bool       `json:"stale"`
+	LastGap             *time.Time `json:"last_gap"`
+	SecondsSinceLastGap *float64   `json:"seconds_since_last_gap"`
+	Recent              []Gap      `json:"recent"`
+}
+
+type VenueSize struct {
+	Venue string          `json:"venue"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+type ConsolidatedLevel struct {
+	Price  decimal.Decimal `json:"price"`
+	Size   decimal.Decimal `json:"size"`
+	Venues []VenueSize     `json:"venues"`
+}
+
+type Quote struct {
+	Venue string          `json:"venue"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+type Arbitrage struct {
+	Bid    Quote           `json:"bid"`
+	Ask    Quote           `json:"ask"`
+	Spread decimal.Decimal `json:"spread"`
+	Size   decimal.Decimal `json:"size"`
+	Opened time.Time       `json:"opened"`
+}
+
+type Consolidated struct {
+	BookSummary
+	Venues    []string            `json:"venues"`
+	Bid       *ConsolidatedLevel  `json:"bid"`
+	Ask       *ConsolidatedLevel  `json:"ask"`
+	Bids      []ConsolidatedLevel `json:"bids"`
+	Asks      []ConsolidatedLevel `json:"asks"`
+	Arbitrage []Arbitrage         `json:"arbitrage"`
+}
+
+type BookHealth struct {
+	BookSummary
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+type Health struct {
+	Status string       `json:"status"`
+	Books  []BookHealth `json:"books"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+func newBookSummary(snapshot orderbook.BookSnapshot) BookSummary {
+	return BookSummary{Product: snapshot.ID, Sequence: snapshot.Sequence, Updated: snapshot.Updated}
+}
+
+func newLevels(depth []orderbook.DepthLevel) []Level {
+	levels := make([]Level, 0, len(depth))
+	for _, level := range depth {
+		levels = append(levels, Level{Price: level.Price, Size: level.Size, NumOrders: level.NumOrders, OwnSize: level.OwnSize})
+	}
+	return levels
+}
+
+func newOrder(order orderbook.Order) Order {
+	return Order{ID: order.ID, Price: order.Price, Size: order.Size, Side: common.ToString(order.Side), Own: order.Own}
+}
+
+func newTrade(trade orderbook.Trade) Trade {
+	return Trade{
+		TradeID:      trade.TradeID,
+		Sequence:     trade.Sequence,
+		MakerOrderID: trade.MakerOrderID,
+		TakerOrderID: trade.TakerOrderID,
+		Time:         trade.Time,
+		Price:        trade.Price,
+		Size:         trade.Size,
+		Side:         common.ToString(trade.MakerSide),
+	}
+}
+
+func newGapStats(productID string, stats gdax.GapStats) GapStats {
+	gaps := GapStats{
+		Product:    productID,
+		Policy:     string(stats.Policy),
+		Gaps:       stats.Gaps,
+		Recovered:  stats.Recovered,
+		Duplicates: stats.Duplicates,
+		Resyncs:    stats.Resyncs,
+		Stale:      stats.Stale,
+		Recent:     []Gap{},
+	}
+	if !stats.LastGap.IsZero() {
+		since := stats.SinceLastGap.Seconds()
+		gaps.LastGap, gaps.SecondsSinceLastGap = &stats.LastGap, &since
+	}
+	// newest first
+	for i := len(stats.Recent) - 1; i >= 0; i-- {
+		gap := stats.Recent[i]
+		gaps.Recent = append(gaps.Recent, Gap{Time: gap.Time, Expected: gap.Expected, Received: gap.Received, Size: gap.Size,
+			Recovered: gap.Recovered, Resynced: gap.Resynced})
+	}
+	return gaps
+}
+
+func newConsolidated(snapshot orderbook.ConsolidatedSnapshot) Consolidated {
+	consolidated := Consolidated{
+		BookSummary: BookSummary{Product: snapshot.ID, Sequence: snapshot.Sequence, Updated: snapshot.Updated},
+		Venues:      snapshot.Venues,
+		Bids:        newConsolidatedLevels(snapshot.Bids),
+		Asks:        newConsolidatedLevels(snapshot.Asks),
+		Arbitrage:   []Arbitrage{},
+	}
+	if len(consolidated.Bids) > 0 {
+		consolidated.Bid = &consolidated.Bids[0]
+	}
+	if len(consolidated.Asks) > 0 {
+		consolidated.Ask = &consolidated.Asks[0]
+	}
+	for _, arbitrage := range snapshot.Arbitrage {
+		consolidated.Arbitrage = append(consolidated.Arbitrage, Arbitrage{
+			Bid:    Quote{Venue: arbitrage.Bid.Venue, Price: arbitrage.Bid.Price, Size: arbitrage.Bid.Size},
+			Ask:    Quote{Venue: arbitrage.Ask.Venue, Price: arbitrage.Ask.Price, Size: arbitrage.Ask.Size},
+			Spread: arbitrage.Spread(),
+			Size:   arbitrage.Size(),
+			Opened: arbitrage.Opened,
+		})
+	}
+	return consolidated
+}
+
+func newConsolidatedLevels(levels []orderbook.ConsolidatedLevel) []ConsolidatedLevel {
+	consolidated := make([]ConsolidatedLevel, 0, len(levels))
+	for _, level := range levels {
+		venues := make([]VenueSize, 0, len(level.Venues))
+		for _, venue := range level.Venues {
+			venues = append(venues, VenueSize{Venue: venue.Venue, Size: venue.Size})
+		}
+		consolidated = append(consolidated, ConsolidatedLevel{Price: level.Price, Size: level.Size, Venues: venues})
+	}
+	return consolidated
+}
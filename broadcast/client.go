@@ -0,0 +1,113 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const writeWait = 10 * time.Second
+
+type client struct {
+	hub  *Hub
+	conn *ws.Conn
+	send chan []byte
+	// product -> channel, guarded by hub.mu
+	subscriptions map[string]map[string]bool
+}
+
+func newClient(hub *Hub, conn *ws.Conn) *client {
+	return &client{
+		hub:           hub,
+		conn:          conn,
+		send:          make(chan []byte, hub.clientBuffer),
+		subscriptions: map[string]map[string]bool{},
+	}
+}
+
+func (c *client) readLoop() {
+	defer func() {
+		c.hub.mu.Lock()
+		c.hub.removeClient(c)
+		c.hub.mu.Unlock()
+	}()
+	for {
+		request := Request{}
+		if err := c.conn.ReadJSON(&request); err != nil {
+			zap.L().Debug("Broadcast client read failed", zap.Error(err))
+			return
+		}
+		switch request.Type {
+		case "subscribe":
+			c.hub.subscribe(c, request)
+		case "unsubscribe":
+			c.hub.unsubscribe(c, request)
+		default:
+			c.hub.mu.Lock()
+			c.sendError("unknown request type " + request.Type)
+			c.hub.mu.Unlock()
+		}
+	}
+}
+
+func (c *client) writeLoop() {
+	defer c.conn.Close()
+	for data := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.conn.WriteMessage(ws.TextMessage, data); err != nil {
+			zap.L().Debug("Broadcast client write failed", zap.Error(err))
+			return
+		}
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	c.conn.WriteMessage(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseNormalClosure, ""))
+}
+
+// The methods below must be called with hub.mu held.
+
+func (c *client) subscribed(product string, channel string) bool {
+	return c.subscriptions[product][channel]
+}
+
+func (c *client) subscribedToProduct(product string) bool {
+	return len(c.subscriptions[product]) > 0
+}
+
+func (c *client) subscribe(product string, channel string) {
+	if c.subscriptions[product] == nil {
+		c.subscriptions[product] = map[string]bool{}
+	}
+	c.subscriptions[product][channel] = true
+}
+
+func (c *client) unsubscribe(product string, channel string) {
+	delete(c.subscriptions[product], channel)
+}
+
+func (c *client) enqueue(message interface{}) bool {
+	data, err := json.Marshal(message)
+	if err != nil {
+		zap.L().Error("Could not encode broadcast message", zap.Error(err))
+		return true
+	}
+	return c.enqueueRaw(data)
+}
+
+// enqueueRaw never blocks the feed. It reports false when the client has fallen too far behind.
+func (c *client) enqueueRaw(data []byte) bool {
+	if _, found := c.hub.clients[c]; !found {
+		return true
+	}
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *client) sendError(message string) {
+	c.enqueue(Error{Type: "error", Message: message})
+}
@@ -0,0 +1,277 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	ws "github.com/gorilla/websocket"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const DefaultClientBuffer = 4096
+
+// Hub fans the books of one process out to websocket clients. Each book's handler
+// feeds the hub through the HandlerConsumer returned by Consumer.
+type Hub struct {
+	mu           sync.Mutex
+	books        map[string]*orderbook.Book
+	clients      map[*client]struct{}
+	sequences    map[string]map[string]int64
+	feedSequence map[string]int64
+	bbo          map[string]BBO
+	clientBuffer int
+	upgrader     ws.Upgrader
+}
+
+// NewHub creates a hub for books. clientBuffer is how many messages a client may
+// fall behind before it is disconnected.
+func NewHub(clientBuffer int, books ...*orderbook.Book) *Hub {
+	if clientBuffer <= 0 {
+		clientBuffer = DefaultClientBuffer
+	}
+	hub := &Hub{
+		books:        map[string]*orderbook.Book{},
+		clients:      map[*client]struct{}{},
+		sequences:    map[string]map[string]int64{},
+		feedSequence: map[string]int64{},
+		bbo:          map[string]BBO{},
+		clientBuffer: clientBuffer,
+		upgrader:     ws.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
+	}
+	for _, book := range books {
+		hub.books[book.ID] = book
+		hub.sequences[book.ID] = map[string]int64{}
+	}
+	return hub
+}
+
+func (hub *Hub) ListenAndServe(addr string) error {
+	zap.L().Info("Starting websocket broadcast server", zap.String("addr", addr))
+	return http.ListenAndServe(addr, hub)
+}
+
+func (hub *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := hub.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		zap.L().Info("Could not upgrade broadcast connection", zap.Error(err))
+		return
+	}
+	c := newClient(hub, conn)
+	hub.mu.Lock()
+	hub.clients[c] = struct{}{}
+	hub.mu.Unlock()
+	go c.writeLoop()
+	go c.readLoop()
+}
+
+// Consumer returns the HandlerConsumer to register on the handler for product.
+func (hub *Hub) Consumer(product string) gdax.HandlerConsumer {
+	return &bookConsumer{hub: hub, product: product}
+}
+
+// QueueLen is the backlog of the slowest client.
+func (hub *Hub) QueueLen() int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	longest := 0
+	for c := range hub.clients {
+		if len(c.send) > longest {
+			longest = len(c.send)
+		}
+	}
+	return longest
+}
+
+// subscribe sends the snapshot of every requested channel and registers the client for
+// updates. The book stays read locked throughout so no update can slip in between.
+func (hub *Hub) subscribe(c *client, request Request) {
+	for _, product := range request.ProductIDs {
+		book, found := hub.books[product]
+		if !found {
+			hub.mu.Lock()
+			c.sendError("unknown product " + product)
+			hub.mu.Unlock()
+			continue
+		}
+		book.View(func(book *orderbook.Book) {
+			hub.mu.Lock()
+			defer hub.mu.Unlock()
+			for _, channel := range request.Channels {
+				if !channels[channel] {
+					c.sendError("unknown channel " + channel)
+					continue
+				}
+				if c.subscribed(product, channel) {
+					continue
+				}
+				if !c.enqueue(hub.snapshot(book, channel)) {
+					hub.removeClient(c)
+					return
+				}
+				c.subscribe(product, channel)
+			}
+		})
+	}
+}
+
+func (hub *Hub) unsubscribe(c *client, request Request) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for _, product := range request.ProductIDs {
+		for _, channel := range request.Channels {
+			c.unsubscribe(product, channel)
+		}
+	}
+}
+
+func (hub *Hub) snapshot(book *orderbook.Book, channel string) interface{} {
+	head := header{Type: "snapshot", ProductID: book.ID, Channel: channel, Sequence: hub.sequences[book.ID][channel]}
+	switch channel {
+	case L3Channel:
+		return L3Snapshot{header: head, Bids: newOrders(book.GetOrders(common.BidSide)), Asks: newOrders(book.GetOrders(common.AskSide))}
+	case L2Channel:
+		return L2Snapshot{header: head, Bids: newLevels(book.GetDepth(common.BidSide, 0)), Asks: newLevels(book.GetDepth(common.AskSide, 0))}
+	case BBOChannel:
+		return BBO{header: head, Bid: topLevel(book.Bid), Ask: topLevel(book.Ask)}
+	default:
+		trades := []Trade{}
+		for _, trade := range book.RecentTrades {
+			trades = append(trades, newTrade(trade))
+		}
+		return TradesSnapshot{header: head, Trades: trades}
+	}
+}
+
+// publish assigns the next sequence for product and channel and queues the message for
+// every subscribed client. Must be called with hub.mu held.
+func (hub *Hub) publish(product string, channel string, build func(head header) interface{}) {
+	hub.sequences[product][channel] += 1
+	head := header{Type: "update", ProductID: product, Channel: channel, Sequence: hub.sequences[product][channel]}
+	var data []byte
+	for c := range hub.clients {
+		if !c.subscribed(product, channel) {
+			continue
+		}
+		if data == nil {
+			var err error
+			data, err = json.Marshal(build(head))
+			if err != nil {
+				zap.L().Error("Could not encode broadcast message", zap.Error(err))
+				return
+			}
+		}
+		if !c.enqueueRaw(data) {
+			zap.L().Info("Disconnecting slow broadcast client", zap.String("remote", c.conn.RemoteAddr().String()))
+			hub.removeClient(c)
+		}
+	}
+}
+
+// resetProduct disconnects every client of product after the book was rebuilt, since
+// their streams can no longer be continued from the snapshot they were sent.
+func (hub *Hub) resetProduct(product string) {
+	for c := range hub.clients {
+		if c.subscribedToProduct(product) {
+			c.sendError("book " + product + " was reset, reconnect for a new snapshot")
+			hub.removeClient(c)
+		}
+	}
+	delete(hub.feedSequence, product)
+	delete(hub.bbo, product)
+}
+
+// removeClient must be called with hub.mu held.
+func (hub *Hub) removeClient(c *client) {
+	if _, found := hub.clients[c]; !found {
+		return
+	}
+	delete(hub.clients, c)
+	close(c.send)
+}
+
+type bookConsumer struct {
+	hub     *Hub
+	product string
+}
+
+// BookUpdate runs on the feed goroutine after the message was applied, with the book write locked.
+func (consumer *bookConsumer) BookUpdate(message gdaxClient.Message) {
+	hub := consumer.hub
+	book := hub.books[consumer.product]
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if last, found := hub.feedSequence[consumer.product]; found && message.Sequence != last+1 {
+		hub.resetProduct(consumer.product)
+	}
+	hub.feedSequence[consumer.product] = message.Sequence
+
+	hub.publish(consumer.product, L3Channel, func(head header) interface{} {
+		return L3Update{header: head, Message: message}
+	})
+
+	if message.Price != "" && message.Type != "received" {
+		consumer.publishLevel(book, message)
+	}
+
+	bbo := BBO{Bid: topLevel(book.Bid), Ask: topLevel(book.Ask)}
+	if last, found := hub.bbo[consumer.product]; !found || !sameLevel(last.Bid, bbo.Bid) || !sameLevel(last.Ask, bbo.Ask) {
+		hub.bbo[consumer.product] = bbo
+		hub.publish(consumer.product, BBOChannel, func(head header) interface{} {
+			bbo.header = head
+			return bbo
+		})
+	}
+}
+
+func (consumer *bookConsumer) publishLevel(book *orderbook.Book, message gdaxClient.Message) {
+	side, err := gdax.ToSide(message.Side)
+	if err != nil {
+		zap.L().Error("Could not parse level side for broadcast", zap.Error(err))
+		return
+	}
+	price, err := decimal.NewFromString(message.Price)
+	if err != nil {
+		zap.L().Error("Could not parse level price for broadcast", zap.Error(err))
+		return
+	}
+	update := L2Update{Side: common.ToString(side), Price: price, Size: decimal.New(0, 0)}
+	if level, found := book.FindLevel(price, side); found {
+		update.Size = level.GetSize()
+	}
+	consumer.hub.publish(consumer.product, L2Channel, func(head header) interface{} {
+		update.header = head
+		return update
+	})
+}
+
+// Name and QueueLen export the slowest client's backlog as consumer queue lag.
+func (consumer *bookConsumer) Name() string {
+	return "broadcast"
+}
+
+func (consumer *bookConsumer) QueueLen() int {
+	return consumer.hub.QueueLen()
+}
+
+func (consumer *bookConsumer) TradeTick(msg gdax.Match) {
+	hub := consumer.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.publish(consumer.product, TradesChannel, func(head header) interface{} {
+		return TradeUpdate{header: head, Trade: newTrade(msg.Trade())}
+	})
+}
+
+func (consumer *bookConsumer) Clear() {
+	hub := consumer.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.resetProduct(consumer.product)
+}
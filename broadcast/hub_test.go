@@ -0,0 +1,150 @@
+package broadcast
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	ws "github.com/gorilla/websocket"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+var allChannels = []string{L3Channel, L2Channel, BBOChannel, TradesChannel}
+
+func dialHub(t *testing.T, hub *Hub, request Request) *ws.Conn {
+	server := httptest.NewServer(hub)
+	t.Cleanup(server.Close)
+	conn, _, err := ws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.WriteJSON(request); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func (hub *Hub) sequence(product string, channel string) int64 {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return hub.sequences[product][channel]
+}
+
+func (hub *Hub) numClients() int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.clients)
+}
+
+// subscribed reports whether there are clients and all of them are subscribed to channel.
+func (hub *Hub) subscribed(product string, channel string) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for c := range hub.clients {
+		if !c.subscribed(product, channel) {
+			return false
+		}
+	}
+	return len(hub.clients) > 0
+}
+
+func TestClientGetsSnapshotThenContiguousUpdates(t *testing.T) {
+	generator := synthetic.NewGenerator(synthetic.DefaultConfig())
+	book := orderbook.NewBook(synthetic.DefaultConfig().ProductID, nil)
+	hub := NewHub(1000000, book)
+	handler := gdax.NewHandler(nil, book, nil)
+	handler.AddHandlerConsumer(hub.Consumer(book.ID))
+	apply := func(messages []gdaxClient.Message) {
+		for _, message := range messages {
+			book.Lock()
+			err := handler.ApplyMessage(message)
+			book.Unlock()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}
+	apply(generator.Generate(2000))
+
+	// the feed keeps going while the client subscribes
+	applied := make(chan struct{})
+	go func() {
+		defer close(applied)
+		apply(generator.Generate(5000))
+	}()
+	conn := dialHub(t, hub, Request{Type: "subscribe", ProductIDs: []string{book.ID}, Channels: allChannels})
+	<-applied
+
+	last := map[string]int64{}
+	done := func() bool {
+		for _, channel := range allChannels {
+			if sequence, found := last[channel]; !found || sequence != hub.sequence(book.ID, channel) {
+				return false
+			}
+		}
+		return true
+	}
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for !done() {
+		head := header{}
+		if err := conn.ReadJSON(&head); err != nil {
+			t.Fatalf("read failed after %v: %v", last, err)
+		}
+		previous, found := last[head.Channel]
+		switch {
+		case !found && head.Type != "snapshot":
+			t.Fatalf("first %s message is %s %d, want the snapshot", head.Channel, head.Type, head.Sequence)
+		case found && (head.Type != "update" || head.Sequence != previous+1):
+			t.Fatalf("%s %s %d follows %d, want update %d", head.Channel, head.Type, head.Sequence, previous, previous+1)
+		}
+		last[head.Channel] = head.Sequence
+	}
+}
+
+func TestSlowClientIsDisconnected(t *testing.T) {
+	book := orderbook.NewBook("BTC-USD", nil)
+	hub := NewHub(4, book)
+	consumer := hub.Consumer(book.ID)
+	conn := dialHub(t, hub, Request{Type: "subscribe", ProductIDs: []string{book.ID}, Channels: []string{L3Channel}})
+	deadline := time.Now().Add(5 * time.Second)
+	for !hub.subscribed(book.ID, L3Channel) {
+		if time.Now().After(deadline) {
+			t.Fatalf("client never subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the client reads nothing, so once the socket buffers are full its queue overflows
+	published := make(chan int64)
+	go func() {
+		padding := strings.Repeat("x", 64*1024)
+		sequence := int64(1)
+		for ; sequence <= 10000 && hub.numClients() > 0; sequence++ {
+			consumer.BookUpdate(gdaxClient.Message{Type: "received", Sequence: sequence, ProductId: book.ID, Message: padding})
+		}
+		published <- sequence - 1
+	}()
+	select {
+	case sequence := <-published:
+		if hub.numClients() != 0 {
+			t.Fatalf("client still connected after %d updates it never read", sequence)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the hub blocked on a client that does not read")
+	}
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !ws.IsCloseError(err, ws.CloseNormalClosure) {
+				t.Fatalf("connection ended with %v, want a normal close after the queued messages", err)
+			}
+			break
+		}
+	}
+}
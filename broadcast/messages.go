@@ -0,0 +1,146 @@
+package broadcast
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	L3Channel     = "l3"
+	L2Channel     = "l2"
+	BBOChannel    = "bbo"
+	TradesChannel = "trades"
+)
+
+var channels = map[string]bool{L3Channel: true, L2Channel: true, BBOChannel: true, TradesChannel: true}
+
+// Request is sent by clients. Type is "subscribe" or "unsubscribe".
+type Request struct {
+	Type       string   `json:"type"`
+	ProductIDs []string `json:"product_ids"`
+	Channels   []string `json:"channels"`
+}
+
+// Every message sent to a client carries the product, channel and a sequence number
+// that is contiguous per product and channel starting after the channel's snapshot.
+type header struct {
+	Type      string `json:"type"`
+	ProductID string `json:"product_id"`
+	Channel   string `json:"channel"`
+	Sequence  int64  `json:"sequence"`
+}
+
+type Order struct {
+	ID    string          `json:"order_id"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+type Level struct {
+	Price     decimal.Decimal `json:"price"`
+	Size      decimal.Decimal `json:"size"`
+	NumOrders int             `json:"num_orders"`
+}
+
+type Trade struct {
+	TradeID      int             `json:"trade_id"`
+	MakerOrderID string          `json:"maker_order_id"`
+	TakerOrderID string          `json:"taker_order_id"`
+	Time         time.Time       `json:"time"`
+	Price        decimal.Decimal `json:"price"`
+	Size         decimal.Decimal `json:"size"`
+	Side         string          `json:"side"`
+}
+
+type L3Snapshot struct {
+	header
+	Bids []Order `json:"bids"`
+	Asks []Order `json:"asks"`
+}
+
+type L2Snapshot struct {
+	header
+	Bids []Level `json:"bids"`
+	Asks []Level `json:"asks"`
+}
+
+type TradesSnapshot struct {
+	header
+	Trades []Trade `json:"trades"`
+}
+
+type L3Update struct {
+	header
+	Message gdaxClient.Message `json:"message"`
+}
+
+// L2Update carries the new aggregate size of a level. A zero size removes the level.
+type L2Update struct {
+	header
+	Side  string          `json:"side"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+type BBO struct {
+	header
+	Bid *Level `json:"bid"`
+	Ask *Level `json:"ask"`
+}
+
+type TradeUpdate struct {
+	header
+	Trade
+}
+
+type Error struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func newOrders(orders []*orderbook.Order) []Order {
+	converted := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		converted = append(converted, Order{ID: order.ID, Price: order.Price, Size: order.Size})
+	}
+	return converted
+}
+
+func newLevels(depth []orderbook.DepthLevel) []Level {
+	levels := make([]Level, 0, len(depth))
+	for _, level := range depth {
+		levels = append(levels, Level{Price: level.Price, Size: level.Size, NumOrders: level.NumOrders})
+	}
+	return levels
+}
+
+func newTrade(trade orderbook.Trade) Trade {
+	return Trade{
+		TradeID:      trade.TradeID,
+		MakerOrderID: trade.MakerOrderID,
+		TakerOrderID: trade.TakerOrderID,
+		Time:         trade.Time,
+		Price:        trade.Price,
+		Size:         trade.Size,
+		Side:         common.ToString(trade.MakerSide),
+	}
+}
+
+func topLevel(bookSide *orderbook.BookSide) *Level {
+	level, err := bookSide.GetTopLevel()
+	if err != nil {
+		return nil
+	}
+	return &Level{Price: level.Price, Size: level.GetSize(), NumOrders: level.GetNumOrders()}
+}
+
+func sameLevel(a *Level, b *Level) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Price.Equal(b.Price) && a.Size.Equal(b.Size) && a.NumOrders == b.NumOrders
+}
@@ -0,0 +1,90 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	Topic string
+	// Key is the product ID so brokers that partition by key keep a product's events in order.
+	Key   []byte
+	Value []byte
+}
+
+// Broker delivers batches of messages. Publish either delivers the whole batch or returns an error.
+type Broker interface {
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// NewBroker connects to the broker named by driver: "nats" (urls are joined into one
+// server list), "kafka" (urls are the bootstrap brokers) or "memory".
+func NewBroker(driver string, urls []string) (Broker, error) {
+	switch driver {
+	case "nats":
+		return NewNatsBroker(strings.Join(urls, ","))
+	case "kafka":
+		return NewKafkaBroker(urls), nil
+	case "memory":
+		return NewMemoryBroker(), nil
+	}
+	return nil, fmt.Errorf("Bus driver %s is not supported", driver)
+}
+
+// MemoryBroker is an in-process Broker for running the publisher without a live cluster.
+// FailNext makes the next n Publish calls fail with Err.
+type MemoryBroker struct {
+	mu       sync.Mutex
+	messages map[string][]Message
+	failures int
+	Err      error
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{messages: map[string][]Message{}, Err: context.DeadlineExceeded}
+}
+
+func (broker *MemoryBroker) Publish(ctx context.Context, messages []Message) error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.failures > 0 {
+		broker.failures -= 1
+		return broker.Err
+	}
+	for _, message := range messages {
+		broker.messages[message.Topic] = append(broker.messages[message.Topic], message)
+	}
+	return nil
+}
+
+func (broker *MemoryBroker) FailNext(n int) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	broker.failures = n
+}
+
+// Messages returns what was published to topic, in order.
+func (broker *MemoryBroker) Messages(topic string) []Message {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	messages := make([]Message, len(broker.messages[topic]))
+	copy(messages, broker.messages[topic])
+	return messages
+}
+
+func (broker *MemoryBroker) Topics() []string {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	topics := make([]string, 0, len(broker.messages))
+	for topic := range broker.messages {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+func (broker *MemoryBroker) Close() error {
+	return nil
+}
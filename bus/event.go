@@ -0,0 +1,84 @@
+package bus
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+const (
+	ReceivedEvent = "received"
+	OpenEvent     = "open"
+	DoneEvent     = "done"
+	ChangeEvent   = "change"
+	MatchEvent    = "match"
+	TradeEvent    = "trade"
+	ResetEvent    = "reset"
+)
+
+// Event is the normalized form of every book and trade event. Decimal fields are
+// strings so no precision is lost and absent fields are omitted.
+type Event struct {
+	Type         string    `json:"type"`
+	ProductID    string    `json:"product_id"`
+	Sequence     int64     `json:"sequence,omitempty"`
+	Time         time.Time `json:"time"`
+	OrderID      string    `json:"order_id,omitempty"`
+	Side         string    `json:"side,omitempty"`
+	Price        string    `json:"price,omitempty"`
+	Size         string    `json:"size,omitempty"`
+	NewSize      string    `json:"new_size,omitempty"`
+	OldSize      string    `json:"old_size,omitempty"`
+	Funds        string    `json:"funds,omitempty"`
+	OrderType    string    `json:"order_type,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	TradeID      int       `json:"trade_id,omitempty"`
+	MakerOrderID string    `json:"maker_order_id,omitempty"`
+	TakerOrderID string    `json:"taker_order_id,omitempty"`
+}
+
+func newBookEvent(message gdaxClient.Message) Event {
+	event := Event{
+		Type:         message.Type,
+		ProductID:    message.ProductId,
+		Sequence:     message.Sequence,
+		Time:         message.Time.Time(),
+		OrderID:      message.OrderId,
+		Price:        message.Price,
+		Funds:        message.Funds,
+		OrderType:    message.OrderType,
+		Reason:       message.Reason,
+		NewSize:      message.NewSize,
+		OldSize:      message.OldSize,
+		TradeID:      message.TradeId,
+		MakerOrderID: message.MakerOrderId,
+		TakerOrderID: message.TakerOrderId,
+	}
+	if side, err := gdax.ToSide(message.Side); err == nil {
+		event.Side = common.ToString(side)
+	}
+	switch message.Type {
+	case OpenEvent, DoneEvent:
+		event.Size = message.RemainingSize
+	default:
+		event.Size = message.Size
+	}
+	return event
+}
+
+func newTradeEvent(match gdax.Match) Event {
+	return Event{
+		Type:         TradeEvent,
+		ProductID:    match.ProductID,
+		Sequence:     match.Sequence,
+		Time:         match.Time.Time(),
+		Side:         common.ToString(match.MatchSide),
+		Price:        match.Price.String(),
+		Size:         match.Size.String(),
+		TradeID:      match.TradeID,
+		MakerOrderID: match.MakerOrderID,
+		TakerOrderID: match.TakerOrderID,
+	}
+}
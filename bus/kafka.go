@@ -0,0 +1,38 @@
+package bus
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaBroker writes each batch synchronously, hashing the key so a product always
+// lands on the same partition.
+type KafkaBroker struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaBroker(brokers []string) *KafkaBroker {
+	return &KafkaBroker{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}}
+}
+
+func (broker *KafkaBroker) Publish(ctx context.Context, messages []Message) error {
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		kafkaMessages = append(kafkaMessages, kafka.Message{Topic: message.Topic, Key: message.Key, Value: message.Value})
+	}
+	if err := broker.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		return errors.Wrap(err, "Could not write to Kafka")
+	}
+	return nil
+}
+
+func (broker *KafkaBroker) Close() error {
+	return broker.writer.Close()
+}
@@ -0,0 +1,39 @@
+package bus
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+// NatsBroker publishes each message to the subject named by its topic. The key is sent
+// as the Nats-Msg-Key header since NATS subjects are already per product.
+type NatsBroker struct {
+	conn *nats.Conn
+}
+
+func NewNatsBroker(url string) (*NatsBroker, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not connect to NATS")
+	}
+	return &NatsBroker{conn: conn}, nil
+}
+
+func (broker *NatsBroker) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(message.Topic)
+		msg.Header.Set("Nats-Msg-Key", string(message.Key))
+		msg.Data = message.Value
+		if err := broker.conn.PublishMsg(msg); err != nil {
+			return errors.Wrap(err, "Could not publish to NATS")
+		}
+	}
+	return broker.conn.FlushWithContext(ctx)
+}
+
+func (broker *NatsBroker) Close() error {
+	broker.conn.Close()
+	return nil
+}
@@ -0,0 +1,202 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+)
+
+type PublisherConfig struct {
+	// TopicFormat names the topic of every event. {product} and {event} are replaced
+	// with the product ID and the event type.
+	TopicFormat   string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	RetryBackoff  time.Duration
+	// QueueSize is how many events may wait for the broker before new ones are dropped.
+	QueueSize int
+}
+
+var DefaultPublisherConfig = PublisherConfig{
+	TopicFormat:   "orderbook.{product}.{event}",
+	BatchSize:     500,
+	FlushInterval: 100 * time.Millisecond,
+	MaxRetries:    5,
+	RetryBackoff:  100 * time.Millisecond,
+	QueueSize:     65536,
+}
+
+// Publisher batches book and trade events onto a Broker from a background goroutine so
+// the feed never waits on the bus.
+type Publisher struct {
+	broker  Broker
+	config  PublisherConfig
+	queue   chan Message
+	done    chan struct{}
+	dropped int64
+
+	// mu guards closing queue: enqueue holds it for reading, Close for writing
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewPublisher starts publishing to broker. Zero fields in config take their value from DefaultPublisherConfig.
+func NewPublisher(broker Broker, config PublisherConfig) *Publisher {
+	if config.TopicFormat == "" {
+		config.TopicFormat = DefaultPublisherConfig.TopicFormat
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultPublisherConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultPublisherConfig.FlushInterval
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultPublisherConfig.MaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultPublisherConfig.RetryBackoff
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultPublisherConfig.QueueSize
+	}
+	publisher := &Publisher{
+		broker: broker,
+		config: config,
+		queue:  make(chan Message, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go publisher.run()
+	return publisher
+}
+
+func (publisher *Publisher) Topic(product string, eventType string) string {
+	return strings.NewReplacer("{product}", product, "{event}", eventType).Replace(publisher.config.TopicFormat)
+}
+
+// Consumer returns the HandlerConsumer to register on the handler for product.
+func (publisher *Publisher) Consumer(product string) gdax.HandlerConsumer {
+	return &bookConsumer{publisher: publisher, product: product}
+}
+
+// Dropped is the number of events lost to a full queue or a broker that kept failing.
+func (publisher *Publisher) Dropped() int64 {
+	return atomic.LoadInt64(&publisher.dropped)
+}
+
+// Close publishes what is queued and closes the broker. Events from handlers that are still
+// running are ignored from then on.
+func (publisher *Publisher) Close() error {
+	publisher.mu.Lock()
+	if publisher.closed {
+		publisher.mu.Unlock()
+		return nil
+	}
+	publisher.closed = true
+	close(publisher.queue)
+	publisher.mu.Unlock()
+	<-publisher.done
+	return publisher.broker.Close()
+}
+
+func (publisher *Publisher) enqueue(product string, event Event) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		zap.L().Error("Could not encode bus event", zap.Error(err))
+		return
+	}
+	message := Message{Topic: publisher.Topic(product, event.Type), Key: []byte(product), Value: value}
+	publisher.mu.RLock()
+	defer publisher.mu.RUnlock()
+	if publisher.closed {
+		return
+	}
+	select {
+	case publisher.queue <- message:
+	default:
+		if atomic.AddInt64(&publisher.dropped, 1)%1000 == 1 {
+			zap.L().Error("Bus publisher queue is full, dropping events", zap.Int64("dropped", publisher.Dropped()))
+		}
+	}
+}
+
+func (publisher *Publisher) run() {
+	defer close(publisher.done)
+	ticker := time.NewTicker(publisher.config.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]Message, 0, publisher.config.BatchSize)
+	for {
+		select {
+		case message, ok := <-publisher.queue:
+			if !ok {
+				publisher.flush(batch)
+				return
+			}
+			batch = append(batch, message)
+			if len(batch) >= publisher.config.BatchSize {
+				publisher.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			publisher.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush retries with exponential backoff. A batch is dropped once MaxRetries is exhausted
+// rather than holding up every event behind it.
+func (publisher *Publisher) flush(batch []Message) {
+	if len(batch) == 0 {
+		return
+	}
+	backoff := publisher.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := publisher.broker.Publish(context.Background(), batch)
+		if err == nil {
+			return
+		}
+		if attempt == publisher.config.MaxRetries {
+			atomic.AddInt64(&publisher.dropped, int64(len(batch)))
+			zap.L().Error("Could not publish batch to bus, dropping it", zap.Int("size", len(batch)), zap.Error(err))
+			return
+		}
+		zap.L().Info("Could not publish batch to bus, retrying", zap.Int("attempt", attempt+1), zap.Error(err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+type bookConsumer struct {
+	publisher *Publisher
+	product   string
+}
+
+func (consumer *bookConsumer) BookUpdate(message gdaxClient.Message) {
+	consumer.publisher.enqueue(consumer.product, newBookEvent(message))
+}
+
+func (consumer *bookConsumer) TradeTick(msg gdax.Match) {
+	consumer.publisher.enqueue(consumer.product, newTradeEvent(msg))
+}
+
+// Clear tells downstream consumers to discard their state for the product.
+func (consumer *bookConsumer) Clear() {
+	consumer.publisher.enqueue(consumer.product, Event{Type: ResetEvent, ProductID: consumer.product, Time: time.Now()})
+}
+
+func (consumer *bookConsumer) Name() string {
+	return "bus"
+}
+
+func (consumer *bookConsumer) QueueLen() int {
+	return len(consumer.publisher.queue)
+}
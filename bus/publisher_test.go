@@ -0,0 +1,215 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// batchRecorder is a MemoryBroker that remembers the size of every batch it was asked to publish,
+// including the ones that failed.
+type batchRecorder struct {
+	*MemoryBroker
+	mu      sync.Mutex
+	batches []int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{MemoryBroker: NewMemoryBroker()}
+}
+
+func (broker *batchRecorder) Publish(ctx context.Context, messages []Message) error {
+	broker.mu.Lock()
+	broker.batches = append(broker.batches, len(messages))
+	broker.mu.Unlock()
+	return broker.MemoryBroker.Publish(ctx, messages)
+}
+
+func (broker *batchRecorder) attempts() []int {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	return append([]int{}, broker.batches...)
+}
+
+// waitForAttempts waits until n batches were attempted.
+func (broker *batchRecorder) waitForAttempts(t *testing.T, n int) []int {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(broker.attempts()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("attempted %v, want %d batches", broker.attempts(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return broker.attempts()
+}
+
+func open(product string, sequence int64) gdaxClient.Message {
+	return gdaxClient.Message{Type: "open", ProductId: product, Sequence: sequence, OrderId: "o", RemainingSize: "1", Price: "100.00", Side: "buy"}
+}
+
+func decodeEvent(t *testing.T, message Message) Event {
+	event := Event{}
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func equalBatches(got []int, want []int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTopic(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "", want: "orderbook.BTC-USD.open"},
+		{format: "md.{event}", want: "md.open"},
+		{format: "{product}-{event}-{product}", want: "BTC-USD-open-BTC-USD"},
+		{format: "book", want: "book"},
+	}
+	for _, test := range tests {
+		broker := NewMemoryBroker()
+		publisher := NewPublisher(broker, PublisherConfig{TopicFormat: test.format})
+		publisher.Consumer("BTC-USD").BookUpdate(open("BTC-USD", 1))
+		publisher.Close()
+		if topics := broker.Topics(); len(topics) != 1 || topics[0] != test.want {
+			t.Fatalf("format %q published to %v, want %s", test.format, topics, test.want)
+		}
+	}
+}
+
+func TestEventsAreKeyedByProduct(t *testing.T) {
+	broker := NewMemoryBroker()
+	publisher := NewPublisher(broker, PublisherConfig{})
+	products := []string{"BTC-USD", "ETH-USD"}
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		for _, product := range products {
+			publisher.Consumer(product).BookUpdate(open(product, sequence))
+		}
+	}
+	match, err := gdax.NewMatchMessage(7, 4, "m", "t", gdaxClient.Time(time.Now()), "BTC-USD", "1", "100.00", "sell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher.Consumer("BTC-USD").TradeTick(match)
+	publisher.Consumer("ETH-USD").Clear()
+	publisher.Close()
+
+	for _, product := range products {
+		messages := broker.Messages(publisher.Topic(product, OpenEvent))
+		if len(messages) != 3 {
+			t.Fatalf("%d %s open events, want 3", len(messages), product)
+		}
+		for i, message := range messages {
+			event := decodeEvent(t, message)
+			if string(message.Key) != product || event.ProductID != product || event.Sequence != int64(i+1) {
+				t.Fatalf("event %d is %+v keyed %s, want %s sequence %d", i, event, message.Key, product, i+1)
+			}
+		}
+	}
+	trades := broker.Messages(publisher.Topic("BTC-USD", TradeEvent))
+	if len(trades) != 1 || string(trades[0].Key) != "BTC-USD" || decodeEvent(t, trades[0]).TradeID != 7 {
+		t.Fatalf("trades are %v, want trade 7 keyed BTC-USD", trades)
+	}
+	resets := broker.Messages(publisher.Topic("ETH-USD", ResetEvent))
+	if len(resets) != 1 || string(resets[0].Key) != "ETH-USD" {
+		t.Fatalf("resets are %v, want one keyed ETH-USD", resets)
+	}
+}
+
+func TestPublisherBatches(t *testing.T) {
+	t.Run("full batches go out at once and Close flushes the rest", func(t *testing.T) {
+		broker := newBatchRecorder()
+		publisher := NewPublisher(broker, PublisherConfig{BatchSize: 3, FlushInterval: time.Hour})
+		for sequence := int64(1); sequence <= 7; sequence++ {
+			publisher.Consumer("BTC-USD").BookUpdate(open("BTC-USD", sequence))
+		}
+		if batches := broker.waitForAttempts(t, 2); !equalBatches(batches, []int{3, 3}) {
+			t.Fatalf("published batches of %v before Close, want 3 and 3", batches)
+		}
+		publisher.Close()
+		if batches := broker.attempts(); !equalBatches(batches, []int{3, 3, 1}) {
+			t.Fatalf("published batches of %v, want 3, 3 and the 1 left at Close", batches)
+		}
+	})
+	t.Run("partial batches go out every flush interval", func(t *testing.T) {
+		broker := newBatchRecorder()
+		publisher := NewPublisher(broker, PublisherConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+		defer publisher.Close()
+		publisher.Consumer("BTC-USD").BookUpdate(open("BTC-USD", 1))
+		if batches := broker.waitForAttempts(t, 1); !equalBatches(batches, []int{1}) {
+			t.Fatalf("published batches of %v, want the event on its own", batches)
+		}
+	})
+}
+
+func TestPublisherRetriesThenDrops(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		attempts  []int
+		delivered int
+		dropped   int64
+	}{
+		{name: "recovers within the retries", failures: 2, attempts: []int{2, 2, 2, 1}, delivered: 3},
+		{name: "drops the batch once retries run out", failures: 4, attempts: []int{2, 2, 2, 2, 1}, delivered: 1, dropped: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			broker := newBatchRecorder()
+			broker.FailNext(test.failures)
+			publisher := NewPublisher(broker, PublisherConfig{BatchSize: 2, FlushInterval: time.Hour, MaxRetries: 3, RetryBackoff: time.Millisecond})
+			for sequence := int64(1); sequence <= 3; sequence++ {
+				publisher.Consumer("BTC-USD").BookUpdate(open("BTC-USD", sequence))
+			}
+			publisher.Close()
+			if batches := broker.attempts(); !equalBatches(batches, test.attempts) {
+				t.Fatalf("attempted batches of %v, want %v", batches, test.attempts)
+			}
+			// a dropped batch does not hold up the ones behind it
+			messages := broker.Messages(publisher.Topic("BTC-USD", OpenEvent))
+			if len(messages) != test.delivered || decodeEvent(t, messages[len(messages)-1]).Sequence != 3 {
+				t.Fatalf("%d events delivered, want %d ending with sequence 3", len(messages), test.delivered)
+			}
+			if publisher.Dropped() != test.dropped {
+				t.Fatalf("%d events dropped, want %d", publisher.Dropped(), test.dropped)
+			}
+		})
+	}
+}
+
+func TestEnqueueAfterCloseIsIgnored(t *testing.T) {
+	broker := NewMemoryBroker()
+	publisher := NewPublisher(broker, PublisherConfig{})
+	consumer := publisher.Consumer("BTC-USD")
+	consumer.BookUpdate(open("BTC-USD", 1))
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	consumer.BookUpdate(open("BTC-USD", 2))
+	consumer.Clear()
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if messages := broker.Messages(publisher.Topic("BTC-USD", OpenEvent)); len(messages) != 1 || publisher.Dropped() != 0 {
+		t.Fatalf("%d events published and %d dropped, want only the one before Close", len(messages), publisher.Dropped())
+	}
+	if topics := broker.Topics(); len(topics) != 1 {
+		t.Fatalf("published to %v, want nothing after Close", topics)
+	}
+}
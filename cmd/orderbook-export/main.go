@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/export"
+)
+
+var (
+	journalDir = flag.String("journal", "", "journal directory (journal.dir)")
+	product    = flag.String("product", "BTC-USD", "product to export")
+	out        = flag.String("out", "export", "directory to write the datasets to")
+	format     = flag.String("format", "parquet", "csv or parquet")
+	levels     = flag.Int("levels", 10, "depth levels per side in each sample")
+	interval   = flag.Duration("interval", time.Second, "feed time between depth samples, 0 to skip depth")
+)
+
+func main() {
+	flag.Parse()
+	err := export.ExportJournal(*journalDir, *product, export.Config{
+		Dir:            *out,
+		Format:         export.Format(*format),
+		Levels:         *levels,
+		SampleInterval: *interval,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
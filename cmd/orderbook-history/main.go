@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/history"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+)
+
+var (
+	checkpointDir = flag.String("checkpoints", "", "directory of kept checkpoints (checkpoint.historyDir)")
+	journalDir    = flag.String("journal", "", "journal directory (journal.dir)")
+	product       = flag.String("product", "BTC-USD", "product to rebuild")
+	sequence      = flag.Int64("sequence", 0, "rebuild the book right after this sequence number")
+	at            = flag.String("time", "", "rebuild the book as of this time, RFC 3339 (UTC when no zone is given)")
+	levels        = flag.Int("levels", 10, "price levels per side to print, 0 for all")
+	l3            = flag.Bool("l3", false, "print every resting order instead of price levels")
+	list          = flag.Bool("list", false, "list the kept checkpoints and exit")
+)
+
+type level struct {
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	NumOrders int    `json:"num_orders"`
+}
+
+type state struct {
+	Product            string      `json:"product"`
+	Sequence           int64       `json:"sequence"`
+	Updated            time.Time   `json:"updated"`
+	CheckpointSequence int64       `json:"checkpoint_sequence,omitempty"`
+	Replayed           int         `json:"replayed"`
+	Bids               interface{} `json:"bids"`
+	Asks               interface{} `json:"asks"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	store := history.NewStore(*checkpointDir, *journalDir)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if *list {
+		checkpoints, err := store.Checkpoints(*product)
+		if err != nil {
+			return err
+		}
+		for _, checkpoint := range checkpoints {
+			fmt.Printf("%d\t%s\t%s\n", checkpoint.Sequence, checkpoint.Time.Format(time.RFC3339Nano), checkpoint.Path)
+		}
+		return nil
+	}
+
+	query := history.Query{Sequence: *sequence}
+	if *at != "" {
+		parsed, err := parseTime(*at)
+		if err != nil {
+			return err
+		}
+		query.Time = parsed
+	}
+	result, err := store.BookAt(*product, query)
+	if err != nil {
+		return err
+	}
+	book := result.Book
+	out := state{Product: book.ID, Sequence: book.Sequence, Updated: book.Updated, Replayed: result.Replayed}
+	if result.Checkpoint != nil {
+		out.CheckpointSequence = result.Checkpoint.Sequence
+	}
+	if *l3 {
+		checkpoint := book.Checkpoint()
+		out.Bids, out.Asks = checkpoint.Bids, checkpoint.Asks
+	} else {
+		out.Bids, out.Asks = depth(book, common.BidSide), depth(book, common.AskSide)
+	}
+	return encoder.Encode(out)
+}
+
+func depth(book *orderbook.Book, side common.Side) []level {
+	depth := []level{}
+	for _, depthLevel := range book.GetDepth(side, *levels) {
+		depth = append(depth, level{Price: depthLevel.Price.String(), Size: depthLevel.Size.String(), NumOrders: depthLevel.NumOrders})
+	}
+	return depth
+}
+
+// parseTime takes RFC 3339 with or without a zone, e.g. 2018-03-01T14:03:07.120.
+func parseTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02T15:04:05.999999999", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Time %q is not RFC 3339", value)
+	}
+	return parsed, nil
+}
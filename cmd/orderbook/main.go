@@ -1,51 +1,303 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/chrischris292/go-gdax-orderbook/api"
+	"github.com/chrischris292/go-gdax-orderbook/broadcast"
 	"github.com/chrischris292/go-gdax-orderbook/common/util"
 	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
 	"github.com/chrischris292/go-gdax-orderbook/gdax"
-	raven "github.com/getsentry/raven-go"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/report"
+	"github.com/chrischris292/go-gdax-orderbook/rpc"
 	"github.com/jinzhu/configor"
 	gdaxClient "github.com/preichenberger/go-gdax"
 	"go.uber.org/zap"
 )
 
+// command is one subcommand of the CLI. run gets the arguments after the command name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"run", "follow products live with every feature the config enables", runCommand},
+		{"record", "journal the raw feed of products", recordCommand},
+		{"replay", "rebuild books from a journal and print stats", replayCommand},
+		{"verify", "compare books replayed from a journal to stored checkpoints", verifyCommand},
+		{"serve", "serve the API, broadcast and gRPC servers over live or replayed books", serveCommand},
+		{"watch", "show a live depth ladder, trades and feed state of a product in the terminal", watchCommand},
+	}
+}
+
 func main() {
+	// without a command, run as before commands existed
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	for _, c := range commands() {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(args); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: orderbook <command> [flags]\n\nCommands:")
+	for _, c := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun orderbook <command> -h for the flags of a command.")
+}
 
-	// Setup config/sentry/logging
-	err := configor.Load(&config.AppConfig, "config/orderbook/config.yml")
+// newFlagSet returns the flags of a command, which always include the config flags.
+func newFlagSet(name string, summary string) (*flag.FlagSet, *config.Flags) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: orderbook %s [flags]\n\n%s.\n\nFlags:\n", name, strings.ToUpper(summary[:1])+summary[1:])
+		flags.PrintDefaults()
+	}
+	return flags, config.AddFlags(flags)
+}
+
+// loadConfig parses args and loads the config. paths maps config fields to the flags that
+// override them, e.g. "journal.dir" to -journal; empty flags leave the config alone.
+func loadConfig(flags *flag.FlagSet, configFlags *config.Flags, args []string, paths map[string]*string) (config.Config, error) {
+	if err := flags.Parse(args); err != nil {
+		return config.Config{}, err
+	}
+	if flags.NArg() > 0 {
+		return config.Config{}, fmt.Errorf("Unexpected arguments %v", flags.Args())
+	}
+	for field, value := range paths {
+		if *value != "" {
+			configFlags.Set = append(configFlags.Set, field+"="+*value)
+		}
+	}
+	return config.Load(configFlags)
+}
+
+// startLogging sets up zap and the error reporter of a command that follows the live feed.
+func startLogging(appConfig config.Config) *report.Async {
+	util.InitializeZap(appConfig.ZapConfig)
+	reporter, err := newErrorReporter(appConfig)
 	if err != nil {
-		fmt.Printf("Could not load config: %v", err)
-		raven.CaptureError(err, nil)
-		panic(nil)
+		// the config was validated, so only a bad Sentry DSN gets here
+		zap.L().Fatal("Could not create error reporter", zap.Error(err))
 	}
+	zap.L().Info("Starting", zap.Strings("products", appConfig.Products))
+	return reporter
+}
 
-	err = raven.SetDSN(config.AppConfig.Sentry.Dsn)
+// newErrorReporter sends errors to the configured reporter in the background.
+func newErrorReporter(appConfig config.Config) (*report.Async, error) {
+	name := appConfig.Errors.Reporter
+	if name == "" {
+		name = "log"
+		if appConfig.Sentry.Dsn != "" {
+			name = "sentry"
+		}
+	}
+	reporter, err := report.Named(name, appConfig.Sentry.Dsn, configor.ENV())
 	if err != nil {
-		panic(err)
-	}
-	raven.SetEnvironment(configor.ENV())
-	raven.CaptureMessageAndWait("Starting", nil)
-	util.InitializeZap(config.AppConfig.ZapConfig)
-
-	ravenErr, _ := raven.CapturePanicAndWait(func() {
-		client := gdaxClient.NewClient(config.AppConfig.Coinbase.CB_Secret, config.AppConfig.Coinbase.CB_Key, config.AppConfig.Coinbase.CB_Passphrase)
-		client.BaseURL = config.AppConfig.Coinbase.CB_REST_API
-		// Initialize Order Book
-		book := gdax.NewBook("BTC-USD")
-		gdaxHandler := gdax.NewHandler(client, book)
-		go gdaxHandler.Run()
-
-		// Block main thread until Sigterm signal received
-		c := make(chan os.Signal)
-		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-		<-c
-		raven.CaptureMessageAndWait("Shutting down", nil)
-	}, nil)
-	zap.L().Fatal(fmt.Sprintf("%v", ravenErr))
+		return nil, err
+	}
+	return report.NewAsync(reporter, report.AsyncConfig{
+		QueueSize:    appConfig.Errors.QueueSize,
+		MaxPerMinute: appConfig.Errors.MaxPerMinute,
+		DedupWindow:  time.Duration(appConfig.Errors.DedupSeconds) * time.Second,
+	}), nil
+}
+
+// newHandlers builds a book and handler per product and, when journal.dir is set, journals
+// their raw feed. Close the writers on shutdown.
+func newHandlers(appConfig config.Config, reporter report.ErrorReporter) ([]*gdax.Handler, []*journal.Writer, error) {
+	coinbase := appConfig.Coinbase
+	client := gdaxClient.NewClient(coinbase.Secret, coinbase.Key, coinbase.Passphrase)
+	client.BaseURL = coinbase.RestURL
+	handlers := []*gdax.Handler{}
+	writers := []*journal.Writer{}
+	for _, product := range appConfig.Products {
+		gdaxHandler, err := newHandler(appConfig, client, product, reporter)
+		if err != nil {
+			return nil, writers, err
+		}
+		if appConfig.Journal.Dir != "" {
+			journalWriter, err := journal.NewWriter(appConfig.Journal.Dir, product)
+			if err != nil {
+				return nil, writers, err
+			}
+			gdaxHandler.AddRawFeedListener(journalWriter)
+			writers = append(writers, journalWriter)
+		}
+		handlers = append(handlers, gdaxHandler)
+	}
+	return handlers, writers, nil
+}
+
+// newHandler builds the book of a product and the handler that follows it on Coinbase.
+func newHandler(appConfig config.Config, client *gdaxClient.Client, product string, reporter report.ErrorReporter) (*gdax.Handler, error) {
+	book := orderbook.NewBook(product, reporter)
+	if appConfig.Book.FixedPoint {
+		scale, err := gdax.ProductScale(client, product, appConfig.Book.SizeLot)
+		if err != nil {
+			return nil, err
+		}
+		book = orderbook.NewFixedBook(product, scale, reporter)
+	}
+	coinbase := appConfig.Coinbase
+	gdaxHandler := gdax.NewHandler(client, book, reporter)
+	gdaxHandler.SetConnection(gdax.ConnectionConfig{
+		WebsocketURL: coinbase.WebsocketURL,
+		Credentials:  gdax.Credentials{Key: coinbase.Key, Secret: coinbase.Secret, Passphrase: coinbase.Passphrase},
+		Backoff: gdax.BackoffConfig{
+			Initial: time.Duration(appConfig.Backoff.InitialMs) * time.Millisecond,
+			Max:     time.Duration(appConfig.Backoff.MaxMs) * time.Millisecond,
+		},
+	})
+	gdaxHandler.SetFeedMode(gdax.FeedMode(appConfig.Book.Feed))
+	if coinbase.Key != "" {
+		gdaxHandler.TrackOwnOrders(gdax.NewOwnOrders())
+	}
+	integrity := gdax.IntegrityConfig{StaleOrderAge: time.Duration(appConfig.Integrity.StaleOrderSeconds) * time.Second}
+	for _, kind := range appConfig.Integrity.Resync {
+		integrity.Resync = append(integrity.Resync, gdax.AnomalyKind(kind))
+	}
+	gdaxHandler.MonitorIntegrity(integrity)
+	gdaxHandler.SetGapPolicy(gdax.GapConfig{
+		Policy:            gdax.ResyncPolicy(appConfig.Gaps.Policy),
+		ReorderWindow:     time.Duration(appConfig.Gaps.ReorderWindowMs) * time.Millisecond,
+		ReorderBuffer:     appConfig.Gaps.ReorderBuffer,
+		MinResyncInterval: time.Duration(appConfig.Gaps.MinResyncIntervalMs) * time.Millisecond,
+		RecentGaps:        appConfig.Gaps.RecentGaps,
+	})
+	gdaxHandler.SetWatchdog(gdax.WatchdogConfig{
+		ReadTimeout:      time.Duration(appConfig.Watchdog.ReadTimeoutSeconds) * time.Second,
+		PingInterval:     time.Duration(appConfig.Watchdog.PingIntervalSeconds) * time.Second,
+		HeartbeatTimeout: time.Duration(appConfig.Watchdog.HeartbeatTimeoutSeconds) * time.Second,
+		SequenceStall:    time.Duration(appConfig.Watchdog.SequenceStallSeconds) * time.Second,
+	})
+	if appConfig.Checkpoint.Dir != "" {
+		interval := time.Duration(appConfig.Checkpoint.IntervalSeconds) * time.Second
+		gdaxHandler.EnableCheckpoints(appConfig.Checkpoint.Dir, interval, appConfig.Journal.Dir)
+		if appConfig.Checkpoint.HistoryDir != "" {
+			gdaxHandler.KeepCheckpointHistory(appConfig.Checkpoint.HistoryDir)
+		}
+	}
+	return gdaxHandler, nil
+}
+
+func handlerBooks(handlers []*gdax.Handler) []*orderbook.Book {
+	books := []*orderbook.Book{}
+	for _, gdaxHandler := range handlers {
+		books = append(books, gdaxHandler.Book())
+	}
+	return books
+}
+
+// startServers starts the configured metrics, broadcast, gRPC and API servers over the books of
+// handlers. live books also report their heartbeats on /health.
+func startServers(appConfig config.Config, handlers []*gdax.Handler, consolidated *orderbook.Consolidated, live bool) {
+	books := handlerBooks(handlers)
+	if appConfig.Metrics.Addr != "" {
+		go func() {
+			err := metrics.Serve(appConfig.Metrics.Addr)
+			zap.L().Error("Metrics server stopped", zap.Error(err))
+		}()
+	}
+	if appConfig.Broadcast.Addr != "" {
+		hub := broadcast.NewHub(appConfig.Broadcast.ClientBuffer, books...)
+		for _, gdaxHandler := range handlers {
+			gdaxHandler.AddHandlerConsumer(hub.Consumer(gdaxHandler.Book().ID))
+		}
+		go func() {
+			err := hub.ListenAndServe(appConfig.Broadcast.Addr)
+			zap.L().Error("Broadcast server stopped", zap.Error(err))
+		}()
+	}
+	if appConfig.RPC.Addr != "" {
+		rpcServer := rpc.NewServer(appConfig.RPC.ReplayBuffer, books...)
+		for _, gdaxHandler := range handlers {
+			gdaxHandler.AddHandlerConsumer(rpcServer.Consumer(gdaxHandler.Book().ID))
+		}
+		go func() {
+			err := rpcServer.ListenAndServe(appConfig.RPC.Addr)
+			zap.L().Error("gRPC server stopped", zap.Error(err))
+		}()
+	}
+	if appConfig.API.Addr != "" {
+		apiServer := api.NewServer(books...)
+		for _, gdaxHandler := range handlers {
+			apiServer.AddGapSource(gdaxHandler.Book().ID, gdaxHandler)
+			if live {
+				apiServer.AddHeartbeatSource(gdaxHandler.Book().ID, gdaxHandler)
+			}
+		}
+		if consolidated != nil {
+			apiServer.AddConsolidated(consolidated)
+		}
+		go func() {
+			err := apiServer.ListenAndServe(appConfig.API.Addr)
+			zap.L().Error("API server stopped", zap.Error(err))
+		}()
+	}
+}
+
+// waitForSignal blocks until the process is interrupted or terminated.
+func waitForSignal() {
+	c := make(chan os.Signal)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+	zap.L().Info("Shutting down")
+}
+
+func closeJournals(writers []*journal.Writer) {
+	for _, writer := range writers {
+		if err := writer.Close(); err != nil {
+			zap.L().Error("Could not close journal", zap.Error(err))
+		}
+	}
+}
+
+// writeJSON writes value to path, or stdout when path is empty.
+func writeJSON(path string, value interface{}) error {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/report"
+	"github.com/pkg/errors"
+)
+
+func recordCommand(args []string) error {
+	flags, configFlags := newFlagSet("record", "journal the raw feed of products")
+	out := flags.String("out", "", "journal directory (journal.dir)")
+	appConfig, err := loadConfig(flags, configFlags, args, map[string]*string{"journal.dir": out})
+	if err != nil {
+		return err
+	}
+	if appConfig.Journal.Dir == "" {
+		return errors.New("Nothing to record to: set -out or journal.dir")
+	}
+	// only the journal; the books are still built so snapshots are taken when the feed needs them
+	appConfig.Checkpoint = config.Checkpoint{}
+	reporter := startLogging(appConfig)
+
+	recovered := report.CapturePanic(reporter, func() {
+		handlers, journals, err := newHandlers(appConfig, reporter)
+		defer closeJournals(journals)
+		if err != nil {
+			panic(err)
+		}
+		for _, gdaxHandler := range handlers {
+			go gdaxHandler.Run()
+		}
+		waitForSignal()
+	})
+	reporter.Close()
+	if recovered != nil {
+		return fmt.Errorf("%v", recovered)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+)
+
+// replayStats describe what replaying a product's journal did to its book.
+type replayStats struct {
+	Product           string         `json:"product"`
+	Messages          int            `json:"messages"`
+	Types             map[string]int `json:"types"`
+	Gaps              int            `json:"gaps"`
+	Broken            bool           `json:"broken"`
+	FirstSequence     int64          `json:"first_sequence"`
+	Sequence          int64          `json:"sequence"`
+	Updated           time.Time      `json:"updated"`
+	Orders            int            `json:"orders"`
+	BidLevels         int            `json:"bid_levels"`
+	AskLevels         int            `json:"ask_levels"`
+	BestBid           string         `json:"best_bid,omitempty"`
+	BestAsk           string         `json:"best_ask,omitempty"`
+	Elapsed           string         `json:"elapsed"`
+	MessagesPerSecond float64        `json:"messages_per_second"`
+}
+
+func replayCommand(args []string) error {
+	flags, configFlags := newFlagSet("replay", "rebuild books from a journal and print stats")
+	journalDir := flags.String("journal", "", "journal directory (journal.dir)")
+	out := flags.String("out", "", "write the stats as JSON to this file instead of stdout")
+	snapshots := flags.String("snapshots", "", "write the rebuilt books as checkpoints to this directory")
+	appConfig, err := loadConfig(flags, configFlags, args, map[string]*string{"journal.dir": journalDir})
+	if err != nil {
+		return err
+	}
+	if appConfig.Journal.Dir == "" {
+		return errors.New("Nothing to replay: set -journal or journal.dir")
+	}
+
+	if *snapshots != "" {
+		if err := os.MkdirAll(*snapshots, 0755); err != nil {
+			return errors.Wrap(err, "Could not create snapshot directory")
+		}
+	}
+	stats := []replayStats{}
+	for _, product := range appConfig.Products {
+		handler, productStats, err := replayJournal(appConfig.Journal.Dir, product)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, productStats)
+		if *snapshots != "" {
+			path := orderbook.CheckpointPath(*snapshots, product)
+			if err := orderbook.WriteCheckpoint(path, handler.Book().Checkpoint()); err != nil {
+				return err
+			}
+		}
+	}
+	return writeJSON(*out, stats)
+}
+
+// replayJournal rebuilds the book of product from the whole journal.
+func replayJournal(journalDir string, product string) (*gdax.Handler, replayStats, error) {
+	book := orderbook.NewBook(product, nil)
+	handler := gdax.NewHandler(nil, book, nil)
+	stats := replayStats{Product: product, Types: map[string]int{}}
+	start := time.Now()
+	err := journal.Read(journalDir, product, func(line []byte) error {
+		header := struct {
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(line, &header); err != nil {
+			return errors.Wrap(err, "Could not unmarshal recorded message")
+		}
+		stats.Messages++
+		stats.Types[header.Type]++
+		err := handler.ApplyRecorded(line)
+		if err == gdax.ErrReplayGap {
+			stats.Gaps++
+			return nil
+		}
+		if stats.FirstSequence == 0 {
+			stats.FirstSequence = book.Sequence
+		}
+		return err
+	})
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "Could not replay the journal of "+product)
+	}
+	elapsed := time.Since(start)
+	stats.Elapsed = elapsed.String()
+	if elapsed > 0 {
+		stats.MessagesPerSecond = float64(stats.Messages) / elapsed.Seconds()
+	}
+	stats.Broken = handler.ReplayBroken()
+	stats.Sequence = book.Sequence
+	stats.Updated = book.Updated
+	stats.Orders = book.NumOrders()
+	stats.BidLevels = book.Bid.NumLevels()
+	stats.AskLevels = book.Ask.NumLevels()
+	if bids := book.GetDepth(common.BidSide, 1); len(bids) > 0 {
+		stats.BestBid = bids[0].Price.String()
+	}
+	if asks := book.GetDepth(common.AskSide, 1); len(asks) > 0 {
+		stats.BestAsk = asks[0].Price.String()
+	}
+	return handler, stats, nil
+}
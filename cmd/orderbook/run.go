@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/bus"
+	"github.com/chrischris292/go-gdax-orderbook/export"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/report"
+	"go.uber.org/zap"
+)
+
+func runCommand(args []string) error {
+	flags, configFlags := newFlagSet("run", "follow products live with every feature the config enables")
+	journalDir := flags.String("journal", "", "journal the raw feed to this directory (journal.dir)")
+	checkpointDir := flags.String("checkpoints", "", "save checkpoints to this directory (checkpoint.dir)")
+	exportDir := flags.String("export", "", "export trades, depth and events to this directory (export.dir)")
+	appConfig, err := loadConfig(flags, configFlags, args, map[string]*string{
+		"journal.dir":    journalDir,
+		"checkpoint.dir": checkpointDir,
+		"export.dir":     exportDir,
+	})
+	if err != nil {
+		return err
+	}
+	reporter := startLogging(appConfig)
+
+	recovered := report.CapturePanic(reporter, func() {
+		handlers, journals, err := newHandlers(appConfig, reporter)
+		defer closeJournals(journals)
+		if err != nil {
+			panic(err)
+		}
+		var publisher *bus.Publisher
+		if busConfig := appConfig.Bus; busConfig.Driver != "" {
+			broker, err := bus.NewBroker(busConfig.Driver, busConfig.URLs)
+			if err != nil {
+				panic(err)
+			}
+			publisher = bus.NewPublisher(broker, bus.PublisherConfig{
+				TopicFormat:   busConfig.TopicFormat,
+				BatchSize:     busConfig.BatchSize,
+				FlushInterval: time.Duration(busConfig.FlushIntervalMs) * time.Millisecond,
+				MaxRetries:    busConfig.MaxRetries,
+			})
+			for _, gdaxHandler := range handlers {
+				gdaxHandler.AddHandlerConsumer(publisher.Consumer(gdaxHandler.Book().ID))
+			}
+		}
+		var consolidated *orderbook.Consolidated
+		if consolidatedConfig := appConfig.Consolidated; consolidatedConfig.Enabled {
+			consolidatedHandler := handlers[0]
+			for _, gdaxHandler := range handlers {
+				if gdaxHandler.Book().ID == consolidatedConfig.Product {
+					consolidatedHandler = gdaxHandler
+				}
+			}
+			product := consolidatedHandler.Book().ID
+			consolidated = orderbook.NewConsolidated(product)
+			consolidatedHandler.AddEventListener(consolidated)
+			for _, venue := range consolidatedConfig.Venues {
+				adapter := orderbook.NewRecordedAdapter(venue.Name, orderbook.NewBook(product, reporter), venue.Path, orderbook.DecodeEvent)
+				adapter.AddEventListener(consolidated)
+				go adapter.Run()
+			}
+		}
+		exporters := []*export.Exporter{}
+		if exportConfig := appConfig.Export; exportConfig.Dir != "" {
+			for _, gdaxHandler := range handlers {
+				exporter, err := export.NewExporter(gdaxHandler.Book(), export.Config{
+					Dir:            exportConfig.Dir,
+					Format:         export.Format(exportConfig.Format),
+					Levels:         exportConfig.Levels,
+					SampleInterval: time.Duration(exportConfig.SampleIntervalMs) * time.Millisecond,
+				})
+				if err != nil {
+					panic(err)
+				}
+				gdaxHandler.AddEventListener(exporter)
+				exporters = append(exporters, exporter)
+			}
+		}
+		startServers(appConfig, handlers, consolidated, true)
+		for _, gdaxHandler := range handlers {
+			go gdaxHandler.Run()
+		}
+
+		waitForSignal()
+		for _, gdaxHandler := range handlers {
+			if err := gdaxHandler.WriteCheckpoint(); err != nil {
+				zap.L().Error("Could not write checkpoint on shutdown", zap.String("product", gdaxHandler.Book().ID), zap.Error(err))
+			}
+		}
+		// the handlers keep running until exit; the publisher and exporters ignore them once closed
+		if publisher != nil {
+			publisher.Close()
+		}
+		for _, exporter := range exporters {
+			if err := exporter.Close(); err != nil {
+				zap.L().Error("Could not finish export", zap.Error(err))
+			}
+		}
+	})
+	reporter.Close()
+	if recovered != nil {
+		return fmt.Errorf("%v", recovered)
+	}
+	return nil
+}
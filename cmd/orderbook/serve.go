@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/report"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func serveCommand(args []string) error {
+	flags, configFlags := newFlagSet("serve", "serve the API, broadcast and gRPC servers over live or replayed books")
+	apiAddr := flags.String("api", "", "serve the JSON API on this address (api.addr)")
+	broadcastAddr := flags.String("broadcast", "", "serve the websocket broadcast on this address (broadcast.addr)")
+	rpcAddr := flags.String("rpc", "", "serve gRPC on this address (rpc.addr)")
+	metricsAddr := flags.String("metrics", "", "serve Prometheus metrics on this address (metrics.addr)")
+	replayDir := flags.String("replay", "", "serve the books rebuilt from this journal directory instead of the live feed")
+	appConfig, err := loadConfig(flags, configFlags, args, map[string]*string{
+		"api.addr":       apiAddr,
+		"broadcast.addr": broadcastAddr,
+		"rpc.addr":       rpcAddr,
+		"metrics.addr":   metricsAddr,
+	})
+	if err != nil {
+		return err
+	}
+	if appConfig.API.Addr == "" && appConfig.Broadcast.Addr == "" && appConfig.RPC.Addr == "" && appConfig.Metrics.Addr == "" {
+		return errors.New("Nothing to serve: set -api, -broadcast, -rpc or -metrics")
+	}
+	// only the servers; run also records, checkpoints, exports and publishes
+	appConfig.Journal = config.Journal{}
+	appConfig.Checkpoint = config.Checkpoint{}
+	reporter := startLogging(appConfig)
+
+	recovered := report.CapturePanic(reporter, func() {
+		handlers := []*gdax.Handler{}
+		if *replayDir != "" {
+			for _, product := range appConfig.Products {
+				handler, stats, err := replayJournal(*replayDir, product)
+				if err != nil {
+					panic(err)
+				}
+				zap.L().Info("Replayed journal", zap.String("product", product), zap.Int("messages", stats.Messages), zap.Int64("sequence", stats.Sequence))
+				handlers = append(handlers, handler)
+			}
+		} else {
+			handlers, _, err = newHandlers(appConfig, reporter)
+			if err != nil {
+				panic(err)
+			}
+		}
+		live := *replayDir == ""
+		startServers(appConfig, handlers, nil, live)
+		if live {
+			for _, gdaxHandler := range handlers {
+				go gdaxHandler.Run()
+			}
+		}
+		waitForSignal()
+	})
+	reporter.Close()
+	if recovered != nil {
+		return fmt.Errorf("%v", recovered)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/history"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+)
+
+// maxDifferences bounds how many differing orders verify lists per product.
+const maxDifferences = 20
+
+// verification is how a book replayed to a checkpoint's sequence differs from the checkpoint.
+type verification struct {
+	Product     string   `json:"product"`
+	Snapshot    string   `json:"snapshot"`
+	Sequence    int64    `json:"sequence"`
+	Orders      int      `json:"orders"`
+	Match       bool     `json:"match"`
+	Differences []string `json:"differences,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func verifyCommand(args []string) error {
+	flags, configFlags := newFlagSet("verify", "compare books replayed from a journal to stored checkpoints")
+	journalDir := flags.String("journal", "", "journal directory (journal.dir)")
+	snapshots := flags.String("snapshots", "", "directory of the checkpoints to compare to (checkpoint.dir)")
+	out := flags.String("out", "", "write the results as JSON to this file instead of stdout")
+	appConfig, err := loadConfig(flags, configFlags, args, map[string]*string{
+		"journal.dir":    journalDir,
+		"checkpoint.dir": snapshots,
+	})
+	if err != nil {
+		return err
+	}
+	if appConfig.Journal.Dir == "" || appConfig.Checkpoint.Dir == "" {
+		return errors.New("Nothing to verify: set -journal and -snapshots, or journal.dir and checkpoint.dir")
+	}
+
+	store := history.NewStore("", appConfig.Journal.Dir)
+	results := []verification{}
+	failed := 0
+	for _, product := range appConfig.Products {
+		result := verifyProduct(store, orderbook.CheckpointPath(appConfig.Checkpoint.Dir, product), product)
+		if !result.Match {
+			failed++
+		}
+		results = append(results, result)
+	}
+	if err := writeJSON(*out, results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d books do not match their checkpoint", failed, len(results))
+	}
+	return nil
+}
+
+// verifyProduct replays the journal to the sequence of the checkpoint at path and compares them.
+func verifyProduct(store *history.Store, path string, product string) verification {
+	result := verification{Product: product, Snapshot: path}
+	checkpoint, err := orderbook.ReadCheckpoint(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Sequence = checkpoint.Sequence
+	result.Orders = len(checkpoint.Bids) + len(checkpoint.Asks)
+	state, err := store.BookAt(product, history.Query{Sequence: checkpoint.Sequence})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Differences = checkpointDifferences(state.Book.Checkpoint(), checkpoint)
+	result.Match = len(result.Differences) == 0
+	return result
+}
+
+// checkpointDifferences lists the orders that are missing, extra or different in got and, when
+// a side holds the same orders, where its queue order differs.
+func checkpointDifferences(got orderbook.Checkpoint, want orderbook.Checkpoint) []string {
+	differences := []string{}
+	add := func(format string, args ...interface{}) {
+		if len(differences) < maxDifferences {
+			differences = append(differences, fmt.Sprintf(format, args...))
+		}
+	}
+	for i, name := range []string{"bid", "ask"} {
+		gotOrders := [][]orderbook.CheckpointOrder{got.Bids, got.Asks}[i]
+		wantOrders := [][]orderbook.CheckpointOrder{want.Bids, want.Asks}[i]
+		gotByID := map[string]orderbook.CheckpointOrder{}
+		for _, order := range gotOrders {
+			gotByID[order.ID] = order
+		}
+		wantByID := map[string]bool{}
+		sameOrders := len(gotOrders) == len(wantOrders)
+		for _, order := range wantOrders {
+			wantByID[order.ID] = true
+			replayed, found := gotByID[order.ID]
+			if !found {
+				add("%s %s %s@%s is missing from the replayed book", name, order.ID, order.Size, order.Price)
+				sameOrders = false
+			} else if !replayed.Price.Equal(order.Price) || !replayed.Size.Equal(order.Size) {
+				add("%s %s is %s@%s, want %s@%s", name, order.ID, replayed.Size, replayed.Price, order.Size, order.Price)
+			}
+		}
+		for _, order := range gotOrders {
+			if !wantByID[order.ID] {
+				add("%s %s %s@%s is not in the checkpoint", name, order.ID, order.Size, order.Price)
+			}
+		}
+		if !sameOrders {
+			continue
+		}
+		for j, order := range wantOrders {
+			if gotOrders[j].ID != order.ID {
+				add("%s queue order differs at %s, want %s", name, gotOrders[j].ID, order.ID)
+				break
+			}
+		}
+	}
+	return differences
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/dashboard"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+)
+
+func watchCommand(args []string) error {
+	flags, configFlags := newFlagSet("watch", "show a live depth ladder, trades and feed state of a product in the terminal")
+	product := flags.String("product", "", "product to watch (default the first of products)")
+	levels := flags.Int("levels", 10, "price levels per side")
+	trades := flags.Int("trades", 20, "recent trades to list")
+	barWidth := flags.Int("bar", 30, "width of the largest size bar")
+	refresh := flags.Duration("refresh", 250*time.Millisecond, "redraw interval")
+	replayPath := flags.String("replay", "", "watch a journal file or directory instead of the live feed")
+	speed := flags.Float64("speed", 1, "replay speed relative to the recording, 0 for as fast as possible")
+	appConfig, err := loadConfig(flags, configFlags, args, nil)
+	if err != nil {
+		return err
+	}
+	if *product == "" {
+		*product = appConfig.Products[0]
+	}
+	// watching must not record or checkpoint, and zap is left unconfigured so its logs do not
+	// scribble over the dashboard; the dashboard shows the last reported error instead
+	appConfig.Products = []string{*product}
+	appConfig.Journal = config.Journal{}
+	appConfig.Checkpoint = config.Checkpoint{}
+
+	board := dashboard.NewDashboard(dashboard.Config{Levels: *levels, Trades: *trades, BarWidth: *barWidth, Refresh: *refresh})
+	stop := make(chan struct{})
+	var replayDone chan error
+	if *replayPath != "" {
+		handler := gdax.NewHandler(nil, orderbook.NewBook(*product, board), board)
+		handler.SetFeedMode(gdax.FeedMode(appConfig.Book.Feed))
+		replay := dashboard.NewReplay(handler, *replayPath, *speed)
+		board.SetSource(handler.Book(), replay)
+		handler.AddHandlerConsumer(board)
+		replayDone = make(chan error, 1)
+		go func() { replayDone <- replay.Run(stop) }()
+	} else {
+		handlers, _, err := newHandlers(appConfig, board)
+		if err != nil {
+			return err
+		}
+		handler := handlers[0]
+		board.SetSource(handler.Book(), dashboard.NewLiveFeed(handler))
+		handler.AddHandlerConsumer(board)
+		go handler.Run()
+	}
+
+	drawn := make(chan struct{})
+	go func() {
+		board.Run(os.Stdout, stop)
+		close(drawn)
+	}()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-interrupt:
+	case err = <-replayDone:
+		// keep the final book on screen until interrupted
+		if err == nil {
+			<-interrupt
+		}
+	}
+	close(stop)
+	<-drawn
+	if err != nil {
+		return errors.Wrap(err, "Replay failed")
+	}
+	return nil
+}
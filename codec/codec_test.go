@@ -0,0 +1,192 @@
+package codec_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	mathRand "math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/codec"
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+const benchOrders = 50000
+
+func newUUID() string {
+	var raw [16]byte
+	rand.Read(raw[:])
+	return fmt.Sprintf("%x-%x-%x-%x-%x", raw[0:4], raw[4:6], raw[6:8], raw[8:10], raw[10:16])
+}
+
+// newBook fills a book with orders within 2% of 10000.00 on either side.
+func newBook(n int) *orderbook.Book {
+	random := mathRand.New(mathRand.NewSource(1))
+	book := orderbook.NewBook("BTC-USD", nil)
+	for i := 0; i < n; i++ {
+		side := common.Side(i % 2)
+		offset := int64(random.Intn(20000) + 1)
+		ticks := int64(1000000) - offset
+		if side == common.AskSide {
+			ticks = 1000000 + offset
+		}
+		book.Add(&orderbook.Order{
+			ID:    newUUID(),
+			Price: synthetic.DefaultScale.Price(ticks),
+			Size:  synthetic.DefaultScale.Size(int64(random.Intn(500000000) + 1)),
+			Side:  side,
+		})
+	}
+	book.Sequence = int64(n)
+	return book
+}
+
+// normalizeDecimals is what decoding does to the decimal fields: trailing zeros are dropped.
+func normalizeDecimals(message gdaxClient.Message) gdaxClient.Message {
+	for _, field := range []*string{&message.Price, &message.Size, &message.RemainingSize, &message.NewSize, &message.OldSize, &message.Funds} {
+		if *field != "" {
+			*field = decimal.RequireFromString(*field).String()
+		}
+	}
+	return message
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	checkpoint := newBook(5000).Checkpoint()
+	var buffer bytes.Buffer
+	if err := codec.EncodeSnapshot(&buffer, checkpoint, synthetic.DefaultScale); err != nil {
+		t.Fatal(err)
+	}
+	decoded, decodedScale, err := codec.DecodeSnapshot(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decodedScale.PriceTick.Equal(synthetic.DefaultScale.PriceTick) || !decodedScale.SizeLot.Equal(synthetic.DefaultScale.SizeLot) {
+		t.Fatalf("scale decoded as %v, want %v", decodedScale, synthetic.DefaultScale)
+	}
+	if decoded.Sequence != checkpoint.Sequence || len(decoded.Bids) != len(checkpoint.Bids) || len(decoded.Asks) != len(checkpoint.Asks) {
+		t.Fatalf("decoded snapshot has sequence %d with %d bids and %d asks, want %d with %d and %d", decoded.Sequence,
+			len(decoded.Bids), len(decoded.Asks), checkpoint.Sequence, len(checkpoint.Bids), len(checkpoint.Asks))
+	}
+	for i, orders := range [][]orderbook.CheckpointOrder{checkpoint.Bids, checkpoint.Asks} {
+		decodedOrders := [][]orderbook.CheckpointOrder{decoded.Bids, decoded.Asks}[i]
+		for j, order := range orders {
+			got := decodedOrders[j]
+			if got.ID != order.ID || !got.Price.Equal(order.Price) || !got.Size.Equal(order.Size) {
+				t.Fatalf("order %d decoded as %v, want %v", j, got, order)
+			}
+		}
+	}
+}
+
+func TestEventRoundTrip(t *testing.T) {
+	messages := synthetic.Stream(20000)
+	var buffer bytes.Buffer
+	encoder, err := codec.NewEncoder(&buffer, "BTC-USD", synthetic.DefaultScale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := codec.NewDecoder(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoder.Product() != "BTC-USD" {
+		t.Fatalf("product decoded as %q", decoder.Product())
+	}
+	for i, message := range messages {
+		got, err := decoder.Decode()
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if want := normalizeDecimals(message); !reflect.DeepEqual(got, want) {
+			t.Fatalf("message %d decoded as %+v, want %+v", i, got, want)
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the last message, got %v", err)
+	}
+}
+
+// BenchmarkSnapshotJSON is the cost of the JSON snapshot the binary encoding replaces.
+func BenchmarkSnapshotJSON(b *testing.B) {
+	book := newBook(benchOrders)
+	orders := append(book.GetOrders(common.BidSide), book.GetOrders(common.AskSide)...)
+	b.ResetTimer()
+	size := 0
+	for i := 0; i < b.N; i++ {
+		size = 0
+		for _, order := range orders {
+			size += len(gdax.OrderJSON(order)) + 1
+		}
+	}
+	b.SetBytes(int64(size))
+}
+
+func BenchmarkSnapshotEncode(b *testing.B) {
+	checkpoint := newBook(benchOrders).Checkpoint()
+	var buffer bytes.Buffer
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		codec.EncodeSnapshot(&buffer, checkpoint, synthetic.DefaultScale)
+	}
+	b.SetBytes(int64(buffer.Len()))
+}
+
+func BenchmarkSnapshotDecode(b *testing.B) {
+	var buffer bytes.Buffer
+	codec.EncodeSnapshot(&buffer, newBook(benchOrders).Checkpoint(), synthetic.DefaultScale)
+	encoded := buffer.Bytes()
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		codec.DecodeSnapshot(bytes.NewReader(encoded))
+	}
+}
+
+func BenchmarkEventEncode(b *testing.B) {
+	messages := synthetic.Stream(synthetic.BenchmarkEvents)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		encoder, _ := codec.NewEncoder(io.Discard, "BTC-USD", synthetic.DefaultScale)
+		for _, message := range messages {
+			encoder.Encode(message)
+		}
+		encoder.Flush()
+	}
+}
+
+func BenchmarkEventDecode(b *testing.B) {
+	var buffer bytes.Buffer
+	encoder, _ := codec.NewEncoder(&buffer, "BTC-USD", synthetic.DefaultScale)
+	for _, message := range synthetic.Stream(synthetic.BenchmarkEvents) {
+		encoder.Encode(message)
+	}
+	encoder.Flush()
+	encoded := buffer.Bytes()
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder, _ := codec.NewDecoder(bytes.NewReader(encoded))
+		for {
+			if _, err := decoder.Decode(); err != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"io"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	eventReceived byte = iota + 1
+	eventOpen
+	eventDone
+	eventChange
+	eventMatch
+)
+
+var eventTypes = map[string]byte{
+	"received": eventReceived,
+	"open":     eventOpen,
+	"done":     eventDone,
+	"change":   eventChange,
+	"match":    eventMatch,
+}
+
+var eventNames = map[byte]string{
+	eventReceived: "received",
+	eventOpen:     "open",
+	eventDone:     "done",
+	eventChange:   "change",
+	eventMatch:    "match",
+}
+
+const (
+	sideBuy  byte = 0
+	sideSell byte = 1
+)
+
+// Every event starts with its type, sequence and time. A presence byte then says which of the
+// optional decimal fields follow, so absent fields (a market order's price) stay absent.
+const (
+	hasPrice byte = 1 << iota
+	hasSize
+	hasOldSize
+	hasFunds
+	hasOldFunds
+)
+
+// Encoder writes a stream of feed messages for one product. Order IDs are interned so
+// each is spelled out once while the order is live.
+type Encoder struct {
+	out   *writer
+	scale common.Scale
+	ids   *internTable
+}
+
+func NewEncoder(w io.Writer, product string, scale common.Scale) (*Encoder, error) {
+	encoder := &Encoder{out: newWriter(w), scale: scale, ids: newInternTable()}
+	writeHeader(encoder.out, kindEvents, product, scale)
+	return encoder, encoder.out.err
+}
+
+// Encode writes a received, open, done, change or match message. Other types are skipped.
+// Output is buffered until Flush.
+func (encoder *Encoder) Encode(message gdaxClient.Message) error {
+	eventType, found := eventTypes[message.Type]
+	if !found {
+		return nil
+	}
+	out := encoder.out
+	out.byte(eventType)
+	out.int64(message.Sequence)
+	out.int64(timeToNanos(message.Time))
+	side := sideBuy
+	if message.Side == "sell" {
+		side = sideSell
+	}
+	out.byte(side)
+
+	var fields []string
+	switch eventType {
+	case eventReceived:
+		encoder.ids.writeRef(out, message.OrderId)
+		out.string(message.OrderType)
+		fields = []string{message.Price, message.Size, "", message.Funds, ""}
+	case eventOpen:
+		encoder.ids.writeRef(out, message.OrderId)
+		fields = []string{message.Price, message.RemainingSize, "", "", ""}
+	case eventDone:
+		encoder.ids.writeRef(out, message.OrderId)
+		out.string(message.Reason)
+		fields = []string{message.Price, message.RemainingSize, "", "", ""}
+	case eventChange:
+		encoder.ids.writeRef(out, message.OrderId)
+		fields = []string{message.Price, message.NewSize, message.OldSize, message.NewFunds, message.OldFunds}
+	case eventMatch:
+		out.uvarint(uint64(message.TradeId))
+		encoder.ids.writeRef(out, message.MakerOrderId)
+		encoder.ids.writeRef(out, message.TakerOrderId)
+		fields = []string{message.Price, message.Size, "", "", ""}
+	}
+	if err := encoder.writeFields(fields); err != nil {
+		return errors.Wrapf(err, "Could not encode %s message %d", message.Type, message.Sequence)
+	}
+	if eventType == eventDone {
+		encoder.ids.release(message.OrderId)
+	}
+	return out.err
+}
+
+// writeFields writes price in ticks, the sizes in lots and the funds as decimal strings since
+// funds are not bounded by the product's increments.
+func (encoder *Encoder) writeFields(fields []string) error {
+	var presence byte
+	for i, field := range fields {
+		if field != "" {
+			presence |= 1 << uint(i)
+		}
+	}
+	encoder.out.byte(presence)
+	for i, field := range fields {
+		if field == "" {
+			continue
+		}
+		value, err := decimal.NewFromString(field)
+		if err != nil {
+			return err
+		}
+		var units int64
+		switch byte(1) << uint(i) {
+		case hasPrice:
+			units, err = encoder.scale.Ticks(value)
+		case hasSize, hasOldSize:
+			units, err = encoder.scale.Lots(value)
+		default:
+			encoder.out.string(value.String())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		encoder.out.int64(units)
+	}
+	return nil
+}
+
+func (encoder *Encoder) Flush() error {
+	return encoder.out.flush()
+}
+
+type Decoder struct {
+	in      *reader
+	product string
+	scale   common.Scale
+	ids     *internTable
+}
+
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	in := newReader(r)
+	product, scale, err := readHeader(in, kindEvents)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{in: in, product: product, scale: scale, ids: newInternTable()}, nil
+}
+
+func (decoder *Decoder) Product() string {
+	return decoder.product
+}
+
+func (decoder *Decoder) Scale() common.Scale {
+	return decoder.scale
+}
+
+// Decode returns the next message, or io.EOF after the last one. Decimal strings come back
+// normalized, e.g. "0.01000000" decodes as "0.01".
+func (decoder *Decoder) Decode() (gdaxClient.Message, error) {
+	in := decoder.in
+	eventType, err := in.r.ReadByte()
+	if err == io.EOF {
+		return gdaxClient.Message{}, io.EOF
+	}
+	if err != nil {
+		return gdaxClient.Message{}, err
+	}
+	name, found := eventNames[eventType]
+	if !found {
+		return gdaxClient.Message{}, errors.Errorf("Unknown event type %d", eventType)
+	}
+	message := gdaxClient.Message{Type: name, ProductId: decoder.product}
+	message.Sequence = in.int64()
+	message.Time = nanosToTime(in.int64())
+	message.Side = "buy"
+	if in.byte() == sideSell {
+		message.Side = "sell"
+	}
+
+	switch eventType {
+	case eventReceived:
+		message.OrderId = decoder.ids.readRef(in)
+		message.OrderType = in.string()
+		fields := decoder.readFields()
+		message.Price, message.Size, message.Funds = fields[0], fields[1], fields[3]
+	case eventOpen:
+		message.OrderId = decoder.ids.readRef(in)
+		fields := decoder.readFields()
+		message.Price, message.RemainingSize = fields[0], fields[1]
+	case eventDone:
+		message.OrderId = decoder.ids.readRef(in)
+		message.Reason = in.string()
+		fields := decoder.readFields()
+		message.Price, message.RemainingSize = fields[0], fields[1]
+		if in.err == nil {
+			decoder.ids.release(message.OrderId)
+		}
+	case eventChange:
+		message.OrderId = decoder.ids.readRef(in)
+		fields := decoder.readFields()
+		message.Price, message.NewSize, message.OldSize, message.NewFunds, message.OldFunds = fields[0], fields[1], fields[2], fields[3], fields[4]
+	case eventMatch:
+		message.TradeId = int(in.uvarint())
+		message.MakerOrderId = decoder.ids.readRef(in)
+		message.TakerOrderId = decoder.ids.readRef(in)
+		fields := decoder.readFields()
+		message.Price, message.Size = fields[0], fields[1]
+	}
+	if in.err != nil {
+		if in.err == io.EOF {
+			in.err = io.ErrUnexpectedEOF
+		}
+		return gdaxClient.Message{}, errors.Wrap(in.err, "Could not decode event")
+	}
+	return message, nil
+}
+
+func (decoder *Decoder) readFields() [5]string {
+	var fields [5]string
+	presence := decoder.in.byte()
+	for i := range fields {
+		bit := byte(1) << uint(i)
+		if presence&bit == 0 {
+			continue
+		}
+		switch bit {
+		case hasPrice:
+			fields[i] = decoder.scale.Price(decoder.in.int64()).String()
+		case hasSize, hasOldSize:
+			fields[i] = decoder.scale.Size(decoder.in.int64()).String()
+		default:
+			fields[i] = decoder.in.string()
+		}
+	}
+	return fields
+}
+
+func timeToNanos(t gdaxClient.Time) int64 {
+	if t.Time().IsZero() {
+		return 0
+	}
+	return t.Time().UnixNano()
+}
+
+func nanosToTime(nanos int64) gdaxClient.Time {
+	if nanos == 0 {
+		return gdaxClient.Time{}
+	}
+	return gdaxClient.Time(time.Unix(0, nanos).UTC())
+}
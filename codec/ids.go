@@ -0,0 +1,135 @@
+package codec
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	idUUID   byte = 0
+	idString byte = 1
+)
+
+// writeID stores UUIDs as 16 raw bytes and anything else as a length prefixed string.
+func writeID(w *writer, id string) {
+	if raw, ok := parseUUID(id); ok {
+		w.byte(idUUID)
+		w.bytes(raw[:])
+		return
+	}
+	w.byte(idString)
+	w.string(id)
+}
+
+func readID(r *reader) string {
+	kind := r.byte()
+	if kind == idUUID {
+		raw := make([]byte, 16)
+		r.full(raw)
+		return formatUUID(raw)
+	}
+	if kind != idString && r.err == nil {
+		r.err = errors.Errorf("Unknown ID kind %d", kind)
+	}
+	return r.string()
+}
+
+func parseUUID(id string) ([16]byte, bool) {
+	var raw [16]byte
+	// only lower case so the decoded ID is byte for byte the same as the original
+	if len(id) != 36 || id[8] != '-' || id[13] != '-' || id[18] != '-' || id[23] != '-' || strings.ToLower(id) != id {
+		return raw, false
+	}
+	_, err := hex.Decode(raw[:], []byte(id[0:8]+id[9:13]+id[14:18]+id[19:23]+id[24:36]))
+	return raw, err == nil
+}
+
+func formatUUID(raw []byte) string {
+	s := hex.EncodeToString(raw)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// internTable assigns every live order ID a small index so an event stream only spells
+// out each ID once. Slots of done orders are reused so the table stays as small as the
+// book. Encoder and decoder apply the same operations in the same order so their tables agree.
+type internTable struct {
+	indexes map[string]uint64
+	ids     []string
+	free    []uint64
+}
+
+func newInternTable() *internTable {
+	return &internTable{indexes: map[string]uint64{}}
+}
+
+func (table *internTable) lookup(id string) (uint64, bool) {
+	index, found := table.indexes[id]
+	return index, found
+}
+
+func (table *internTable) add(id string) uint64 {
+	var index uint64
+	if n := len(table.free); n > 0 {
+		index = table.free[n-1]
+		table.free = table.free[:n-1]
+		table.ids[index] = id
+	} else {
+		index = uint64(len(table.ids))
+		table.ids = append(table.ids, id)
+	}
+	table.indexes[id] = index
+	return index
+}
+
+func (table *internTable) get(index uint64) (string, bool) {
+	if index >= uint64(len(table.ids)) {
+		return "", false
+	}
+	id := table.ids[index]
+	if live, found := table.indexes[id]; !found || live != index {
+		return "", false
+	}
+	return id, true
+}
+
+func (table *internTable) release(id string) {
+	index, found := table.indexes[id]
+	if !found {
+		return
+	}
+	delete(table.indexes, id)
+	table.ids[index] = ""
+	table.free = append(table.free, index)
+}
+
+// writeRef writes 0 followed by the ID the first time it is seen and index+1 afterwards.
+func (table *internTable) writeRef(w *writer, id string) {
+	if index, found := table.lookup(id); found {
+		w.uvarint(index + 1)
+		return
+	}
+	w.uvarint(0)
+	writeID(w, id)
+	table.add(id)
+}
+
+func (table *internTable) readRef(r *reader) string {
+	ref := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+	if ref == 0 {
+		id := readID(r)
+		if r.err == nil {
+			table.add(id)
+		}
+		return id
+	}
+	id, found := table.get(ref - 1)
+	if !found {
+		r.err = errors.Errorf("Unknown interned ID %d", ref-1)
+	}
+	return id
+}
@@ -0,0 +1,121 @@
+package codec
+
+import (
+	"io"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+)
+
+// Version is bumped whenever the layout changes. Decoders reject versions they do not know.
+const Version byte = 1
+
+var magic = [4]byte{'G', 'D', 'X', 'B'}
+
+const (
+	kindSnapshot byte = 1
+	kindEvents   byte = 2
+)
+
+// The header is: magic, version, kind, product, price tick and size lot.
+func writeHeader(w *writer, kind byte, product string, scale common.Scale) {
+	w.bytes(magic[:])
+	w.byte(Version)
+	w.byte(kind)
+	w.string(product)
+	w.string(scale.PriceTick.String())
+	w.string(scale.SizeLot.String())
+}
+
+func readHeader(r *reader, kind byte) (string, common.Scale, error) {
+	var header [4]byte
+	r.full(header[:])
+	version := r.byte()
+	gotKind := r.byte()
+	product := r.string()
+	tick := r.string()
+	lot := r.string()
+	if r.err != nil {
+		return "", common.Scale{}, errors.Wrap(r.err, "Could not read header")
+	}
+	if header != magic {
+		return "", common.Scale{}, errors.New("Not a binary book encoding")
+	}
+	if version != Version {
+		return "", common.Scale{}, errors.Errorf("Binary book encoding version %d is not supported", version)
+	}
+	if gotKind != kind {
+		return "", common.Scale{}, errors.Errorf("Expected encoding kind %d, got %d", kind, gotKind)
+	}
+	scale, err := common.NewScale(tick, lot)
+	return product, scale, err
+}
+
+// EncodeSnapshot writes a whole book. Each order is its ID followed by its price in ticks and
+// its size in lots as fixed width integers, in the checkpoint's level and arrival order.
+func EncodeSnapshot(w io.Writer, checkpoint orderbook.Checkpoint, scale common.Scale) error {
+	out := newWriter(w)
+	writeHeader(out, kindSnapshot, checkpoint.ProductID, scale)
+	out.int64(checkpoint.Sequence)
+	out.int64(checkpoint.Created.UnixNano())
+	for _, orders := range [][]orderbook.CheckpointOrder{checkpoint.Bids, checkpoint.Asks} {
+		out.uvarint(uint64(len(orders)))
+		for _, order := range orders {
+			ticks, err := scale.Ticks(order.Price)
+			if err != nil {
+				return errors.Wrap(err, "Could not encode price of order "+order.ID)
+			}
+			lots, err := scale.Lots(order.Size)
+			if err != nil {
+				return errors.Wrap(err, "Could not encode size of order "+order.ID)
+			}
+			writeID(out, order.ID)
+			out.int64(ticks)
+			out.int64(lots)
+		}
+	}
+	return out.flush()
+}
+
+func DecodeSnapshot(r io.Reader) (orderbook.Checkpoint, common.Scale, error) {
+	in := newReader(r)
+	product, scale, err := readHeader(in, kindSnapshot)
+	if err != nil {
+		return orderbook.Checkpoint{}, common.Scale{}, err
+	}
+	checkpoint := orderbook.Checkpoint{
+		Version:   orderbook.CheckpointVersion,
+		ProductID: product,
+		Sequence:  in.int64(),
+		Created:   time.Unix(0, in.int64()),
+	}
+	sides := [2][]orderbook.CheckpointOrder{}
+	for i := range sides {
+		count := in.uvarint()
+		if in.err != nil {
+			break
+		}
+		orders := make([]orderbook.CheckpointOrder, 0, minInt(count, 1<<20))
+		for j := uint64(0); j < count && in.err == nil; j++ {
+			id := readID(in)
+			price := scale.Price(in.int64())
+			size := scale.Size(in.int64())
+			orders = append(orders, orderbook.CheckpointOrder{ID: id, Price: price, Size: size})
+		}
+		sides[i] = orders
+	}
+	if in.err != nil {
+		return orderbook.Checkpoint{}, common.Scale{}, errors.Wrap(in.err, "Could not decode snapshot")
+	}
+	checkpoint.Bids, checkpoint.Asks = sides[0], sides[1]
+	return checkpoint, scale, nil
+}
+
+func minInt(a uint64, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,123 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// writer and reader keep the first error so encoding code can be written straight through
+// and checked once at the end.
+type writer struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+	err     error
+}
+
+func newWriter(w io.Writer) *writer {
+	if buffered, ok := w.(*bufio.Writer); ok {
+		return &writer{w: buffered}
+	}
+	return &writer{w: bufio.NewWriter(w)}
+}
+
+func (w *writer) bytes(b []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(b)
+	}
+}
+
+func (w *writer) byte(b byte) {
+	if w.err == nil {
+		w.err = w.w.WriteByte(b)
+	}
+}
+
+func (w *writer) uvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.bytes(w.scratch[:n])
+}
+
+// int64 is fixed width so prices, sizes and sequences can be located without decoding varints.
+func (w *writer) int64(v int64) {
+	binary.LittleEndian.PutUint64(w.scratch[:8], uint64(v))
+	w.bytes(w.scratch[:8])
+}
+
+func (w *writer) string(s string) {
+	w.uvarint(uint64(len(s)))
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+func (w *writer) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+type reader struct {
+	r       *bufio.Reader
+	scratch [8]byte
+	err     error
+}
+
+func newReader(r io.Reader) *reader {
+	if buffered, ok := r.(*bufio.Reader); ok {
+		return &reader{r: buffered}
+	}
+	return &reader{r: bufio.NewReader(r)}
+}
+
+func (r *reader) full(b []byte) {
+	if r.err == nil {
+		_, r.err = io.ReadFull(r.r, b)
+	}
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	var b byte
+	b, r.err = r.r.ReadByte()
+	return b
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	var v uint64
+	v, r.err = binary.ReadUvarint(r.r)
+	return v
+}
+
+func (r *reader) int64() int64 {
+	r.full(r.scratch[:])
+	if r.err != nil {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(r.scratch[:]))
+}
+
+// maxStringLength guards against allocating huge buffers for corrupt input.
+const maxStringLength = 1 << 20
+
+func (r *reader) string() string {
+	n := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+	if n > maxStringLength {
+		r.err = errors.Errorf("String of length %d is too long", n)
+		return ""
+	}
+	b := make([]byte, n)
+	r.full(b)
+	return string(b)
+}
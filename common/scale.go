@@ -0,0 +1,89 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSizeLot is the smallest size increment Coinbase uses for any product.
+var DefaultSizeLot = decimal.New(1, -8)
+
+// Scale converts prices to integer ticks and sizes to integer lots, e.g. a product's
+// quote_increment and base increment.
+type Scale struct {
+	PriceTick decimal.Decimal
+	SizeLot   decimal.Decimal
+
+	// set by NewScale when the unit is a power of ten so conversion is a shift, not a division
+	priceShift, lotShift int32
+	pricePow10, lotPow10 bool
+}
+
+func NewScale(priceTick string, sizeLot string) (Scale, error) {
+	tick, err := decimal.NewFromString(priceTick)
+	if err != nil {
+		return Scale{}, errors.Wrap(err, "Could not convert price tick to decimal")
+	}
+	lot := DefaultSizeLot
+	if sizeLot != "" {
+		if lot, err = decimal.NewFromString(sizeLot); err != nil {
+			return Scale{}, errors.Wrap(err, "Could not convert size lot to decimal")
+		}
+	}
+	if !tick.IsPositive() || !lot.IsPositive() {
+		return Scale{}, fmt.Errorf("Scale must be positive, got tick %s lot %s", tick.String(), lot.String())
+	}
+	scale := Scale{PriceTick: tick, SizeLot: lot}
+	scale.priceShift, scale.pricePow10 = powerOfTen(tick)
+	scale.lotShift, scale.lotPow10 = powerOfTen(lot)
+	return scale, nil
+}
+
+func (scale Scale) Ticks(price decimal.Decimal) (int64, error) {
+	if scale.pricePow10 {
+		return shiftToUnits(price, scale.priceShift, scale.PriceTick)
+	}
+	return toUnits(price, scale.PriceTick)
+}
+
+func (scale Scale) Lots(size decimal.Decimal) (int64, error) {
+	if scale.lotPow10 {
+		return shiftToUnits(size, scale.lotShift, scale.SizeLot)
+	}
+	return toUnits(size, scale.SizeLot)
+}
+
+func (scale Scale) Price(ticks int64) decimal.Decimal {
+	return decimal.New(ticks, 0).Mul(scale.PriceTick)
+}
+
+func (scale Scale) Size(lots int64) decimal.Decimal {
+	return decimal.New(lots, 0).Mul(scale.SizeLot)
+}
+
+func powerOfTen(unit decimal.Decimal) (int32, bool) {
+	coefficient := unit.Coefficient()
+	return -unit.Exponent(), coefficient.IsInt64() && coefficient.Int64() == 1
+}
+
+func shiftToUnits(value decimal.Decimal, shift int32, unit decimal.Decimal) (int64, error) {
+	// only values with more decimal places than the unit can fall between two units
+	if -value.Exponent() > shift {
+		units := value.Shift(shift)
+		if !units.Equal(units.Truncate(0)) {
+			return 0, fmt.Errorf("%s is not a multiple of %s", value.String(), unit.String())
+		}
+		return units.IntPart(), nil
+	}
+	return value.Shift(shift).IntPart(), nil
+}
+
+func toUnits(value decimal.Decimal, unit decimal.Decimal) (int64, error) {
+	units := value.Div(unit)
+	if !units.Equal(units.Truncate(0)) {
+		return 0, fmt.Errorf("%s is not a multiple of %s", value.String(), unit.String())
+	}
+	return units.IntPart(), nil
+}
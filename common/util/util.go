@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	raven "github.com/getsentry/raven-go"
 	"go.uber.org/zap"
 )
 
@@ -23,7 +22,6 @@ func NumDecPlaces(v float64) int {
 func InitializeZap(config zap.Config) {
 	logger, err := config.Build()
 	if err != nil {
-		raven.CaptureError(err, nil)
 		panic(err)
 	}
 
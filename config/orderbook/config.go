@@ -2,22 +2,170 @@ package config
 
 import "go.uber.org/zap"
 
+// Config is the collector's configuration. Load reads it from the config files, ORDERBOOK_*
+// environment variables and flags; fields tagged default take that value when left empty.
 type Config struct {
-	Coinbase  Coinbase
-	ZapConfig zap.Config `yaml:"zap"`
-	Sentry    Sentry     `yaml:"sentry"`
+	Products     []string `default:"[BTC-USD]"`
+	Coinbase     Coinbase
+	Backoff      Backoff      `yaml:"backoff"`
+	ZapConfig    zap.Config   `yaml:"zap"`
+	Sentry       Sentry       `yaml:"sentry"`
+	Errors       Errors       `yaml:"errors"`
+	Metrics      Metrics      `yaml:"metrics"`
+	API          API          `yaml:"api"`
+	Broadcast    Broadcast    `yaml:"broadcast"`
+	RPC          RPC          `yaml:"rpc"`
+	Bus          Bus          `yaml:"bus"`
+	Journal      Journal      `yaml:"journal"`
+	Checkpoint   Checkpoint   `yaml:"checkpoint"`
+	Book         Book         `yaml:"book"`
+	Integrity    Integrity    `yaml:"integrity"`
+	Gaps         Gaps         `yaml:"gaps"`
+	Watchdog     Watchdog     `yaml:"watchdog"`
+	Consolidated Consolidated `yaml:"consolidated"`
+	Export       Export       `yaml:"export"`
+}
+
+// Export writes the book's trades, depth sampled every SampleIntervalMs (0 disables it) at
+// Levels per side, and events as Format ("csv" or "parquet") files under Dir. Leave Dir empty
+// to disable.
+type Export struct {
+	Dir              string
+	Format           string `default:"csv"`
+	Levels           int    `default:"10"`
+	SampleIntervalMs int
+}
+
+// Consolidated merges the Coinbase book of Product (the first of Products by default) with the
+// same instrument on the Venues, which are replayed from event files written by
+// orderbook.EventWriter, and serves the result at /consolidated/{product}. Leave Enabled false
+// to disable.
+type Consolidated struct {
+	Enabled bool
+	Product string
+	Venues  []RecordedVenue
+}
+
+// RecordedVenue is a venue followed from a recorded event file at Path.
+type RecordedVenue struct {
+	Name string
+	Path string
+}
+
+// Watchdog reconnects when a read blocks for ReadTimeoutSeconds (default 30), no heartbeat
+// arrives for HeartbeatTimeoutSeconds (default 10) or the book falls behind the heartbeat's
+// sequence for SequenceStallSeconds (default 30). A ping is sent every PingIntervalSeconds (default 15).
+type Watchdog struct {
+	ReadTimeoutSeconds      int
+	PingIntervalSeconds     int
+	HeartbeatTimeoutSeconds int
+	SequenceStallSeconds    int
+}
+
+// Gaps is the resync policy on a sequence gap: "immediate" (the default), "reorder", which waits
+// up to ReorderWindowMs for late messages holding at most ReorderBuffer of them, or
+// "rate_limited", which resyncs at most once every MinResyncIntervalMs. RecentGaps is how many
+// gaps /books/{product}/gaps lists.
+type Gaps struct {
+	Policy              string `default:"immediate"`
+	ReorderWindowMs     int
+	ReorderBuffer       int
+	MinResyncIntervalMs int
+	RecentGaps          int
+}
+
+// Integrity flags orders at the top of the book older than StaleOrderSeconds (0 disables it)
+// and resyncs the book on the anomaly kinds in Resync, e.g. "crossed" or "impossible_change".
+type Integrity struct {
+	StaleOrderSeconds int
+	Resync            []string
+}
+
+// Book switches the book to fixed-point ticks and lots taken from the product's
+// quote_increment. SizeLot overrides the default base increment of 0.00000001. Feed is the
+// channel the book is built from: "full" (the default), "level2" or "ticker".
+type Book struct {
+	FixedPoint bool
+	SizeLot    string
+	Feed       string `default:"full"`
+}
+
+// Journal records the raw feed of every book under Dir. Leave Dir empty to disable.
+type Journal struct {
+	Dir string
+}
+
+// Checkpoint saves every book to Dir every IntervalSeconds and on shutdown, and restores
+// from it on startup. Leave Dir empty to disable. HistoryDir also keeps every checkpoint for
+// historical queries; leave it empty to keep only the latest.
+type Checkpoint struct {
+	Dir             string
+	IntervalSeconds int
+	HistoryDir      string
+}
+
+// Bus publishes book and trade events to a message bus. Driver is "nats" or "kafka";
+// leave it empty to disable. URLs is the NATS server URL or the list of Kafka brokers.
+type Bus struct {
+	Driver          string
+	URLs            []string
+	TopicFormat     string
+	BatchSize       int
+	FlushIntervalMs int
+	MaxRetries      int
+}
+
+// RPC serves the gRPC OrderBook service on Addr. ReplayBuffer is how many updates per
+// product a stream can resume from. Leave Addr empty to disable.
+type RPC struct {
+	Addr         string
+	ReplayBuffer int
+}
+
+// Broadcast re-serves the books to websocket clients on Addr. Clients more than
+// ClientBuffer messages behind are disconnected. Leave Addr empty to disable.
+type Broadcast struct {
+	Addr         string
+	ClientBuffer int
+}
+
+// API serves the JSON query API on Addr (e.g. ":8080"). Leave Addr empty to disable.
+type API struct {
+	Addr string
+}
+
+// Metrics serves Prometheus metrics on Addr (e.g. ":9100"). Leave Addr empty to disable.
+type Metrics struct {
+	Addr string
+}
+
+// Errors sends errors to Reporter: "sentry" (at Sentry.Dsn), "log" or "none". It defaults to
+// sentry when a DSN is set and to log otherwise. Reports are sent in the background: at most
+// QueueSize wait, at most MaxPerMinute go out a minute and repeats of an error within
+// DedupSeconds are dropped.
+type Errors struct {
+	Reporter     string
+	QueueSize    int `default:"100"`
+	MaxPerMinute int `default:"30"`
+	DedupSeconds int `default:"60"`
 }
 
 type Sentry struct {
 	Dsn string
 }
 
+// Coinbase is where the feed and REST API are. Key, Secret and Passphrase are an API key, which
+// is only needed to track our own orders; set all three or none.
 type Coinbase struct {
-	CB_Secret     string
-	CB_Key        string
-	CB_Passphrase string
-	CB_REST_API   string
-	CB_WS         string
+	Key          string
+	Secret       string
+	Passphrase   string
+	RestURL      string `default:"https://api.gdax.com"`
+	WebsocketURL string `default:"wss://ws-feed.gdax.com"`
 }
 
-var AppConfig Config
+// Backoff waits InitialMs before reconnecting a dropped feed, doubling up to MaxMs.
+type Backoff struct {
+	InitialMs int `default:"1000"`
+	MaxMs     int `default:"60000"`
+}
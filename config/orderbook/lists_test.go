@@ -0,0 +1,26 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+)
+
+func TestListsMatchGdax(t *testing.T) {
+	canonical := map[string][]string{"feeds": {}, "gap policies": {}, "anomaly kinds": {}}
+	for _, mode := range gdax.FeedModes {
+		canonical["feeds"] = append(canonical["feeds"], string(mode))
+	}
+	for _, policy := range gdax.ResyncPolicies {
+		canonical["gap policies"] = append(canonical["gap policies"], string(policy))
+	}
+	for _, kind := range gdax.AnomalyKinds {
+		canonical["anomaly kinds"] = append(canonical["anomaly kinds"], string(kind))
+	}
+	for name, list := range map[string][]string{"feeds": feeds, "gap policies": gapPolicies, "anomaly kinds": anomalyKinds} {
+		if !reflect.DeepEqual(list, canonical[name]) {
+			t.Errorf("config accepts %s %v, gdax has %v", name, list, canonical[name])
+		}
+	}
+}
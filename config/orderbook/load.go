@@ -0,0 +1,110 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/configor"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const DefaultFile = "config/orderbook/config.yml"
+
+// EnvPrefix starts the environment variables that override the config files, e.g.
+// ORDERBOOK_COINBASE_WEBSOCKETURL or ORDERBOOK_PRODUCTS="[BTC-USD, ETH-USD]".
+const EnvPrefix = "ORDERBOOK"
+
+// Flags are the command line overrides of a config. Set takes section.field=value and may be
+// repeated; values are read the same way as environment variables.
+type Flags struct {
+	File     string
+	Products string
+	Set      Overrides
+}
+
+// AddFlags registers -config, -products and -set on flags.
+func AddFlags(flags *flag.FlagSet) *Flags {
+	parsed := &Flags{}
+	flags.StringVar(&parsed.File, "config", DefaultFile, "config file; config.<env>.yml next to it is read too")
+	flags.StringVar(&parsed.Products, "products", "", "comma separated products to follow, e.g. BTC-USD,ETH-USD")
+	flags.Var(&parsed.Set, "set", "override a config field as section.field=value, e.g. api.addr=:8080 (repeatable)")
+	return parsed
+}
+
+// Overrides are section.field=value assignments to a config.
+type Overrides []string
+
+func (overrides *Overrides) String() string {
+	return strings.Join(*overrides, " ")
+}
+
+func (overrides *Overrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("%q is not section.field=value", value)
+	}
+	*overrides = append(*overrides, value)
+	return nil
+}
+
+// Load reads the config file, then the environment and then flags, which may be nil, and
+// validates the result. A *ValidationError lists every invalid field.
+func Load(flags *Flags) (Config, error) {
+	if flags == nil {
+		flags = &Flags{File: DefaultFile}
+	}
+	var config Config
+	if err := configor.New(&configor.Config{ENVPrefix: EnvPrefix}).Load(&config, flags.File); err != nil {
+		return config, errors.Wrap(err, "Could not load config")
+	}
+	if flags.Products != "" {
+		config.Products = strings.Split(flags.Products, ",")
+	}
+	for _, override := range flags.Set {
+		if err := config.Override(override); err != nil {
+			return config, err
+		}
+	}
+	return config, config.Validate()
+}
+
+// Override sets the field named by a section.field=value assignment. Sections and fields are
+// matched by their yaml key or field name, ignoring case.
+func (config *Config) Override(assignment string) error {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%q is not section.field=value", assignment)
+	}
+	field := reflect.ValueOf(config).Elem()
+	for _, name := range strings.Split(parts[0], ".") {
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("Could not set %s: %s has no fields", parts[0], field.Type())
+		}
+		next, found := structField(field, name)
+		if !found {
+			return fmt.Errorf("Could not set %s: no field %s", parts[0], name)
+		}
+		field = next
+	}
+	if field.Kind() == reflect.String {
+		field.SetString(parts[1])
+		return nil
+	}
+	if err := yaml.Unmarshal([]byte(parts[1]), field.Addr().Interface()); err != nil {
+		return errors.Wrapf(err, "Could not set %s", parts[0])
+	}
+	return nil
+}
+
+func structField(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if strings.EqualFold(field.Name, name) || (key != "" && strings.EqualFold(key, name)) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,42 @@
+package config_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
+)
+
+func TestLoadAppliesDefaultsFileEnvironmentAndFlagsInOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orderbook-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yml")
+	yml := "products: [ETH-USD]\napi:\n  addr: \":8080\"\nmetrics:\n  addr: \":9100\"\n"
+	if err := ioutil.WriteFile(file, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(config.EnvPrefix+"_METRICS_ADDR", ":9200")
+	defer os.Unsetenv(config.EnvPrefix + "_METRICS_ADDR")
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	parsed := config.AddFlags(flags)
+	if err := flags.Parse([]string{"-config", file, "-products", "BTC-USD,ETH-USD", "-set", "api.addr=:8081", "-set", "gaps.recentgaps=5"}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := config.Load(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Products) != 2 || loaded.API.Addr != ":8081" || loaded.Metrics.Addr != ":9200" || loaded.Gaps.RecentGaps != 5 {
+		t.Fatalf("overrides were not applied: %+v", loaded)
+	}
+	if loaded.Coinbase.WebsocketURL == "" || loaded.Backoff.MaxMs == 0 || loaded.Gaps.Policy != "immediate" {
+		t.Fatalf("defaults were not applied: %+v", loaded)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var productPattern = regexp.MustCompile(`^[A-Z0-9]+-[A-Z0-9]+$`)
+
+// The values the collector understands, kept here so config does not depend on the packages
+// that use them. TestListsMatchGdax keeps the feeds, gap policies and anomaly kinds in step with gdax.
+var (
+	feeds         = []string{"full", "level2", "ticker"}
+	gapPolicies   = []string{"immediate", "reorder", "rate_limited"}
+	anomalyKinds  = []string{"crossed", "locked", "stale_order", "negative_size", "impossible_change", "unknown_maker", "off_grid"}
+	busDrivers    = []string{"nats", "kafka", "memory"}
+	exportFormats = []string{"csv", "parquet"}
+	reporters     = []string{"none", "log", "sentry"}
+)
+
+// ValidationError lists every invalid field of a config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "Invalid config:\n  " + strings.Join(err.Problems, "\n  ")
+}
+
+func (err *ValidationError) add(format string, args ...interface{}) {
+	err.Problems = append(err.Problems, fmt.Sprintf(format, args...))
+}
+
+// Validate checks every section and returns a *ValidationError listing what is wrong, or nil.
+func (config Config) Validate() error {
+	invalid := &ValidationError{}
+
+	if len(config.Products) == 0 {
+		invalid.add("products is empty")
+	}
+	seen := map[string]bool{}
+	for _, product := range config.Products {
+		if !productPattern.MatchString(product) {
+			invalid.add("products: %q is not a product like BTC-USD", product)
+		}
+		if seen[product] {
+			invalid.add("products: %s is listed twice", product)
+		}
+		seen[product] = true
+	}
+
+	coinbase := config.Coinbase
+	checkURL(invalid, "coinbase.websocketurl", coinbase.WebsocketURL, "ws", "wss")
+	checkURL(invalid, "coinbase.resturl", coinbase.RestURL, "http", "https")
+	if set := countSet(coinbase.Key, coinbase.Secret, coinbase.Passphrase); set != 0 && set != 3 {
+		invalid.add("coinbase: key, secret and passphrase must be set together")
+	}
+	if coinbase.Secret != "" {
+		if _, err := base64.StdEncoding.DecodeString(coinbase.Secret); err != nil {
+			invalid.add("coinbase.secret is not base64: %v", err)
+		}
+	}
+
+	if config.Backoff.InitialMs <= 0 {
+		invalid.add("backoff.initialms must be positive, got %d", config.Backoff.InitialMs)
+	}
+	if config.Backoff.MaxMs < config.Backoff.InitialMs {
+		invalid.add("backoff.maxms %d is below backoff.initialms %d", config.Backoff.MaxMs, config.Backoff.InitialMs)
+	}
+
+	if config.Errors.Reporter != "" {
+		checkOneOf(invalid, "errors.reporter", config.Errors.Reporter, reporters)
+	}
+	if config.Errors.Reporter == "sentry" && config.Sentry.Dsn == "" {
+		invalid.add("errors.reporter is sentry but sentry.dsn is empty")
+	}
+	checkNotNegative(invalid, "errors.queuesize", config.Errors.QueueSize)
+	checkNotNegative(invalid, "errors.maxperminute", config.Errors.MaxPerMinute)
+	checkNotNegative(invalid, "errors.dedupseconds", config.Errors.DedupSeconds)
+
+	checkAddr(invalid, "metrics.addr", config.Metrics.Addr)
+	checkAddr(invalid, "api.addr", config.API.Addr)
+	checkAddr(invalid, "broadcast.addr", config.Broadcast.Addr)
+	checkAddr(invalid, "rpc.addr", config.RPC.Addr)
+	checkNotNegative(invalid, "broadcast.clientbuffer", config.Broadcast.ClientBuffer)
+	checkNotNegative(invalid, "rpc.replaybuffer", config.RPC.ReplayBuffer)
+
+	checkOneOf(invalid, "book.feed", config.Book.Feed, feeds)
+	if config.Book.SizeLot != "" {
+		if lot, err := strconv.ParseFloat(config.Book.SizeLot, 64); err != nil || lot <= 0 {
+			invalid.add("book.sizelot %q is not a positive number", config.Book.SizeLot)
+		}
+	}
+
+	checkOneOf(invalid, "gaps.policy", config.Gaps.Policy, gapPolicies)
+	checkNotNegative(invalid, "gaps.reorderwindowms", config.Gaps.ReorderWindowMs)
+	checkNotNegative(invalid, "gaps.reorderbuffer", config.Gaps.ReorderBuffer)
+	checkNotNegative(invalid, "gaps.minresyncintervalms", config.Gaps.MinResyncIntervalMs)
+	checkNotNegative(invalid, "gaps.recentgaps", config.Gaps.RecentGaps)
+
+	checkNotNegative(invalid, "integrity.staleorderseconds", config.Integrity.StaleOrderSeconds)
+	for _, kind := range config.Integrity.Resync {
+		checkOneOf(invalid, "integrity.resync", kind, anomalyKinds)
+	}
+
+	checkNotNegative(invalid, "watchdog.readtimeoutseconds", config.Watchdog.ReadTimeoutSeconds)
+	checkNotNegative(invalid, "watchdog.pingintervalseconds", config.Watchdog.PingIntervalSeconds)
+	checkNotNegative(invalid, "watchdog.heartbeattimeoutseconds", config.Watchdog.HeartbeatTimeoutSeconds)
+	checkNotNegative(invalid, "watchdog.sequencestallseconds", config.Watchdog.SequenceStallSeconds)
+
+	if config.Bus.Driver != "" {
+		checkOneOf(invalid, "bus.driver", config.Bus.Driver, busDrivers)
+		if len(config.Bus.URLs) == 0 && config.Bus.Driver != "memory" {
+			invalid.add("bus.urls is empty but bus.driver is %s", config.Bus.Driver)
+		}
+	}
+	checkNotNegative(invalid, "bus.batchsize", config.Bus.BatchSize)
+	checkNotNegative(invalid, "bus.flushintervalms", config.Bus.FlushIntervalMs)
+	checkNotNegative(invalid, "bus.maxretries", config.Bus.MaxRetries)
+
+	checkNotNegative(invalid, "checkpoint.intervalseconds", config.Checkpoint.IntervalSeconds)
+	if config.Checkpoint.HistoryDir != "" && config.Checkpoint.Dir == "" {
+		invalid.add("checkpoint.historydir is set but checkpoint.dir is empty")
+	}
+
+	if consolidated := config.Consolidated; consolidated.Enabled {
+		if consolidated.Product != "" && !seen[consolidated.Product] {
+			invalid.add("consolidated.product %s is not one of the products", consolidated.Product)
+		}
+		venues := map[string]bool{}
+		for i, venue := range consolidated.Venues {
+			if venue.Name == "" || venue.Path == "" {
+				invalid.add("consolidated.venues[%d] needs a name and a path", i)
+			}
+			if venues[venue.Name] {
+				invalid.add("consolidated.venues: %s is listed twice", venue.Name)
+			}
+			venues[venue.Name] = true
+		}
+	}
+
+	if config.Export.Dir != "" {
+		checkOneOf(invalid, "export.format", config.Export.Format, exportFormats)
+	}
+	checkNotNegative(invalid, "export.levels", config.Export.Levels)
+	checkNotNegative(invalid, "export.sampleintervalms", config.Export.SampleIntervalMs)
+
+	if len(invalid.Problems) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+func checkURL(invalid *ValidationError, name string, value string, schemes ...string) {
+	if value == "" {
+		invalid.add("%s is empty", name)
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		invalid.add("%s %q is not a URL: %v", name, value, err)
+		return
+	}
+	if parsed.Host == "" || !contains(schemes, parsed.Scheme) {
+		invalid.add("%s %q is not a %s:// URL", name, value, strings.Join(schemes, ":// or "))
+	}
+}
+
+// checkAddr accepts an empty addr, which disables the server, or host:port.
+func checkAddr(invalid *ValidationError, name string, addr string) {
+	if addr == "" {
+		return
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		invalid.add("%s %q is not host:port", name, addr)
+		return
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+		invalid.add("%s %q has an invalid port", name, addr)
+	}
+}
+
+func checkOneOf(invalid *ValidationError, name string, value string, allowed []string) {
+	if !contains(allowed, value) {
+		invalid.add("%s %q is not one of %s", name, value, strings.Join(allowed, ", "))
+	}
+}
+
+func checkNotNegative(invalid *ValidationError, name string, value int) {
+	if value < 0 {
+		invalid.add("%s must not be negative, got %d", name, value)
+	}
+}
+
+func countSet(values ...string) int {
+	set := 0
+	for _, value := range values {
+		if value != "" {
+			set++
+		}
+	}
+	return set
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
+)
+
+func TestValidateListsEveryInvalidField(t *testing.T) {
+	invalid := config.Config{Products: []string{"btc"}}
+	invalid.Coinbase.WebsocketURL = "https://ws-feed.gdax.com"
+	invalid.Coinbase.RestURL = "https://api.gdax.com"
+	invalid.Coinbase.Key = "key"
+	invalid.Backoff = config.Backoff{InitialMs: 1000, MaxMs: 1000}
+	invalid.Book.Feed = "full"
+	invalid.Gaps.Policy = "sometimes"
+	invalid.Export.Format = "csv"
+	err, ok := invalid.Validate().(*config.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want a *config.ValidationError", invalid.Validate())
+	}
+	// the product, the websocket URL, the half set credentials and the gap policy
+	if len(err.Problems) != 4 {
+		t.Fatalf("got %d problems, want 4: %v", len(err.Problems), err)
+	}
+}
@@ -0,0 +1,331 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultLevels   = 10
+	defaultTrades   = 20
+	defaultBarWidth = 30
+	defaultRefresh  = 250 * time.Millisecond
+
+	// rateWindow is how far back message rates are averaged.
+	rateWindow = 5 * time.Second
+)
+
+const (
+	reset = "\x1b[0m"
+	bold  = "\x1b[1m"
+	dim   = "\x1b[2m"
+	red   = "\x1b[31m"
+	green = "\x1b[32m"
+
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+	home        = "\x1b[H"
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+// Config sizes the dashboard. Levels is the depth per side, Trades how many recent trades are
+// listed and BarWidth the widest size bar. Zero fields take the defaults.
+type Config struct {
+	Levels   int
+	Trades   int
+	BarWidth int
+	Refresh  time.Duration
+}
+
+// FeedState is the status line: where the book comes from and how healthy that feed is.
+type FeedState struct {
+	Source        string
+	Status        string
+	LastHeartbeat time.Time
+	Gaps          int64
+	Resyncs       int64
+}
+
+// FeedSource reports the state of the feed a dashboard watches. LiveFeed and Replay implement it.
+type FeedSource interface {
+	FeedState() FeedState
+}
+
+// Dashboard draws a depth ladder with size bars, the spread and mid, recent trades, the feed
+// state and message rates of one book to a terminal with ANSI escapes. It is a
+// gdax.HandlerConsumer, for the trades and message counts, and a report.ErrorReporter, whose
+// last error it shows, so it is built before the handler and given the book with SetSource.
+type Dashboard struct {
+	book   *orderbook.Book
+	feed   FeedSource
+	config Config
+
+	mu        sync.Mutex
+	trades    []gdax.Match
+	counts    map[string]int64
+	total     int64
+	samples   []rateSample
+	lastError string
+	errorAt   time.Time
+}
+
+type rateSample struct {
+	at     time.Time
+	total  int64
+	counts map[string]int64
+}
+
+func NewDashboard(config Config) *Dashboard {
+	if config.Levels <= 0 {
+		config.Levels = defaultLevels
+	}
+	if config.Trades <= 0 {
+		config.Trades = defaultTrades
+	}
+	if config.BarWidth <= 0 {
+		config.BarWidth = defaultBarWidth
+	}
+	if config.Refresh <= 0 {
+		config.Refresh = defaultRefresh
+	}
+	return &Dashboard{config: config, trades: []gdax.Match{}, counts: map[string]int64{}}
+}
+
+// SetSource sets the book to draw and the feed that maintains it. Call it before Run.
+func (dashboard *Dashboard) SetSource(book *orderbook.Book, feed FeedSource) {
+	dashboard.book, dashboard.feed = book, feed
+}
+
+func (dashboard *Dashboard) BookUpdate(message gdaxClient.Message) {
+	dashboard.mu.Lock()
+	dashboard.counts[message.Type]++
+	dashboard.total++
+	dashboard.mu.Unlock()
+}
+
+func (dashboard *Dashboard) TradeTick(msg gdax.Match) {
+	dashboard.mu.Lock()
+	dashboard.trades = append(dashboard.trades, msg)
+	if len(dashboard.trades) > dashboard.config.Trades {
+		dashboard.trades = dashboard.trades[len(dashboard.trades)-dashboard.config.Trades:]
+	}
+	dashboard.mu.Unlock()
+}
+
+// Clear keeps the trades; the ladder is read from the book, which the handler clears itself.
+func (dashboard *Dashboard) Clear() {}
+
+func (dashboard *Dashboard) Report(err error, tags map[string]string) {
+	dashboard.mu.Lock()
+	dashboard.lastError = err.Error()
+	dashboard.errorAt = time.Now()
+	dashboard.mu.Unlock()
+}
+
+// Run redraws the dashboard on w every Refresh until stop is closed, then restores the terminal.
+func (dashboard *Dashboard) Run(w io.Writer, stop <-chan struct{}) {
+	io.WriteString(w, enterScreen)
+	defer io.WriteString(w, leaveScreen)
+	ticker := time.NewTicker(dashboard.config.Refresh)
+	defer ticker.Stop()
+	for {
+		io.WriteString(w, dashboard.Render(time.Now()))
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Render draws one frame. Each call also samples the message counts for the rates.
+func (dashboard *Dashboard) Render(now time.Time) string {
+	snapshot := dashboard.book.Snapshot(dashboard.config.Levels)
+	feed := FeedState{}
+	if dashboard.feed != nil {
+		feed = dashboard.feed.FeedState()
+	}
+	dashboard.mu.Lock()
+	trades := append([]gdax.Match{}, dashboard.trades...)
+	rate, rates := dashboard.sampleRates(now)
+	lastError, errorAt := dashboard.lastError, dashboard.errorAt
+	dashboard.mu.Unlock()
+
+	lines := []string{
+		dashboard.header(snapshot, feed, now),
+		dashboard.rateLine(rate, rates),
+	}
+	if lastError != "" {
+		lines = append(lines, fmt.Sprintf("%slast error %s ago: %s%s", red, age(now, errorAt), lastError, reset))
+	}
+	lines = append(lines, "")
+	ladder := dashboard.ladder(snapshot)
+	tradeLines := dashboard.tradeLines(trades)
+	for i := 0; i < len(ladder) || i < len(tradeLines); i++ {
+		line := strings.Repeat(" ", ladderWidth(dashboard.config.BarWidth))
+		if i < len(ladder) {
+			line = ladder[i]
+		}
+		if i < len(tradeLines) {
+			line += "   " + tradeLines[i]
+		}
+		lines = append(lines, line)
+	}
+
+	frame := strings.Builder{}
+	frame.WriteString(home)
+	for _, line := range lines {
+		frame.WriteString(line + clearLine + "\n")
+	}
+	frame.WriteString(clearBelow)
+	return frame.String()
+}
+
+func (dashboard *Dashboard) header(snapshot orderbook.BookSnapshot, feed FeedState, now time.Time) string {
+	status := feed.Status
+	switch {
+	case status == "live":
+		status = green + status + reset
+	case status == "syncing" || status == "stale" || strings.HasPrefix(status, "failed"):
+		status = red + status + reset
+	}
+	line := fmt.Sprintf("%s%s%s  seq %d  updated %s  %s %s", bold, snapshot.ID, reset, snapshot.Sequence, formatTime(snapshot.Updated), feed.Source, status)
+	if !feed.LastHeartbeat.IsZero() {
+		line += fmt.Sprintf("  heartbeat %s ago", age(now, feed.LastHeartbeat))
+	}
+	return line + fmt.Sprintf("  gaps %d  resyncs %d", feed.Gaps, feed.Resyncs)
+}
+
+func (dashboard *Dashboard) rateLine(rate float64, rates map[string]float64) string {
+	types := []string{}
+	for messageType := range rates {
+		types = append(types, messageType)
+	}
+	sort.Strings(types)
+	parts := []string{}
+	for _, messageType := range types {
+		parts = append(parts, fmt.Sprintf("%s %.0f", messageType, rates[messageType]))
+	}
+	return fmt.Sprintf("%.0f msgs/s %s%s%s", rate, dim, strings.Join(parts, "  "), reset)
+}
+
+// sampleRates records the counts at now and returns the message rates over the rate window.
+func (dashboard *Dashboard) sampleRates(now time.Time) (float64, map[string]float64) {
+	counts := map[string]int64{}
+	for messageType, count := range dashboard.counts {
+		counts[messageType] = count
+	}
+	dashboard.samples = append(dashboard.samples, rateSample{at: now, total: dashboard.total, counts: counts})
+	for len(dashboard.samples) > 2 && now.Sub(dashboard.samples[1].at) >= rateWindow {
+		dashboard.samples = dashboard.samples[1:]
+	}
+	oldest := dashboard.samples[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	rates := map[string]float64{}
+	if elapsed <= 0 {
+		return 0, rates
+	}
+	for messageType, count := range counts {
+		if rate := float64(count-oldest.counts[messageType]) / elapsed; rate > 0 {
+			rates[messageType] = rate
+		}
+	}
+	return float64(dashboard.total-oldest.total) / elapsed, rates
+}
+
+// ladder lists the asks, worst first, down to the spread and the bids below it.
+func (dashboard *Dashboard) ladder(snapshot orderbook.BookSnapshot) []string {
+	maxSize := decimal.New(0, 0)
+	for _, level := range append(append([]orderbook.DepthLevel{}, snapshot.Bids...), snapshot.Asks...) {
+		if level.Size.GreaterThan(maxSize) {
+			maxSize = level.Size
+		}
+	}
+	width := ladderWidth(dashboard.config.BarWidth)
+	lines := []string{padRight(fmt.Sprintf("%14s %14s %6s  %s", "PRICE", "SIZE", "ORDERS", "DEPTH"), width)}
+	for i := dashboard.config.Levels - 1; i >= 0; i-- {
+		if i >= len(snapshot.Asks) {
+			lines = append(lines, strings.Repeat(" ", width))
+			continue
+		}
+		lines = append(lines, red+dashboard.levelLine(snapshot.Asks[i], maxSize)+reset)
+	}
+	middle := "empty book"
+	if len(snapshot.Bids) > 0 && len(snapshot.Asks) > 0 {
+		bid, ask := snapshot.Bids[0].Price, snapshot.Asks[0].Price
+		middle = fmt.Sprintf("spread %s  mid %s", ask.Sub(bid).String(), bid.Add(ask).Div(decimal.New(2, 0)).String())
+	}
+	lines = append(lines, bold+padRight(fmt.Sprintf("%14s", "")+" "+middle, width)+reset)
+	for i := 0; i < dashboard.config.Levels; i++ {
+		if i >= len(snapshot.Bids) {
+			lines = append(lines, strings.Repeat(" ", width))
+			continue
+		}
+		lines = append(lines, green+dashboard.levelLine(snapshot.Bids[i], maxSize)+reset)
+	}
+	return lines
+}
+
+func (dashboard *Dashboard) levelLine(level orderbook.DepthLevel, maxSize decimal.Decimal) string {
+	bar := 0
+	if maxSize.Sign() > 0 {
+		ratio, _ := level.Size.Div(maxSize).Float64()
+		bar = int(ratio*float64(dashboard.config.BarWidth) + 0.5)
+		if bar == 0 && level.Size.Sign() > 0 {
+			bar = 1
+		}
+	}
+	line := fmt.Sprintf("%14s %14s %6d  ", level.Price.String(), level.Size.StringFixed(8), level.NumOrders)
+	return padRight(line+strings.Repeat("█", bar), ladderWidth(dashboard.config.BarWidth))
+}
+
+// tradeLines lists the recent trades, newest first, red when the taker sold into a bid and
+// green when the taker bought from an ask. MatchSide is the maker's side.
+func (dashboard *Dashboard) tradeLines(trades []gdax.Match) []string {
+	lines := []string{fmt.Sprintf("%-12s %14s %14s  %s", "TIME", "PRICE", "SIZE", "TAKER")}
+	for i := len(trades) - 1; i >= 0; i-- {
+		trade := trades[i]
+		colour, taker := green, "buy"
+		if trade.MatchSide == common.BidSide {
+			colour, taker = red, "sell"
+		}
+		lines = append(lines, fmt.Sprintf("%s%-12s %14s %14s  %s%s", colour, trade.Time.Time().UTC().Format("15:04:05.000"), trade.Price.String(),
+			trade.Size.StringFixed(8), taker, reset))
+	}
+	return lines
+}
+
+// ladderWidth is the width of a ladder line: price, size, orders and the bar.
+func ladderWidth(barWidth int) int {
+	return 14 + 1 + 14 + 1 + 6 + 2 + barWidth
+}
+
+// padRight pads s with spaces to width runes.
+func padRight(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func formatTime(at time.Time) string {
+	if at.IsZero() {
+		return "-"
+	}
+	return at.UTC().Format("15:04:05.000")
+}
+
+func age(now time.Time, at time.Time) string {
+	return now.Sub(at).Truncate(100 * time.Millisecond).String()
+}
@@ -0,0 +1,93 @@
+package dashboard_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/dashboard"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// writeJournal records a generated session, led by an empty snapshot, as a journal in dir.
+func writeJournal(t *testing.T, dir string) (synthetic.Config, []gdaxClient.Message) {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	messages := append(generator.Generate(5000), generator.Flush()...)
+	writer, err := journal.NewWriter(dir, config.ProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.Message(gdax.SnapshotStartJSON(config.ProductID, messages[0].Sequence-1, messages[0].Time.Time()))
+	for _, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Message(string(line))
+	}
+	return config, messages
+}
+
+func TestRenderReplayedBookTradesAndRates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orderbook-dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config, messages := writeJournal(t, dir)
+
+	board := dashboard.NewDashboard(dashboard.Config{Levels: 5, Trades: 3})
+	handler := gdax.NewHandler(nil, orderbook.NewBook(config.ProductID, board), board)
+	replay := dashboard.NewReplay(handler, dir, 0)
+	board.SetSource(handler.Book(), replay)
+	handler.AddHandlerConsumer(board)
+	start := time.Now()
+	board.Render(start)
+	if err := replay.Run(make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	frame := board.Render(start.Add(time.Second))
+
+	if state := replay.FeedState(); state.Status != "done" || !strings.Contains(state.Source, fmt.Sprintf("replay %d msgs", len(messages)+1)) {
+		t.Fatalf("feed state is %+v after replaying %d messages", state, len(messages)+1)
+	}
+	if !strings.Contains(frame, fmt.Sprintf("%d msgs/s", len(messages))) {
+		t.Fatalf("frame does not show %d msgs/s", len(messages))
+	}
+	book := handler.Book()
+	bids, asks := book.GetDepth(common.BidSide, 5), book.GetDepth(common.AskSide, 5)
+	if len(bids) == 0 || len(asks) == 0 {
+		t.Fatalf("replayed book has %d bid and %d ask levels", len(bids), len(asks))
+	}
+	spread := "spread " + asks[0].Price.Sub(bids[0].Price).String()
+	if !strings.Contains(frame, spread) {
+		t.Fatalf("frame does not show %q", spread)
+	}
+	for _, level := range append(bids, asks...) {
+		if !strings.Contains(frame, level.Price.String()+" ") {
+			t.Fatalf("frame does not show the level at %s", level.Price)
+		}
+	}
+	trades := strings.Count(frame, " buy") + strings.Count(frame, " sell")
+	matches := 0
+	for _, message := range messages {
+		if message.Type == "match" {
+			matches++
+		}
+	}
+	if matches >= 3 && trades != 3 {
+		t.Fatalf("frame lists %d trades, want 3", trades)
+	}
+}
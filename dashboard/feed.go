@@ -0,0 +1,151 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// maxReplayPause bounds how long a replay waits between two recorded messages, so quiet
+// stretches of a recording do not freeze the dashboard.
+const maxReplayPause = time.Second
+
+// LiveFeed is the state of a handler following Coinbase.
+type LiveFeed struct {
+	handler *gdax.Handler
+}
+
+func NewLiveFeed(handler *gdax.Handler) *LiveFeed {
+	return &LiveFeed{handler: handler}
+}
+
+func (feed *LiveFeed) FeedState() FeedState {
+	heartbeat := feed.handler.Heartbeat()
+	gaps := feed.handler.GapStats()
+	state := FeedState{
+		Source:        "live " + string(feed.handler.FeedMode()),
+		Status:        "live",
+		LastHeartbeat: heartbeat.Last,
+		Gaps:          gaps.Gaps,
+		Resyncs:       gaps.Resyncs,
+	}
+	switch {
+	case heartbeat.Sequence == 0 && heartbeat.Last.IsZero():
+		state.Status = "syncing"
+	case heartbeat.Stale || gaps.Stale:
+		state.Status = "stale"
+	}
+	return state
+}
+
+// Replay feeds a handler from a journal file, or every file of a product in a journal
+// directory, at Speed times the recorded pace. A speed of 0 replays as fast as possible.
+type Replay struct {
+	handler *gdax.Handler
+	path    string
+	speed   float64
+
+	mu       sync.Mutex
+	messages int64
+	gaps     int64
+	at       time.Time
+	broken   bool
+	done     bool
+	err      error
+}
+
+func NewReplay(handler *gdax.Handler, path string, speed float64) *Replay {
+	return &Replay{handler: handler, path: path, speed: speed}
+}
+
+// Run applies the recording to the handler's book, locking it for each message so a dashboard
+// can draw it meanwhile. It returns once the recording ends or stop is closed.
+func (replay *Replay) Run(stop <-chan struct{}) error {
+	info, err := os.Stat(replay.path)
+	if err != nil {
+		return errors.Wrap(err, "Could not open replay")
+	}
+	var last time.Time
+	apply := func(line []byte) error {
+		header := struct {
+			Time gdaxClient.Time `json:"time"`
+		}{}
+		if err := json.Unmarshal(line, &header); err != nil {
+			return errors.Wrap(err, "Could not unmarshal recorded message")
+		}
+		at := header.Time.Time()
+		if replay.speed > 0 && !at.IsZero() {
+			if !last.IsZero() && at.After(last) {
+				pause := time.Duration(float64(at.Sub(last)) / replay.speed)
+				if pause > maxReplayPause {
+					pause = maxReplayPause
+				}
+				select {
+				case <-stop:
+					return errStopped
+				case <-time.After(pause):
+				}
+			}
+			last = at
+		}
+		book := replay.handler.Book()
+		book.Lock()
+		err := replay.handler.ApplyRecorded(line)
+		book.Unlock()
+		replay.mu.Lock()
+		replay.messages++
+		replay.broken = replay.handler.ReplayBroken()
+		if !at.IsZero() {
+			replay.at = at
+		}
+		if err == gdax.ErrReplayGap {
+			replay.gaps++
+			err = nil
+		}
+		replay.mu.Unlock()
+		return err
+	}
+	if info.IsDir() {
+		err = journal.Read(replay.path, replay.handler.Book().ID, apply)
+	} else {
+		err = journal.ReadFile(replay.path, apply)
+	}
+	if err == errStopped {
+		err = nil
+	}
+	replay.mu.Lock()
+	replay.done, replay.err = true, err
+	replay.mu.Unlock()
+	return err
+}
+
+var errStopped = errors.New("replay stopped")
+
+func (replay *Replay) FeedState() FeedState {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+	state := FeedState{
+		Source: fmt.Sprintf("replay %d msgs at %s", replay.messages, formatTime(replay.at)),
+		Status: "replaying",
+		Gaps:   replay.gaps,
+	}
+	if replay.speed > 0 {
+		state.Source += fmt.Sprintf(" (%gx)", replay.speed)
+	}
+	switch {
+	case replay.err != nil:
+		state.Status = "failed: " + replay.err.Error()
+	case replay.done:
+		state.Status = "done"
+	case replay.broken:
+		state.Status = "stale"
+	}
+	return state
+}
@@ -0,0 +1,299 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"go.uber.org/zap"
+)
+
+type Format string
+
+const (
+	CSV     Format = "csv"
+	Parquet Format = "parquet"
+)
+
+const defaultLevels = 10
+
+// Config picks what an Exporter writes. Depth is sampled every SampleInterval of feed time; zero
+// disables it. Levels is the depth per side, 10 by default.
+type Config struct {
+	Dir            string
+	Format         Format
+	Levels         int
+	SampleInterval time.Duration
+}
+
+// Exporter writes a book's trades, sampled depth and events as flat files partitioned by product
+// and UTC day, at <dir>/<dataset>/product=<product>/date=<YYYY-MM-DD>/<first sequence>.<format>.
+// A file that already exists, from an earlier run or a sequence that restarted after a reconnect,
+// is kept and the new one is named <first sequence>-<n>.<format>.
+// It is an orderbook.EventListener and reads the book directly, which is safe on the adapter
+// goroutine that calls it. Parquet files are only readable once Close or the next day ends them.
+type Exporter struct {
+	config Config
+	book   *orderbook.Book
+
+	mu         sync.Mutex
+	files      map[string]*partitionFile
+	lastTime   time.Time
+	nextSample time.Time
+	err        error
+	closed     bool
+}
+
+type rowWriter interface {
+	write(r row) error
+	close() error
+}
+
+// partitionFile is the open file of one dataset, product and day.
+type partitionFile struct {
+	date   string
+	writer rowWriter
+}
+
+func NewExporter(book *orderbook.Book, config Config) (*Exporter, error) {
+	if config.Format != CSV && config.Format != Parquet {
+		return nil, fmt.Errorf("Export format %q is not csv or parquet", config.Format)
+	}
+	if config.Levels <= 0 {
+		config.Levels = defaultLevels
+	}
+	return &Exporter{config: config, book: book, files: map[string]*partitionFile{}}, nil
+}
+
+// Event writes the event, and the trade and depth sample it leads to. Events after Close are
+// ignored, so the handler may still be running when the exporter is closed.
+func (exporter *Exporter) Event(event orderbook.Event) {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if exporter.err != nil || exporter.closed {
+		return
+	}
+	if err := exporter.export(event); err != nil {
+		zap.L().Error("Could not export event. Export stopped", zap.String("product", event.ProductID), zap.Error(err))
+		exporter.err = err
+	}
+}
+
+func (exporter *Exporter) export(event orderbook.Event) error {
+	// orders from snapshots and checkpoints carry no time, so they take the one before them
+	at := event.Time
+	if at.IsZero() {
+		at = exporter.lastTime
+	}
+	if at.IsZero() {
+		at = exporter.book.Updated
+	}
+	exporter.lastTime = at
+
+	err := exporter.write(eventsDataset, event.ProductID, at, event.Sequence, EventRow{
+		Product:  event.ProductID,
+		Venue:    event.Venue,
+		Time:     micros(at),
+		Sequence: event.Sequence,
+		Type:     string(event.Type),
+		OrderID:  event.OrderID,
+		Side:     common.ToString(event.Side),
+		Price:    float(event.Price),
+		Size:     float(event.Size),
+		OldSize:  float(event.OldSize),
+	})
+	if err != nil {
+		return err
+	}
+	if trade := event.Trade; trade != nil && (event.Type == orderbook.EventMatch || event.Type == orderbook.EventTrade) {
+		err = exporter.write(tradesDataset, event.ProductID, trade.Time, event.Sequence, TradeRow{
+			Product:      event.ProductID,
+			Time:         micros(trade.Time),
+			TradeID:      int64(trade.TradeID),
+			Sequence:     trade.Sequence,
+			Price:        float(trade.Price),
+			Size:         float(trade.Size),
+			MakerSide:    common.ToString(trade.MakerSide),
+			MakerOrderID: trade.MakerOrderID,
+			TakerOrderID: trade.TakerOrderID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// a book is only sampled between messages, not while a snapshot is loading
+	if exporter.config.SampleInterval <= 0 || event.Time.IsZero() || event.Type == orderbook.EventReset {
+		return nil
+	}
+	if exporter.nextSample.IsZero() {
+		exporter.nextSample = at.Truncate(exporter.config.SampleInterval)
+	}
+	if at.Before(exporter.nextSample) {
+		return nil
+	}
+	exporter.nextSample = at.Truncate(exporter.config.SampleInterval).Add(exporter.config.SampleInterval)
+	return exporter.sample(event.ProductID, event.Sequence, at)
+}
+
+// sample writes the top levels of both sides as the book is after the event.
+func (exporter *Exporter) sample(product string, sequence int64, at time.Time) error {
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		for i, level := range exporter.book.GetDepth(side, exporter.config.Levels) {
+			err := exporter.write(depthDataset, product, at, sequence, DepthRow{
+				Product:   product,
+				Time:      micros(at),
+				Sequence:  sequence,
+				Side:      common.ToString(side),
+				Level:     int32(i),
+				Price:     float(level.Price),
+				Size:      float(level.Size),
+				NumOrders: int32(level.NumOrders),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write appends a row to its partition, starting a new file when the day changes.
+func (exporter *Exporter) write(set dataset, product string, at time.Time, sequence int64, r row) error {
+	date := at.UTC().Format("2006-01-02")
+	key := set.name + "/" + product
+	file, found := exporter.files[key]
+	if found && file.date != date {
+		delete(exporter.files, key)
+		if err := file.writer.close(); err != nil {
+			return err
+		}
+		found = false
+	}
+	if !found {
+		dir := filepath.Join(exporter.config.Dir, set.name, "product="+product, "date="+date)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrap(err, "Could not create export directory")
+		}
+		opened, err := exporter.open(set, dir, sequence)
+		if err != nil {
+			return err
+		}
+		file = &partitionFile{date: date, writer: opened}
+		exporter.files[key] = file
+	}
+	return file.writer.write(r)
+}
+
+func (exporter *Exporter) open(set dataset, dir string, sequence int64) (rowWriter, error) {
+	name := fmt.Sprintf("%d", sequence)
+	file, err := os.OpenFile(filepath.Join(dir, name+"."+string(exporter.config.Format)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	for n := 1; os.IsExist(err); n++ {
+		file, err = os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s-%d.%s", name, n, exporter.config.Format)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not create export file")
+	}
+	if exporter.config.Format == CSV {
+		csvFile := &csvWriter{file: file, writer: csv.NewWriter(file)}
+		if err := csvFile.writer.Write(set.header); err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "Could not write CSV header")
+		}
+		return csvFile, nil
+	}
+	parquetFile, err := writer.NewParquetWriterFromWriter(file, set.prototype, 1)
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "Could not create Parquet writer")
+	}
+	parquetFile.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &parquetWriter{file: file, writer: parquetFile}, nil
+}
+
+// Close ends every open file and reports the first error of the export.
+func (exporter *Exporter) Close() error {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	exporter.closed = true
+	for key, file := range exporter.files {
+		if err := file.writer.close(); err != nil && exporter.err == nil {
+			exporter.err = err
+		}
+		delete(exporter.files, key)
+	}
+	return exporter.err
+}
+
+// ExportJournal replays a product's journal and exports what it did to the book.
+func ExportJournal(journalDir string, product string, config Config) error {
+	book := orderbook.NewBook(product, nil)
+	exporter, err := NewExporter(book, config)
+	if err != nil {
+		return err
+	}
+	handler := gdax.NewHandler(nil, book, nil)
+	handler.AddEventListener(exporter)
+	err = journal.Read(journalDir, product, func(line []byte) error {
+		if err := handler.ApplyRecorded(line); err != nil && err != gdax.ErrReplayGap {
+			return err
+		}
+		return nil
+	})
+	if closeErr := exporter.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type csvWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func (w *csvWriter) write(r row) error {
+	return w.writer.Write(r.csvRecord())
+}
+
+func (w *csvWriter) close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return errors.Wrap(err, "Could not write CSV file")
+	}
+	return w.file.Close()
+}
+
+type parquetWriter struct {
+	file   *os.File
+	writer *writer.ParquetWriter
+}
+
+func (w *parquetWriter) write(r row) error {
+	return w.writer.Write(r)
+}
+
+func (w *parquetWriter) close() error {
+	if err := w.writer.WriteStop(); err != nil {
+		w.file.Close()
+		return errors.Wrap(err, "Could not finish Parquet file")
+	}
+	return w.file.Close()
+}
+
+// float rounds a decimal to the nearest float64, which is what the columns hold. Sizes and prices
+// of more than 15 significant digits lose their last digits.
+func float(value decimal.Decimal) float64 {
+	f, _ := value.Float64()
+	return f
+}
@@ -0,0 +1,170 @@
+package export_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/export"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// eventCounter counts events by type.
+type eventCounter map[orderbook.EventType]int
+
+func (counter eventCounter) Event(event orderbook.Event) {
+	counter[event.Type] += 1
+}
+
+// parquetFile lets the Parquet reader open an exported file.
+type parquetFile struct {
+	*os.File
+}
+
+func (file parquetFile) Open(name string) (source.ParquetFile, error) {
+	opened, err := os.Open(file.Name())
+	return parquetFile{opened}, err
+}
+
+func (file parquetFile) Create(name string) (source.ParquetFile, error) {
+	return nil, fmt.Errorf("exported files are read only")
+}
+
+// exportedRows counts the rows of every file of a dataset, checking the CSV header on the way.
+func exportedRows(dir string, format export.Format, dataset string, prototype interface{}) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, dataset, "product=*", "date=*", "*."+string(format)))
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		if format == export.CSV {
+			records, err := csv.NewReader(file).ReadAll()
+			file.Close()
+			if err != nil {
+				return 0, err
+			}
+			if len(records) == 0 || records[0][0] != "product" {
+				return 0, fmt.Errorf("%s has no header", path)
+			}
+			rows += len(records) - 1
+			continue
+		}
+		parquetReader, err := reader.NewParquetReader(parquetFile{file}, prototype, 1)
+		if err != nil {
+			file.Close()
+			return 0, err
+		}
+		rows += int(parquetReader.GetNumRows())
+		parquetReader.ReadStop()
+		file.Close()
+	}
+	return rows, nil
+}
+
+// writeJournal records a generated session, led by an empty snapshot, as a journal in dir.
+func writeJournal(t *testing.T, dir string) synthetic.Config {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	messages := append(generator.Generate(5000), generator.Flush()...)
+	writer, err := journal.NewWriter(dir, config.ProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.Message(gdax.SnapshotStartJSON(config.ProductID, messages[0].Sequence-1, messages[0].Time.Time()))
+	for _, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Message(string(line))
+	}
+	return config
+}
+
+func TestExportJournalToCSVAndParquet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orderbook-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := writeJournal(t, filepath.Join(dir, "journal"))
+	counter := eventCounter{}
+	handler := gdax.NewHandler(nil, orderbook.NewBook(config.ProductID, nil), nil)
+	handler.AddEventListener(counter)
+	if err := journal.Read(filepath.Join(dir, "journal"), config.ProductID, handler.ApplyRecorded); err != nil {
+		t.Fatal(err)
+	}
+	events := 0
+	for _, count := range counter {
+		events += count
+	}
+
+	for _, format := range []export.Format{export.CSV, export.Parquet} {
+		out := filepath.Join(dir, string(format))
+		err := export.ExportJournal(filepath.Join(dir, "journal"), config.ProductID, export.Config{Dir: out, Format: format, Levels: 5,
+			SampleInterval: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dataset := range []struct {
+			name      string
+			prototype interface{}
+			want      int
+		}{{"events", new(export.EventRow), events}, {"trades", new(export.TradeRow), counter[orderbook.EventMatch]}, {"depth", new(export.DepthRow), -1}} {
+			rows, err := exportedRows(out, format, dataset.name, dataset.prototype)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dataset.want >= 0 && rows != dataset.want {
+				t.Fatalf("%s %s has %d rows, want %d", format, dataset.name, rows, dataset.want)
+			}
+			if rows == 0 {
+				t.Fatalf("%s %s is empty", format, dataset.name)
+			}
+		}
+		if paths, _ := filepath.Glob(filepath.Join(out, "depth", "product="+config.ProductID, "date=*")); len(paths) == 0 {
+			t.Fatalf("%s depth is not partitioned by product and date", format)
+		}
+	}
+}
+
+func TestExportingAgainKeepsEarlierFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orderbook-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := writeJournal(t, filepath.Join(dir, "journal"))
+	out := filepath.Join(dir, "csv")
+	counts := []int{}
+	for i := 0; i < 2; i++ {
+		if err := export.ExportJournal(filepath.Join(dir, "journal"), config.ProductID, export.Config{Dir: out, Format: export.CSV}); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := exportedRows(out, export.CSV, "events", new(export.EventRow))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, rows)
+	}
+	if counts[1] != 2*counts[0] {
+		t.Fatalf("events have %d rows after exporting twice, want %d", counts[1], 2*counts[0])
+	}
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"strconv"
+	"time"
+)
+
+// The rows of each dataset. Parquet columns are named after the CSV header. Times are UTC, as
+// RFC 3339 in CSV and as microsecond timestamps in Parquet. Prices and sizes are doubles, sides are
+// BID or ASK.
+
+// TradeRow is one fill. MakerSide is the side of the resting order.
+type TradeRow struct {
+	Product      string  `parquet:"name=product, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Time         int64   `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	TradeID      int64   `parquet:"name=trade_id, type=INT64"`
+	Sequence     int64   `parquet:"name=sequence, type=INT64"`
+	Price        float64 `parquet:"name=price, type=DOUBLE"`
+	Size         float64 `parquet:"name=size, type=DOUBLE"`
+	MakerSide    string  `parquet:"name=maker_side, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	MakerOrderID string  `parquet:"name=maker_order_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TakerOrderID string  `parquet:"name=taker_order_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// DepthRow is one level of a sampled book. Level 0 is the best price of its side.
+type DepthRow struct {
+	Product   string  `parquet:"name=product, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Time      int64   `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	Sequence  int64   `parquet:"name=sequence, type=INT64"`
+	Side      string  `parquet:"name=side, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Level     int32   `parquet:"name=level, type=INT32"`
+	Price     float64 `parquet:"name=price, type=DOUBLE"`
+	Size      float64 `parquet:"name=size, type=DOUBLE"`
+	NumOrders int32   `parquet:"name=num_orders, type=INT32"`
+}
+
+// EventRow is one orderbook.Event, the L3 change a feed message made to the book.
+type EventRow struct {
+	Product  string  `parquet:"name=product, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Venue    string  `parquet:"name=venue, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Time     int64   `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	Sequence int64   `parquet:"name=sequence, type=INT64"`
+	Type     string  `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	OrderID  string  `parquet:"name=order_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Side     string  `parquet:"name=side, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Price    float64 `parquet:"name=price, type=DOUBLE"`
+	Size     float64 `parquet:"name=size, type=DOUBLE"`
+	OldSize  float64 `parquet:"name=old_size, type=DOUBLE"`
+}
+
+type row interface {
+	csvRecord() []string
+}
+
+// dataset is one kind of row and the directory its files go to.
+type dataset struct {
+	name      string
+	header    []string
+	prototype interface{}
+}
+
+var (
+	tradesDataset = dataset{"trades", []string{"product", "time", "trade_id", "sequence", "price", "size", "maker_side", "maker_order_id",
+		"taker_order_id"}, new(TradeRow)}
+	depthDataset  = dataset{"depth", []string{"product", "time", "sequence", "side", "level", "price", "size", "num_orders"}, new(DepthRow)}
+	eventsDataset = dataset{"events", []string{"product", "venue", "time", "sequence", "type", "order_id", "side", "price", "size", "old_size"},
+		new(EventRow)}
+)
+
+func (r TradeRow) csvRecord() []string {
+	return []string{r.Product, formatTime(r.Time), strconv.FormatInt(r.TradeID, 10), strconv.FormatInt(r.Sequence, 10), formatFloat(r.Price),
+		formatFloat(r.Size), r.MakerSide, r.MakerOrderID, r.TakerOrderID}
+}
+
+func (r DepthRow) csvRecord() []string {
+	return []string{r.Product, formatTime(r.Time), strconv.FormatInt(r.Sequence, 10), r.Side, strconv.Itoa(int(r.Level)), formatFloat(r.Price),
+		formatFloat(r.Size), strconv.Itoa(int(r.NumOrders))}
+}
+
+func (r EventRow) csvRecord() []string {
+	return []string{r.Product, r.Venue, formatTime(r.Time), strconv.FormatInt(r.Sequence, 10), r.Type, r.OrderID, r.Side, formatFloat(r.Price),
+		formatFloat(r.Size), formatFloat(r.OldSize)}
+}
+
+func micros(at time.Time) int64 {
+	return at.UnixNano() / int64(time.Microsecond)
+}
+
+func formatTime(micros int64) string {
+	return time.Unix(0, micros*int64(time.Microsecond)).UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
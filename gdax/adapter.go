@@ -0,0 +1,96 @@
+package gdax
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+// Venue is how the Coinbase adapter names its events.
+const Venue = "coinbase"
+
+// Handler is the Coinbase orderbook.Adapter.
+func (handler *Handler) Venue() string {
+	return Venue
+}
+
+func (handler *Handler) Book() *orderbook.Book {
+	return handler.book
+}
+
+func (handler *Handler) AddEventListener(listener orderbook.EventListener) {
+	handler.eventListeners = append(handler.eventListeners, listener)
+}
+
+// Trade is the exchange-neutral form of a match.
+func (msg Match) Trade() orderbook.Trade {
+	return orderbook.Trade{
+		TradeID:      msg.TradeID,
+		Sequence:     msg.Sequence,
+		MakerOrderID: msg.MakerOrderID,
+		TakerOrderID: msg.TakerOrderID,
+		Time:         msg.Time.Time(),
+		ProductID:    msg.ProductID,
+		Size:         msg.Size,
+		Price:        msg.Price,
+		MakerSide:    msg.MatchSide,
+	}
+}
+
+func (handler *Handler) flushEvent(event orderbook.Event) {
+	if len(handler.eventListeners) == 0 {
+		return
+	}
+	event.Venue = Venue
+	event.ProductID = handler.book.ID
+	event.Sequence = handler.sequence
+	for _, listener := range handler.eventListeners {
+		listener.Event(event)
+	}
+}
+
+// flushOrderEvent reports an order resting on, leaving or resized on the book.
+func (handler *Handler) flushOrderEvent(eventType orderbook.EventType, order *orderbook.Order, oldSize decimal.Decimal, at time.Time) {
+	handler.flushEvent(orderbook.Event{Type: eventType, Time: at, OrderID: order.ID, Side: order.Side, Price: order.Price,
+		Size: order.Size, OldSize: oldSize})
+}
+
+// flushBookEvents reports the whole book as a reset followed by an open for every order, after
+// it was rebuilt from a snapshot or checkpoint.
+func (handler *Handler) flushBookEvents() {
+	if len(handler.eventListeners) == 0 {
+		return
+	}
+	handler.flushEvent(orderbook.Event{Type: orderbook.EventReset, Time: handler.book.Updated})
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		for _, order := range handler.book.GetOrders(side) {
+			handler.flushOrderEvent(orderbook.EventOpen, order, decimal.Decimal{}, order.Time)
+		}
+	}
+}
+
+// clearBook empties the book and the orders received but not yet open. The caller holds the lock.
+func (handler *Handler) clearBook() {
+	handler.book.Clear()
+	handler.pending = map[string]Received{}
+}
+
+// addPending tracks a received order until it either opens on the book or is done.
+func (handler *Handler) addPending(message Received) {
+	handler.pending[message.OrderID] = message
+}
+
+func (handler *Handler) removePending(id string) (Received, bool) {
+	message, found := handler.pending[id]
+	if found {
+		delete(handler.pending, id)
+	}
+	return message, found
+}
+
+// NumPending counts orders received but not yet open or done.
+func (handler *Handler) NumPending() int {
+	return len(handler.pending)
+}
@@ -0,0 +1,64 @@
+package gdax
+
+import "time"
+
+const DefaultWebsocketURL = "wss://ws-feed.gdax.com"
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// ConnectionConfig is where the handler connects and how long it waits to reconnect. Zero fields
+// take the defaults. Credentials are only used by TrackOwnOrders.
+type ConnectionConfig struct {
+	WebsocketURL string
+	Credentials  Credentials
+	Backoff      BackoffConfig
+}
+
+// Credentials are an API key with the base64 Secret Coinbase issued with it.
+type Credentials struct {
+	Key        string
+	Secret     string
+	Passphrase string
+}
+
+// BackoffConfig waits Initial before the first reconnect and doubles up to Max. It starts over
+// after a connection that lasted longer than Max.
+type BackoffConfig struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func newConnectionConfig(config ConnectionConfig) ConnectionConfig {
+	if config.WebsocketURL == "" {
+		config.WebsocketURL = DefaultWebsocketURL
+	}
+	if config.Backoff.Initial <= 0 {
+		config.Backoff.Initial = defaultInitialBackoff
+	}
+	if config.Backoff.Max <= 0 {
+		config.Backoff.Max = defaultMaxBackoff
+	}
+	if config.Backoff.Max < config.Backoff.Initial {
+		config.Backoff.Max = config.Backoff.Initial
+	}
+	return config
+}
+
+// SetConnection replaces the connection config. Call it before Run.
+func (handler *Handler) SetConnection(config ConnectionConfig) {
+	handler.connection = newConnectionConfig(config)
+}
+
+// next is how long to wait after a connection that lasted connected, given the last wait.
+func (config BackoffConfig) next(last time.Duration, connected time.Duration) time.Duration {
+	if last <= 0 || connected > config.Max {
+		return config.Initial
+	}
+	if last*2 > config.Max {
+		return config.Max
+	}
+	return last * 2
+}
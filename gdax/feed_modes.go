@@ -0,0 +1,183 @@
+package gdax
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+type FeedMode string
+
+const (
+	// FullFeed keeps every order from the "full" channel.
+	FullFeed FeedMode = "full"
+	// Level2Feed keeps aggregated price levels from the "level2" channel.
+	Level2Feed FeedMode = "level2"
+	// TickerFeed keeps only the best bid and ask and the last trade from the "ticker" channel.
+	TickerFeed FeedMode = "ticker"
+)
+
+// FeedModes are every feed mode, the values book.feed accepts.
+var FeedModes = []FeedMode{FullFeed, Level2Feed, TickerFeed}
+
+// Level2UpdateMessageType is what consumers receive for each level a level2 update changes. It
+// carries the side, price and new total size of the level.
+const Level2UpdateMessageType = "l2update"
+
+type tickerFields struct {
+	BestBidSize string `json:"best_bid_size"`
+	BestAskSize string `json:"best_ask_size"`
+}
+
+// SetFeedMode picks the channel the handler subscribes to. Call it before Run or replaying. In
+// level2 and ticker mode every level of the book is a single order, so Snapshot, the depth API
+// and consumers work unchanged, and the book's sequence counts local updates since the exchange
+// does not number level2 messages.
+func (handler *Handler) SetFeedMode(mode FeedMode) {
+	handler.mode = mode
+}
+
+func (handler *Handler) FeedMode() FeedMode {
+	return handler.mode
+}
+
+// applyAggregated applies a level2 or ticker message from the socket.
+func (handler *Handler) applyAggregated(message gdaxClient.Message, data []byte, received time.Time) error {
+	handler.flushRawFeedMessage(string(data))
+	handler.book.Lock()
+	err := handler.handleAggregated(message, data, received)
+	handler.book.Unlock()
+	if err != nil {
+		return errors.Wrapf(err, "Could not read %s message", message.Type)
+	}
+	if message.Type == SnapshotMessageType {
+		handler.flushClear()
+	}
+	handler.observeMessage(message, received)
+	return nil
+}
+
+func (handler *Handler) handleAggregated(message gdaxClient.Message, data []byte, received time.Time) error {
+	switch message.Type {
+	case SnapshotMessageType:
+		handler.clearBook()
+		handler.sequence = 0
+		handler.book.Sequence = 0
+		handler.book.Updated = received
+		handler.flushEvent(orderbook.Event{Type: orderbook.EventReset, Time: received})
+		for _, entries := range []struct {
+			side   common.Side
+			levels [][]string
+		}{{common.BidSide, message.Bids}, {common.AskSide, message.Asks}} {
+			for _, entry := range entries.levels {
+				if len(entry) < 2 {
+					return fmt.Errorf("Level %v has no size", entry)
+				}
+				price, size, err := parseLevel(entry[0], entry[1])
+				if err != nil {
+					return err
+				}
+				if err := handler.book.SetLevel(entries.side, price, size, received); err != nil {
+					return err
+				}
+				handler.flushEvent(orderbook.Event{Type: orderbook.EventLevel, Time: received, Side: entries.side, Price: price, Size: size})
+			}
+		}
+		return nil
+	case Level2UpdateMessageType:
+		for _, change := range message.Changes {
+			if len(change) < 3 {
+				return fmt.Errorf("Level2 change %v is incomplete", change)
+			}
+			side, err := ToSide(change[0])
+			if err != nil {
+				return err
+			}
+			price, size, err := parseLevel(change[1], change[2])
+			if err != nil {
+				return err
+			}
+			if err := handler.book.SetLevel(side, price, size, received); err != nil {
+				return err
+			}
+			// consumers see one update per level, in the same shape as the other messages
+			handler.nextLocalSequence(received)
+			handler.flushEvent(orderbook.Event{Type: orderbook.EventLevel, Time: received, Side: side, Price: price, Size: size})
+			handler.flushBookUpdate(gdaxClient.Message{Type: Level2UpdateMessageType, ProductId: message.ProductId, Sequence: handler.sequence,
+				Time: message.Time, Side: change[0], Price: change[1], Size: change[2]})
+		}
+		return nil
+	case "ticker":
+		fields := tickerFields{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return errors.Wrap(err, "Could not unmarshal ticker message")
+		}
+		handler.nextLocalSequence(received)
+		for _, top := range []struct {
+			side        common.Side
+			price, size string
+		}{{common.BidSide, message.BestBid, fields.BestBidSize}, {common.AskSide, message.BestAsk, fields.BestAskSize}} {
+			if top.price == "" {
+				continue
+			}
+			if top.size == "" {
+				top.size = "0"
+			}
+			price, size, err := parseLevel(top.price, top.size)
+			if err != nil {
+				return err
+			}
+			if err := handler.book.SetTop(top.side, price, size, received); err != nil {
+				return err
+			}
+			handler.flushEvent(orderbook.Event{Type: orderbook.EventTop, Time: received, Side: top.side, Price: price, Size: size})
+		}
+		tick := message
+		tick.Sequence = handler.sequence
+		handler.flushBookUpdate(tick)
+		if message.TradeId == 0 {
+			return nil
+		}
+		// the ticker's side is the taker's, a match's is the maker's
+		makerSide := "sell"
+		if message.Side == "sell" {
+			makerSide = "buy"
+		}
+		match, err := NewMatchMessage(message.TradeId, handler.sequence, "", "", message.Time, message.ProductId, message.LastSize,
+			message.Price, makerSide)
+		if err != nil {
+			return errors.Wrap(err, "Could not create match from ticker")
+		}
+		trade := match.Trade()
+		handler.book.AddTrade(trade)
+		handler.flushEvent(orderbook.Event{Type: orderbook.EventTrade, Time: trade.Time, Side: trade.MakerSide, Price: trade.Price,
+			Size: trade.Size, Trade: &trade})
+		handler.flushTradeTick(match)
+		return nil
+	}
+	return nil
+}
+
+func (handler *Handler) nextLocalSequence(received time.Time) {
+	handler.sequence += 1
+	handler.book.Sequence = handler.sequence
+	handler.book.Updated = received
+}
+
+func parseLevel(price string, size string) (decimal.Decimal, decimal.Decimal, error) {
+	priceDec, err := decimal.NewFromString(price)
+	if err != nil {
+		return decimal.Decimal{}, decimal.Decimal{}, errors.Wrap(err, "Could not convert level price to decimal")
+	}
+	sizeDec, err := decimal.NewFromString(size)
+	if err != nil {
+		return decimal.Decimal{}, decimal.Decimal{}, errors.Wrap(err, "Could not convert level size to decimal")
+	}
+	return priceDec, sizeDec, nil
+}
@@ -0,0 +1,92 @@
+package gdax_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// levelSizes maps "side@price" to the size resting there.
+func levelSizes(book *orderbook.Book) map[string]string {
+	sizes := map[string]string{}
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		for _, level := range book.GetDepth(side, 0) {
+			sizes[common.ToString(side)+"@"+level.Price.String()] = level.Size.String()
+		}
+	}
+	return sizes
+}
+
+// level2Changes is the l2update the exchange would send for the difference between two books.
+func level2Changes(before map[string]string, after map[string]string) [][]string {
+	changes := [][]string{}
+	for key, size := range after {
+		if before[key] != size {
+			changes = append(changes, splitLevelKey(key, size))
+		}
+	}
+	for key := range before {
+		if _, found := after[key]; !found {
+			changes = append(changes, splitLevelKey(key, "0"))
+		}
+	}
+	return changes
+}
+
+func splitLevelKey(key string, size string) []string {
+	parts := strings.SplitN(key, "@", 2)
+	side := "buy"
+	if parts[0] == common.ToString(common.AskSide) {
+		side = "sell"
+	}
+	return []string{side, parts[1], size}
+}
+
+func TestLevel2UpdatesRebuildTheAggregatedBook(t *testing.T) {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	full := orderbook.NewBook(config.ProductID, nil)
+	fullHandler := gdax.NewHandler(nil, full, nil)
+	level2 := orderbook.NewBook(config.ProductID, nil)
+	level2Handler := gdax.NewHandler(nil, level2, nil)
+	level2Handler.SetFeedMode(gdax.Level2Feed)
+
+	snapshot, _ := json.Marshal(gdaxClient.Message{Type: gdax.SnapshotMessageType, ProductId: config.ProductID,
+		Bids: [][]string{}, Asks: [][]string{}})
+	if err := level2Handler.ApplyRecorded(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	before := levelSizes(full)
+	for _, message := range append(generator.Generate(5000), generator.Flush()...) {
+		if err := fullHandler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+		after := levelSizes(full)
+		if changes := level2Changes(before, after); len(changes) > 0 {
+			line, _ := json.Marshal(gdaxClient.Message{Type: gdax.Level2UpdateMessageType, ProductId: config.ProductID, Changes: changes})
+			if err := level2Handler.ApplyRecorded(line); err != nil {
+				t.Fatal(err)
+			}
+		}
+		before = after
+	}
+	got, want := levelSizes(level2), levelSizes(full)
+	if len(got) != len(want) {
+		t.Fatalf("level2 book has %d levels, full book %d", len(got), len(want))
+	}
+	for key, size := range want {
+		if got[key] != size {
+			t.Fatalf("level %s is %s in the level2 book and %s in the full book", key, got[key], size)
+		}
+	}
+	if err := level2.CheckUncrossed(); err != nil {
+		t.Fatal(err)
+	}
+}
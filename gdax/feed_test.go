@@ -0,0 +1,107 @@
+package gdax
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	ws "github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// restBook is a level 3 REST snapshot as Coinbase serves it, with [price, size, order id] entries.
+type restBook struct {
+	Sequence int64      `json:"sequence"`
+	Bids     [][]string `json:"bids"`
+	Asks     [][]string `json:"asks"`
+}
+
+// snapshotServer serves the book SyncBook fetches and counts the requests for it. onSync, when
+// set, runs while a request is being served.
+type snapshotServer struct {
+	mu     sync.Mutex
+	book   restBook
+	syncs  int
+	onSync func()
+}
+
+func newSnapshotServer(t *testing.T, book restBook) (*snapshotServer, *gdaxClient.Client) {
+	snapshots := &snapshotServer{book: book}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshots.mu.Lock()
+		defer snapshots.mu.Unlock()
+		snapshots.syncs += 1
+		if snapshots.onSync != nil {
+			snapshots.onSync()
+		}
+		json.NewEncoder(w).Encode(snapshots.book)
+	}))
+	t.Cleanup(server.Close)
+	client := gdaxClient.NewClient("", "", "")
+	client.BaseURL = server.URL
+	return snapshots, client
+}
+
+func (snapshots *snapshotServer) setBook(book restBook) {
+	snapshots.mu.Lock()
+	snapshots.book = book
+	snapshots.mu.Unlock()
+}
+
+func (snapshots *snapshotServer) count() int {
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+	return snapshots.syncs
+}
+
+// dialFeed connects to a websocket server that runs serve on its end of the connection.
+func dialFeed(t *testing.T, serve func(conn *ws.Conn)) *ws.Conn {
+	upgrader := ws.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}))
+	t.Cleanup(server.Close)
+	conn, _, err := ws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntilClosed serves a connection that sends nothing and answers pings until it is closed.
+func readUntilClosed(conn *ws.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// playFeed runs listenToSocket against a websocket that sends messages in order and then closes
+// normally, which is not an error.
+func playFeed(t *testing.T, handler *Handler, messages []gdaxClient.Message) error {
+	conn := dialFeed(t, func(conn *ws.Conn) {
+		for _, message := range messages {
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		}
+		conn.WriteMessage(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseNormalClosure, ""))
+		conn.ReadMessage()
+	})
+	err := handler.listenToSocket(conn)
+	if ws.IsCloseError(errors.Cause(err), ws.CloseNormalClosure) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,298 @@
+package gdax
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+)
+
+type ResyncPolicy string
+
+const (
+	// ResyncImmediate rebuilds the book from a REST snapshot on every gap.
+	ResyncImmediate ResyncPolicy = "immediate"
+	// ResyncReorder holds messages after a gap for up to ReorderWindow in case the missing ones
+	// arrive late, and only resyncs when they do not.
+	ResyncReorder ResyncPolicy = "reorder"
+	// ResyncRateLimited resyncs at most once per MinResyncInterval. In between the book is stale
+	// and messages are dropped.
+	ResyncRateLimited ResyncPolicy = "rate_limited"
+)
+
+// ResyncPolicies are every resync policy, the values gaps.policy accepts.
+var ResyncPolicies = []ResyncPolicy{ResyncImmediate, ResyncReorder, ResyncRateLimited}
+
+const (
+	defaultRecentGaps    = 100
+	defaultReorderWindow = 500 * time.Millisecond
+	defaultReorderBuffer = 1000
+)
+
+// GapConfig is the handler's resync policy. The zero value resyncs immediately.
+type GapConfig struct {
+	Policy            ResyncPolicy
+	ReorderWindow     time.Duration
+	ReorderBuffer     int
+	MinResyncInterval time.Duration
+	RecentGaps        int
+}
+
+// Gap is one break in the feed's sequence numbers.
+type Gap struct {
+	Time      time.Time
+	Expected  int64
+	Received  int64
+	Size      int64
+	Recovered bool
+	Resynced  bool
+}
+
+type GapStats struct {
+	Policy       ResyncPolicy
+	Gaps         int64
+	Recovered    int64
+	Duplicates   int64
+	Resyncs      int64
+	LastGap      time.Time
+	SinceLastGap time.Duration
+	// Stale is set while a rate limited resync is pending and the book is known to be wrong.
+	Stale  bool
+	Recent []Gap
+}
+
+type bufferedMessage struct {
+	message  gdaxClient.Message
+	data     []byte
+	received time.Time
+}
+
+// gapTracker keeps the gap statistics, which the API reads from other goroutines under mu, and
+// the reorder buffer, which only the handler goroutine touches.
+type gapTracker struct {
+	config GapConfig
+
+	mu         sync.Mutex
+	gaps       int64
+	recovered  int64
+	duplicates int64
+	resyncs    int64
+	recent     []Gap
+
+	buffer     map[int64]bufferedMessage
+	deadline   time.Time
+	lastResync time.Time
+	stale      bool
+}
+
+func newGapTracker(config GapConfig) *gapTracker {
+	if config.Policy == "" {
+		config.Policy = ResyncImmediate
+	}
+	if config.ReorderWindow <= 0 {
+		config.ReorderWindow = defaultReorderWindow
+	}
+	if config.ReorderBuffer <= 0 {
+		config.ReorderBuffer = defaultReorderBuffer
+	}
+	if config.RecentGaps <= 0 {
+		config.RecentGaps = defaultRecentGaps
+	}
+	return &gapTracker{config: config, recent: []Gap{}, buffer: map[int64]bufferedMessage{}}
+}
+
+// SetGapPolicy replaces the resync policy. Call it before Run.
+func (handler *Handler) SetGapPolicy(config GapConfig) {
+	handler.gaps = newGapTracker(config)
+}
+
+// GapStats summarises the sequence gaps seen since the handler started.
+func (handler *Handler) GapStats() GapStats {
+	tracker := handler.gaps
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	stats := GapStats{
+		Policy:     tracker.config.Policy,
+		Gaps:       tracker.gaps,
+		Recovered:  tracker.recovered,
+		Duplicates: tracker.duplicates,
+		Resyncs:    tracker.resyncs,
+		Stale:      tracker.stale,
+		Recent:     append([]Gap{}, tracker.recent...),
+	}
+	if len(tracker.recent) > 0 {
+		stats.LastGap = tracker.recent[len(tracker.recent)-1].Time
+		stats.SinceLastGap = time.Since(stats.LastGap)
+	}
+	return stats
+}
+
+func (tracker *gapTracker) duplicate() {
+	tracker.mu.Lock()
+	tracker.duplicates += 1
+	tracker.mu.Unlock()
+}
+
+func (tracker *gapTracker) record(gap Gap) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.gaps += 1
+	if len(tracker.recent) == tracker.config.RecentGaps {
+		tracker.recent = append(tracker.recent[:0], tracker.recent[1:]...)
+	}
+	tracker.recent = append(tracker.recent, gap)
+}
+
+// settle marks how the latest gap ended, unless it already has.
+func (tracker *gapTracker) settle(resynced bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if resynced {
+		tracker.resyncs += 1
+	}
+	if len(tracker.recent) == 0 {
+		return
+	}
+	last := &tracker.recent[len(tracker.recent)-1]
+	if last.Recovered || last.Resynced {
+		return
+	}
+	if resynced {
+		last.Resynced = true
+	} else {
+		last.Recovered = true
+		tracker.recovered += 1
+	}
+}
+
+func (tracker *gapTracker) setStale(stale bool) {
+	tracker.mu.Lock()
+	tracker.stale = stale
+	tracker.mu.Unlock()
+}
+
+func (tracker *gapTracker) isStale() bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.stale
+}
+
+// reset drops held messages when the connection, and with it the sequence, starts over.
+func (tracker *gapTracker) reset() {
+	tracker.buffer = map[int64]bufferedMessage{}
+	tracker.setStale(false)
+}
+
+// sequenced applies a message from the feed according to its sequence number and the policy.
+func (handler *Handler) sequenced(message gdaxClient.Message, data []byte, received time.Time) error {
+	tracker := handler.gaps
+	if message.Sequence <= handler.sequence {
+		tracker.duplicate()
+		metrics.DuplicateMessages.WithLabelValues(handler.book.ID).Inc()
+		zap.L().Debug("Received old message", zap.Int64("sequence number", message.Sequence), zap.Int64("bookSequence", handler.sequence))
+		return nil
+	}
+	if tracker.isStale() {
+		return handler.resync(received)
+	}
+	if message.Sequence != handler.sequence+1 {
+		if len(tracker.buffer) > 0 && !received.Before(tracker.deadline) {
+			zap.L().Info("Missing messages did not arrive in time", zap.Int64("bookSequence", handler.sequence))
+			return handler.resync(received)
+		}
+		return handler.onGap(message, data, received)
+	}
+	if err := handler.applyLive(message, data, received); err != nil {
+		return err
+	}
+	return handler.drainReordered()
+}
+
+// onGap handles a message that skips ahead of the book. It either holds the message in the
+// reorder buffer or resyncs.
+func (handler *Handler) onGap(message gdaxClient.Message, data []byte, received time.Time) error {
+	tracker := handler.gaps
+	if len(tracker.buffer) == 0 {
+		handler.openGap(message.Sequence, received)
+	}
+	if tracker.config.Policy == ResyncReorder && len(tracker.buffer) < tracker.config.ReorderBuffer {
+		tracker.buffer[message.Sequence] = bufferedMessage{message: message, data: data, received: received}
+		return nil
+	}
+	return handler.resync(received)
+}
+
+func (handler *Handler) openGap(sequence int64, now time.Time) {
+	tracker := handler.gaps
+	expected := handler.sequence + 1
+	size := sequence - expected
+	zap.L().Info("Book is out of order", zap.Int64("sequence number", sequence), zap.Int64("bookSequence", handler.sequence),
+		zap.String("policy", string(tracker.config.Policy)))
+	metrics.SequenceGaps.WithLabelValues(handler.book.ID).Inc()
+	metrics.GapSize.WithLabelValues(handler.book.ID).Observe(float64(size))
+	tracker.record(Gap{Time: now, Expected: expected, Received: sequence, Size: size})
+	tracker.deadline = now.Add(tracker.config.ReorderWindow)
+}
+
+// resync rebuilds the book unless the policy's rate limit forbids it, in which case the book
+// stays stale and messages are dropped until one arrives after the interval.
+func (handler *Handler) resync(now time.Time) error {
+	tracker := handler.gaps
+	if tracker.config.Policy == ResyncRateLimited && now.Sub(tracker.lastResync) < tracker.config.MinResyncInterval {
+		if !tracker.isStale() {
+			zap.L().Warn("Resync is rate limited. Book is stale", zap.String("product", handler.book.ID),
+				zap.Duration("wait", tracker.config.MinResyncInterval-now.Sub(tracker.lastResync)))
+		}
+		tracker.setStale(true)
+		return nil
+	}
+	if err := handler.SyncBook(); err != nil {
+		return errors.Wrap(err, "could not sync book")
+	}
+	tracker.lastResync = now
+	tracker.setStale(false)
+	tracker.settle(true)
+	// messages held for reordering may still be newer than the snapshot
+	for sequence := range tracker.buffer {
+		if sequence <= handler.sequence {
+			delete(tracker.buffer, sequence)
+		}
+	}
+	return handler.drainReordered()
+}
+
+// drainReordered applies held messages that are now in sequence. Once the buffer empties the gap
+// has been bridged without a resync.
+func (handler *Handler) drainReordered() error {
+	tracker := handler.gaps
+	if len(tracker.buffer) == 0 {
+		return nil
+	}
+	for {
+		buffered, found := tracker.buffer[handler.sequence+1]
+		if !found {
+			break
+		}
+		delete(tracker.buffer, buffered.message.Sequence)
+		if err := handler.applyLive(buffered.message, buffered.data, buffered.received); err != nil {
+			return err
+		}
+	}
+	if len(tracker.buffer) == 0 {
+		tracker.settle(false)
+		return nil
+	}
+	// what is left skips ahead again, which is a new gap
+	first := int64(-1)
+	for sequence := range tracker.buffer {
+		if first < 0 || sequence < first {
+			first = sequence
+		}
+	}
+	tracker.settle(false)
+	handler.openGap(first, time.Now())
+	return nil
+}
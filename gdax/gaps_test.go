@@ -0,0 +1,130 @@
+package gdax
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+)
+
+// openRecorder lists the opens the handler applies to the book, in order.
+type openRecorder []string
+
+func (recorder *openRecorder) Event(event orderbook.Event) {
+	if event.Type == orderbook.EventOpen {
+		*recorder = append(*recorder, event.OrderID)
+	}
+}
+
+// gapStep delivers an open with sequence at an offset from the start. A snapshot sequence is what
+// the REST book serves from then on, and stale is whether the book must be stale afterwards.
+type gapStep struct {
+	sequence int64
+	at       time.Duration
+	snapshot int64
+	stale    bool
+}
+
+func TestSequencedGapPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GapConfig
+		steps   []gapStep
+		applied []int64
+		stats   GapStats
+		gaps    []Gap
+	}{
+		{
+			name:    "immediate resyncs on the gap and drops what the snapshot covers",
+			config:  GapConfig{Policy: ResyncImmediate},
+			steps:   []gapStep{{sequence: 11}, {sequence: 13, snapshot: 20}, {sequence: 12}, {sequence: 21}},
+			applied: []int64{11, 21},
+			stats:   GapStats{Gaps: 1, Duplicates: 1, Resyncs: 1},
+			gaps:    []Gap{{Expected: 12, Received: 13, Size: 1, Resynced: true}},
+		},
+		{
+			name:   "reorder bridges a gap the late message fills",
+			config: GapConfig{Policy: ResyncReorder, ReorderWindow: time.Second},
+			steps: []gapStep{{sequence: 11}, {sequence: 13, at: 10 * time.Millisecond}, {sequence: 15, at: 20 * time.Millisecond},
+				{sequence: 12, at: 30 * time.Millisecond}, {sequence: 14, at: 40 * time.Millisecond}},
+			applied: []int64{11, 12, 13, 14, 15},
+			stats:   GapStats{Gaps: 2, Recovered: 2},
+			gaps:    []Gap{{Expected: 12, Received: 13, Size: 1, Recovered: true}, {Expected: 14, Received: 15, Size: 1, Recovered: true}},
+		},
+		{
+			name:   "reorder resyncs once the window has passed",
+			config: GapConfig{Policy: ResyncReorder, ReorderWindow: time.Second},
+			steps: []gapStep{{sequence: 11}, {sequence: 13}, {sequence: 14, at: 500 * time.Millisecond},
+				{sequence: 15, at: time.Second, snapshot: 20}, {sequence: 21, at: 1100 * time.Millisecond}},
+			applied: []int64{11, 21},
+			stats:   GapStats{Gaps: 1, Resyncs: 1},
+			gaps:    []Gap{{Expected: 12, Received: 13, Size: 1, Resynced: true}},
+		},
+		{
+			name:    "reorder resyncs once the buffer is full",
+			config:  GapConfig{Policy: ResyncReorder, ReorderWindow: time.Second, ReorderBuffer: 2},
+			steps:   []gapStep{{sequence: 11}, {sequence: 13}, {sequence: 14}, {sequence: 15, snapshot: 20}, {sequence: 21}},
+			applied: []int64{11, 21},
+			stats:   GapStats{Gaps: 1, Resyncs: 1},
+			gaps:    []Gap{{Expected: 12, Received: 13, Size: 1, Resynced: true}},
+		},
+		{
+			name:   "rate limited leaves the book stale until the interval has passed",
+			config: GapConfig{Policy: ResyncRateLimited, MinResyncInterval: time.Second},
+			steps: []gapStep{{sequence: 11}, {sequence: 13, snapshot: 20}, {sequence: 22, at: 100 * time.Millisecond, stale: true},
+				{sequence: 21, at: 200 * time.Millisecond, stale: true}, {sequence: 24, at: 1100 * time.Millisecond, snapshot: 30},
+				{sequence: 31, at: 1200 * time.Millisecond}},
+			applied: []int64{11, 31},
+			stats:   GapStats{Gaps: 2, Resyncs: 2},
+			gaps:    []Gap{{Expected: 12, Received: 13, Size: 1, Resynced: true}, {Expected: 21, Received: 22, Size: 1, Resynced: true}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshots, client := newSnapshotServer(t, restBook{Sequence: 10})
+			handler := NewHandler(client, orderbook.NewBook("BTC-USD", nil), nil)
+			handler.SetGapPolicy(test.config)
+			recorder := &openRecorder{}
+			handler.AddEventListener(recorder)
+			if err := handler.SyncBook(); err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			for _, step := range test.steps {
+				if step.snapshot != 0 {
+					snapshots.setBook(restBook{Sequence: step.snapshot})
+				}
+				message := open(step.sequence, fmt.Sprint(step.sequence), "100.00", "buy")
+				if err := handler.sequenced(message, nil, start.Add(step.at)); err != nil {
+					t.Fatal(err)
+				}
+				if stale := handler.GapStats().Stale; stale != step.stale {
+					t.Fatalf("after %d the book is stale %v, want %v", step.sequence, stale, step.stale)
+				}
+			}
+			applied := []string{}
+			for _, sequence := range test.applied {
+				applied = append(applied, fmt.Sprint(sequence))
+			}
+			if !reflect.DeepEqual([]string(*recorder), applied) {
+				t.Fatalf("applied %v, want %v", *recorder, applied)
+			}
+			stats := handler.GapStats()
+			if stats.Gaps != test.stats.Gaps || stats.Recovered != test.stats.Recovered || stats.Duplicates != test.stats.Duplicates ||
+				stats.Resyncs != test.stats.Resyncs {
+				t.Fatalf("gap stats %+v, want %+v", stats, test.stats)
+			}
+			if syncs := snapshots.count() - 1; int64(syncs) != test.stats.Resyncs {
+				t.Fatalf("%d snapshots requested after the first, want %d", syncs, test.stats.Resyncs)
+			}
+			for i := range stats.Recent {
+				stats.Recent[i].Time = time.Time{}
+			}
+			if !reflect.DeepEqual(stats.Recent, test.gaps) {
+				t.Fatalf("recent gaps %+v, want %+v", stats.Recent, test.gaps)
+			}
+		})
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"time"
 
 	"github.com/chrischris292/go-gdax-orderbook/common"
-	"github.com/chrischris292/go-gdax-orderbook/config/orderbook"
-	raven "github.com/getsentry/raven-go"
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/report"
 	ws "github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	gdaxClient "github.com/preichenberger/go-gdax"
@@ -15,22 +16,56 @@ import (
 	"go.uber.org/zap"
 )
 
-const retry = time.Minute * 1
-
 type Handler struct {
 	client           *gdaxClient.Client
 	sequence         int64
-	book             *Book
+	book             *orderbook.Book
+	mode             FeedMode
+	connection       ConnectionConfig
+	reporter         report.ErrorReporter
 	bookListeners    []HandlerConsumer
 	rawFeedListeners []RawFeedListener
+	flowListeners    []OrderFlowListener
+	anomalyListeners []AnomalyListener
+	eventListeners   []orderbook.EventListener
+	pending          map[string]Received
+	ownOrders        *OwnOrders
+	ownSequences     ownSequences
+	lastBookMetrics  time.Time
+
+	integrity     IntegrityConfig
+	anomalies     []Anomaly
+	crossedKind   AnomalyKind
+	staleReported map[string]bool
+	gaps          *gapTracker
+	watchdog      *watchdog
+
+	checkpointDir      string
+	checkpointInterval time.Duration
+	journalDir         string
+	historyDir         string
+	restoreAttempted   bool
+	catchUpPending     bool
+	replaySnapshot     snapshotState
+	replayBroken       bool
 }
 
-func NewHandler(gdaxClient *gdaxClient.Client, book *Book) *Handler {
+// NewHandler follows a product on Coinbase into book. Errors go to reporter, which may be nil.
+func NewHandler(gdaxClient *gdaxClient.Client, book *orderbook.Book, reporter report.ErrorReporter) *Handler {
 	return &Handler{
 		client:           gdaxClient,
 		book:             book,
+		reporter:         report.OrNop(reporter),
+		mode:             FullFeed,
+		connection:       newConnectionConfig(ConnectionConfig{}),
 		bookListeners:    []HandlerConsumer{},
 		rawFeedListeners: []RawFeedListener{},
+		flowListeners:    []OrderFlowListener{},
+		anomalyListeners: []AnomalyListener{},
+		eventListeners:   []orderbook.EventListener{},
+		pending:          map[string]Received{},
+		gaps:             newGapTracker(GapConfig{}),
+		watchdog:         newWatchdog(WatchdogConfig{}),
 	}
 }
 
@@ -40,62 +75,110 @@ func (handler *Handler) AddHandlerConsumer(listener HandlerConsumer) {
 func (handler *Handler) AddRawFeedListener(listener RawFeedListener) {
 	handler.rawFeedListeners = append(handler.rawFeedListeners, listener)
 }
+func (handler *Handler) AddOrderFlowListener(listener OrderFlowListener) {
+	handler.flowListeners = append(handler.flowListeners, listener)
+}
+
+// TrackOwnOrders subscribes to the authenticated user channel using the connection's
+// Credentials and keeps store in sync with our orders.
+func (handler *Handler) TrackOwnOrders(store *OwnOrders) {
+	handler.ownOrders = store
+}
 
 func (handler *Handler) Run() {
+	if handler.checkpointDir != "" && handler.checkpointInterval > 0 {
+		go handler.checkpointLoop()
+	}
 	// Connect to socket and send subscribe message
+	var backoff time.Duration
 	for {
+		connected := time.Now()
 		err := handler.startListening()
 		zap.L().Error("Failed to listen to web socket", zap.Error(err))
-		raven.CaptureErrorAndWait(fmt.Errorf("Failed to listen to web socket: %v", err), nil)
+		handler.reporter.Report(errors.Wrap(err, "Failed to listen to web socket"), map[string]string{"product": handler.book.ID})
+		metrics.Reconnects.WithLabelValues(handler.book.ID).Inc()
 		handler.flushClear()
 		handler.sequence = 0
-		handler.book.Clear()
-		time.Sleep(retry)
+		handler.ownSequences = ownSequences{}
+		handler.catchUpPending = false
+		handler.resetIntegrity()
+		handler.gaps.reset()
+		handler.book.Lock()
+		handler.clearBook()
+		handler.flushEvent(orderbook.Event{Type: orderbook.EventReset, Time: time.Now()})
+		handler.book.Unlock()
+		backoff = handler.connection.Backoff.next(backoff, time.Since(connected))
+		zap.L().Info("Reconnecting", zap.String("product", handler.book.ID), zap.Duration("backoff", backoff))
+		time.Sleep(backoff)
 	}
 }
 
 func (handler *Handler) startListening() error {
 	// Connect to socket and send subscribe message
 	var wsDialer ws.Dialer
-	wsConn, _, err := wsDialer.Dial(config.AppConfig.Coinbase.CB_WS, nil)
+	wsConn, _, err := wsDialer.Dial(handler.connection.WebsocketURL, nil)
 	if err != nil {
-		raven.CaptureErrorAndWait(err, nil)
 		zap.L().Error("Could start web socket", zap.Error(err))
-		return err
+		return errors.Wrap(err, "Could not start web socket")
 	}
 
 	subscribe := gdaxClient.Message{
 		Type: "subscribe",
 		Channels: []gdaxClient.MessageChannel{
 			gdaxClient.MessageChannel{
-				Name: "full",
+				Name: string(handler.mode),
 				ProductIds: []string{
 					handler.book.ID,
 				},
 			},
+			gdaxClient.MessageChannel{
+				Name:       "heartbeat",
+				ProductIds: []string{handler.book.ID},
+			},
 		},
 	}
-	if err := wsConn.WriteJSON(subscribe); err != nil {
+	var subscription interface{} = subscribe
+	if handler.ownOrders != nil {
+		subscribe.Channels = append(subscribe.Channels, gdaxClient.MessageChannel{
+			Name:       "user",
+			ProductIds: []string{handler.book.ID},
+		})
+		subscription, err = newSignedSubscription(subscribe, handler.connection.Credentials)
+		if err != nil {
+			zap.L().Error("Could not sign subscription message", zap.Error(err))
+			return errors.Wrap(err, "Could not sign subscription message")
+		}
+	}
+	if err := wsConn.WriteJSON(subscription); err != nil {
 		zap.L().Error("Could not write subscription message", zap.Error(err))
 		return errors.New("Could not write subscription message")
 	}
+	// level2 sends its own snapshot and the ticker needs none
+	if handler.mode != FullFeed {
+		return handler.listenToSocket(wsConn)
+	}
+	if handler.restoreCheckpoint() {
+		return handler.listenToSocket(wsConn)
+	}
 	err = handler.SyncBook()
 	if err != nil {
 		zap.L().Error("Could not sync book", zap.Error(err))
-		raven.CaptureErrorAndWait(err, nil)
-		return errors.New("Could not sync book")
+		return errors.Wrap(err, "Could not sync book")
 	}
 	return handler.listenToSocket(wsConn)
 }
 
 func (handler *Handler) listenToSocket(wsConn *ws.Conn) error {
+	defer wsConn.Close()
+	stopWatchdog := handler.watchdog.watch(wsConn, handler.book.ID)
+	defer stopWatchdog()
 	for true {
-		zap.L().Info("fsdfds")
 		// Parse message
 		_, data, err := wsConn.ReadMessage()
 		if err != nil {
-			return errors.Wrap(err, "Could not read from wsConn...Closing connection")
+			return errors.Wrap(handler.watchdog.explain(err), "Could not read from wsConn...Closing connection")
 		}
+		received := time.Now()
 
 		message := gdaxClient.Message{}
 		err = json.Unmarshal(data, &message)
@@ -109,57 +192,127 @@ func (handler *Handler) listenToSocket(wsConn *ws.Conn) error {
 			continue
 		}
 
-		if message.Sequence <= handler.sequence {
-			zap.L().Info("Received old messagef", zap.Int64("sequence number", message.Sequence), zap.Int64("bookSequence", handler.sequence))
+		if message.Type == "heartbeat" {
+			handler.watchdog.heartbeat(wsConn, handler, message, received)
+			continue
+		}
+
+		// Activations are only sent for our own stop orders and carry no sequence
+		if message.Type == "activate" {
+			if err := handler.handleActivate(message, data); err != nil {
+				return err
+			}
 			continue
 		}
 
-		if message.Sequence != (handler.sequence + 1) {
-			zap.L().Info("Book is out of order. Resyncing", zap.Int64("sequence number", message.Sequence), zap.Int64("bookSequence", handler.sequence))
-			err = handler.SyncBook()
+		if isUserMessage(message) {
+			duplicate, err := handler.trackOwnOrder(message)
 			if err != nil {
-				return errors.Wrap(err, "could not sync book")
+				return err
+			}
+			// level2 and ticker books are built from their own channel only
+			if duplicate || handler.mode != FullFeed {
+				continue
+			}
+		}
+
+		if handler.mode != FullFeed {
+			if err := handler.applyAggregated(message, data, received); err != nil {
+				return err
 			}
 			continue
 		}
-		handler.flushRawFeedMessage(string(data))
 
-		handler.sequence = message.Sequence
-		err = handler.handleIncremental(message)
-		if err != nil {
-			return errors.Wrap(err, "Could not read incremental")
+		if handler.catchUpPending {
+			handler.catchUp(message.Sequence)
+		}
+		if err := handler.sequenced(message, data, received); err != nil {
+			return err
 		}
-		handler.book.PrintTopFive()
 	}
 	return errors.New("for loop should never end...")
 }
 
+// applyLive applies the next message in sequence and resyncs if it leaves the book with an
+// anomaly that calls for it.
+func (handler *Handler) applyLive(message gdaxClient.Message, data []byte, received time.Time) error {
+	handler.flushRawFeedMessage(string(data))
+
+	// consumers are notified while the book is locked, so they must read it directly rather than through Snapshot
+	handler.book.Lock()
+	handler.sequence = message.Sequence
+	handler.book.Sequence = message.Sequence
+	handler.book.Updated = received
+	err := handler.handleIncremental(message)
+	if err == nil {
+		handler.checkIntegrity(message)
+	}
+	handler.book.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "Could not read incremental")
+	}
+	if handler.flushAnomalies() {
+		zap.L().Info("Book anomaly. Resyncing", zap.Int64("bookSequence", handler.sequence))
+		return handler.resync(received)
+	}
+	handler.observeMessage(message, received)
+	return nil
+}
+
 func (handler *Handler) handleIncremental(message gdaxClient.Message) error {
 	switch messageType := message.Type; messageType {
+	case "received":
+		receivedMessage, err := NewReceivedMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.OrderType,
+			message.Size, message.Funds, message.Price, message.Side)
+		if err != nil {
+			return errors.Wrap(err, "Could not create received message")
+		}
+		handler.addPending(receivedMessage)
+		handler.flushBookUpdate(message)
+		handler.flushReceived(receivedMessage)
+		return nil
 	case "open":
 		order, err := NewOrder(message.OrderId, message.RemainingSize, message.Price, message.Side)
 		if err != nil {
 			return errors.Wrap(err, "Could not create open message")
 		}
+		if handler.ownOrders != nil && handler.ownOrders.Has(order.ID) {
+			order.Own = true
+		}
+		order.Time = message.Time.Time()
 		zap.L().Debug("Open message", zap.String("Order", order.ToString()))
-		handler.book.Add(order)
+		handler.removePending(order.ID)
+		err = handler.book.Add(order)
+		if err == nil {
+			handler.flushOrderEvent(orderbook.EventOpen, order, decimal.Decimal{}, order.Time)
+		}
+		if err = handler.bookAnomaly(err, message, order.ID, order.Size); err != nil {
+			return errors.Wrap(err, "Could not process open message")
+		}
 		handler.flushBookUpdate(message)
 		return nil
 	case "done":
-		// ignore done messages with no price
-		if message.Price == "" {
-			return nil
-		}
-		order, err := NewOrder(message.OrderId, message.RemainingSize, message.Price, message.Side)
+		doneMessage, err := NewDoneMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.Reason,
+			message.RemainingSize, message.Price, message.Side)
 		if err != nil {
 			return errors.Wrap(err, "Could not create done message")
 		}
-		zap.L().Debug("Done message", zap.String("Order", order.ToString()))
-		err = handler.book.Remove(order)
-		if err != nil {
-			return errors.Wrap(err, "Could not process done message")
+		zap.L().Debug("Done message", zap.String("Done", doneMessage.ToString()))
+		handler.removePending(doneMessage.OrderID)
+		delete(handler.staleReported, doneMessage.OrderID)
+		// market orders never rest on the book
+		if !doneMessage.Market {
+			order := &orderbook.Order{ID: doneMessage.OrderID, Size: doneMessage.RemainingSize, Price: doneMessage.Price, Side: doneMessage.Side}
+			removed, err := handler.book.Remove(order)
+			if err != nil {
+				return errors.Wrap(err, "Could not process done message")
+			}
+			if removed {
+				handler.flushOrderEvent(orderbook.EventDone, order, decimal.Decimal{}, doneMessage.Time.Time())
+			}
 		}
 		handler.flushBookUpdate(message)
+		handler.flushDone(doneMessage)
 		return nil
 	case "match":
 		matchMessage, err := NewMatchMessage(message.TradeId, message.Sequence, message.MakerOrderId, message.TakerOrderId, message.Time, message.ProductId, message.Size, message.Price, message.Side)
@@ -167,21 +320,36 @@ func (handler *Handler) handleIncremental(message gdaxClient.Message) error {
 			return errors.Wrap(err, "Could not create match message")
 		}
 		zap.L().Debug("Match message", zap.String("Match", fmt.Sprintf("%v", matchMessage.ToString())), zap.String("price", matchMessage.Price.String()))
-		err = handler.book.Match(matchMessage)
-		if err != nil {
+		trade := matchMessage.Trade()
+		err = handler.book.Match(trade)
+		if err == nil {
+			handler.flushEvent(orderbook.Event{Type: orderbook.EventMatch, Time: trade.Time, OrderID: trade.MakerOrderID, Side: trade.MakerSide,
+				Price: trade.Price, Size: trade.Size, Trade: &trade})
+		}
+		if err = handler.bookAnomaly(err, message, matchMessage.MakerOrderID, matchMessage.Size); err != nil {
 			return errors.Wrap(err, "Could not process match message")
 		}
 		handler.flushBookUpdate(message)
 		handler.flushTradeTick(matchMessage)
 		return nil
 	case "change":
-		changeMessage, err := NewChangeMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.NewSize, message.OldSize, message.Price, message.Side)
+		changeMessage, err := NewChangeMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.NewSize, message.OldSize,
+			message.NewFunds, message.OldFunds, message.Price, message.Side)
 		if err != nil {
 			return errors.Wrap(err, "Could not create change message")
 		}
 		zap.L().Debug("Change message", zap.String("Change", fmt.Sprintf("%v", changeMessage)))
-		err = handler.book.Change(changeMessage)
-		if err != nil {
+		// market orders never rest, and limit orders can change before they open
+		if handler.changePending(changeMessage) || changeMessage.IsMarket() {
+			handler.flushBookUpdate(message)
+			return nil
+		}
+		err = handler.book.Change(changeMessage.OrderID, changeMessage.OldSize, changeMessage.NewSize)
+		if err == nil {
+			handler.flushEvent(orderbook.Event{Type: orderbook.EventChange, Time: changeMessage.Time.Time(), OrderID: changeMessage.OrderID,
+				Side: changeMessage.Side, Price: changeMessage.Price, Size: changeMessage.NewSize, OldSize: changeMessage.OldSize})
+		}
+		if err = handler.bookAnomaly(err, message, changeMessage.OrderID, changeMessage.NewSize); err != nil {
 			return errors.Wrap(err, "Could not process change message")
 		}
 		handler.flushBookUpdate(message)
@@ -190,12 +358,42 @@ func (handler *Handler) handleIncremental(message gdaxClient.Message) error {
 	return nil
 }
 
+// changePending applies a change message to an order that was received but has not rested. A
+// limit order can change before its open arrives, and self-trade prevention reduces the size or
+// funds of market orders, which never rest. The open carries the changed remaining size.
+func (handler *Handler) changePending(message Change) bool {
+	pending, found := handler.pending[message.OrderID]
+	if !found {
+		return false
+	}
+	if !message.NewSize.IsZero() || !message.OldSize.IsZero() {
+		pending.Size = message.NewSize
+	}
+	if !message.NewFunds.IsZero() || !message.OldFunds.IsZero() {
+		pending.Funds = message.NewFunds
+	}
+	handler.pending[message.OrderID] = pending
+	return true
+}
+
 func (handler *Handler) SyncBook() error {
+	handler.watchdog.hold(true)
+	defer handler.watchdog.hold(false)
 	snapshotBook, err := handler.client.GetBook(handler.book.ID, 3)
 	if err != nil {
 		return errors.Wrap(err, "Could not get a snapshot of the book")
 	}
+	metrics.Resyncs.WithLabelValues(handler.book.ID).Inc()
+
+	handler.book.Lock()
+	defer handler.book.Unlock()
+	// the snapshot replaces whatever the book held before a resync
+	handler.clearBook()
+	handler.resetIntegrity()
 	handler.sequence = int64(snapshotBook.Sequence)
+	handler.book.Sequence = handler.sequence
+	handler.book.Updated = time.Now()
+	handler.flushRawFeedMessage(SnapshotStartJSON(handler.book.ID, handler.sequence, time.Now()))
 
 	for _, bid := range snapshotBook.Bids {
 		price, err := decimal.NewFromString(bid.Price)
@@ -206,15 +404,18 @@ func (handler *Handler) SyncBook() error {
 		if err != nil {
 			return errors.Wrap(err, "Could not convert bid size to decimal")
 		}
-		order := &Order{
+		order := &orderbook.Order{
 			ID:    bid.OrderId,
 			Size:  size,
 			Price: price,
 			Side:  common.BidSide,
+			Time:  handler.book.Updated,
 		}
 		zap.L().Debug(fmt.Sprintf("Snapshot bid: %v", bid))
-		handler.flushRawFeedMessage(order.ToJSON())
-		handler.book.Add(order)
+		handler.flushRawFeedMessage(OrderJSON(order))
+		if err := handler.book.Add(order); err != nil {
+			return errors.Wrap(err, "Could not add snapshot bid")
+		}
 	}
 	for _, ask := range snapshotBook.Asks {
 		price, err := decimal.NewFromString(ask.Price)
@@ -225,16 +426,23 @@ func (handler *Handler) SyncBook() error {
 		if err != nil {
 			return errors.Wrap(err, "Could not convert ask size to decimal")
 		}
-		order := &Order{
+		order := &orderbook.Order{
 			ID:    ask.OrderId,
 			Size:  size,
 			Price: price,
 			Side:  common.AskSide,
+			Time:  handler.book.Updated,
 		}
 		zap.L().Debug(fmt.Sprintf("Snapshot ask: %v", ask))
-		handler.flushRawFeedMessage(order.ToJSON())
-		handler.book.Add(order)
+		handler.flushRawFeedMessage(OrderJSON(order))
+		if err := handler.book.Add(order); err != nil {
+			return errors.Wrap(err, "Could not add snapshot ask")
+		}
+	}
+	if handler.ownOrders != nil {
+		handler.reconcileOwnOrders()
 	}
+	handler.flushBookEvents()
 	return nil
 }
 
@@ -256,8 +464,46 @@ func (handler *Handler) flushTradeTick(msg Match) {
 	}
 }
 
+func (handler *Handler) flushReceived(msg Received) {
+	for _, listener := range handler.flowListeners {
+		listener.Received(msg)
+	}
+}
+
+func (handler *Handler) flushActivate(msg Activate) {
+	for _, listener := range handler.flowListeners {
+		listener.Activate(msg)
+	}
+}
+
+func (handler *Handler) flushDone(msg Done) {
+	for _, listener := range handler.flowListeners {
+		listener.Done(msg)
+	}
+}
+
 func (handler *Handler) flushClear() {
 	for _, listener := range handler.bookListeners {
 		listener.Clear()
 	}
 }
+
+type activateFields struct {
+	StopType  string `json:"stop_type"`
+	StopPrice string `json:"stop_price"`
+}
+
+func (handler *Handler) handleActivate(message gdaxClient.Message, data []byte) error {
+	fields := activateFields{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.Wrap(err, "Could not unmarshal activate message")
+	}
+	activateMessage, err := NewActivateMessage(message.Time, message.OrderId, message.ProductId, fields.StopType, fields.StopPrice,
+		message.Size, message.Funds, message.Side)
+	if err != nil {
+		return errors.Wrap(err, "Could not create activate message")
+	}
+	zap.L().Info("Stop order activated", zap.String("id", activateMessage.OrderID), zap.String("stopPrice", activateMessage.StopPrice.String()))
+	handler.flushActivate(activateMessage)
+	return nil
+}
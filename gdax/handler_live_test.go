@@ -0,0 +1,40 @@
+package gdax
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+)
+
+// BenchmarkHandleIncremental is the live path of a decoded feed message: the sequence check,
+// the book lock, handleIncremental and the integrity checks.
+func BenchmarkHandleIncremental(b *testing.B) {
+	messages := synthetic.Stream(synthetic.BenchmarkEvents)
+	data := make([][]byte, len(messages))
+	for i, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			b.Fatal(err)
+		}
+		data[i] = line
+	}
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				handler := NewHandler(nil, mode.NewBook(), nil)
+				handler.sequence = messages[0].Sequence - 1
+				for j, message := range messages {
+					if err := handler.sequenced(message, data[j], message.Time.Time()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*len(messages)), "ns/msg")
+		})
+	}
+}
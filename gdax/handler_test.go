@@ -0,0 +1,60 @@
+package gdax_test
+
+import (
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+type eventRecorder []orderbook.Event
+
+func (recorder *eventRecorder) Event(event orderbook.Event) {
+	*recorder = append(*recorder, event)
+}
+
+func TestGeneratedStreamApplies(t *testing.T) {
+	for _, mode := range synthetic.BookModes() {
+		generator := synthetic.NewGenerator(synthetic.DefaultConfig())
+		book := mode.NewBook()
+		handler := gdax.NewHandler(nil, book, nil)
+		for _, message := range append(generator.Generate(20000), generator.Flush()...) {
+			if err := handler.ApplyMessage(message); err != nil {
+				t.Fatalf("%s book: %v", mode.Name, err)
+			}
+		}
+		if book.NumOrders() != generator.NumOrders() {
+			t.Fatalf("%s book has %d orders, generator %d", mode.Name, book.NumOrders(), generator.NumOrders())
+		}
+	}
+}
+
+func TestDoneEmitsEventOnlyForRestingOrders(t *testing.T) {
+	handler := gdax.NewHandler(nil, orderbook.NewBook("BTC-USD", nil), nil)
+	recorder := &eventRecorder{}
+	handler.AddEventListener(recorder)
+	messages := []gdaxClient.Message{
+		{Type: "received", OrderId: "filled", OrderType: "limit", Size: "1", Price: "100.00", Side: "buy"},
+		{Type: "done", OrderId: "filled", Reason: "filled", RemainingSize: "0", Price: "100.00", Side: "buy"},
+		{Type: "open", OrderId: "resting", RemainingSize: "1", Price: "100.00", Side: "buy"},
+		{Type: "done", OrderId: "resting", Reason: "canceled", RemainingSize: "1", Price: "100.00", Side: "buy"},
+		{Type: "done", OrderId: "unknown", Reason: "canceled", RemainingSize: "1", Price: "100.00", Side: "buy"},
+	}
+	for i, message := range messages {
+		message.Sequence, message.ProductId = int64(i+1), "BTC-USD"
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := []string{}
+	for _, event := range *recorder {
+		if event.Type == orderbook.EventDone {
+			done = append(done, event.OrderID)
+		}
+	}
+	if len(done) != 1 || done[0] != "resting" {
+		t.Fatalf("done events for %v, want only the resting order", done)
+	}
+}
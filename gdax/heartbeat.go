@@ -0,0 +1,201 @@
+package gdax
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	ws "github.com/gorilla/websocket"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReadTimeout      = 30 * time.Second
+	defaultPingInterval     = 15 * time.Second
+	defaultHeartbeatTimeout = 10 * time.Second
+	defaultSequenceStall    = 30 * time.Second
+)
+
+// WatchdogConfig decides when a connection that is still open counts as dead. Zero fields take
+// the defaults.
+type WatchdogConfig struct {
+	// ReadTimeout is how long a read may block. Coinbase sends a heartbeat every second, so only a
+	// half-open connection hits it.
+	ReadTimeout time.Duration
+	// PingInterval is how often a websocket ping is sent. Each pong extends the read deadline.
+	PingInterval time.Duration
+	// HeartbeatTimeout drops the connection when no heartbeat arrived for this long, even if other
+	// messages still do.
+	HeartbeatTimeout time.Duration
+	// SequenceStall drops the connection when heartbeats report newer sequence numbers than the
+	// book has applied for this long.
+	SequenceStall time.Duration
+}
+
+// Heartbeat is the state of the heartbeat channel for health checks.
+type Heartbeat struct {
+	Last     time.Time
+	Sequence int64
+	Stale    bool
+}
+
+type watchdog struct {
+	config WatchdogConfig
+	// now is the clock the watchdog measures silence with, replaced in tests
+	now func() time.Time
+
+	mu            sync.Mutex
+	lastHeartbeat time.Time
+	sequence      int64
+	reason        string
+	holding       bool
+
+	// only touched by the handler goroutine
+	conn         *ws.Conn
+	progress     int64
+	progressTime time.Time
+}
+
+func newWatchdog(config WatchdogConfig) *watchdog {
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = defaultReadTimeout
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = defaultPingInterval
+	}
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = defaultHeartbeatTimeout
+	}
+	if config.SequenceStall <= 0 {
+		config.SequenceStall = defaultSequenceStall
+	}
+	return &watchdog{config: config, now: time.Now}
+}
+
+// SetWatchdog replaces the watchdog config. Call it before Run.
+func (handler *Handler) SetWatchdog(config WatchdogConfig) {
+	handler.watchdog = newWatchdog(config)
+}
+
+// Heartbeat reports when the last heartbeat arrived. It is safe to call from any goroutine.
+func (handler *Handler) Heartbeat() Heartbeat {
+	dog := handler.watchdog
+	dog.mu.Lock()
+	defer dog.mu.Unlock()
+	return Heartbeat{
+		Last:     dog.lastHeartbeat,
+		Sequence: dog.sequence,
+		Stale:    dog.lastHeartbeat.IsZero() || dog.now().Sub(dog.lastHeartbeat) > dog.config.HeartbeatTimeout,
+	}
+}
+
+// watch sets up read deadlines and pings on a new connection and closes it once heartbeats stop.
+// Closing makes the blocked read fail, so listenToSocket returns and Run reconnects. The returned
+// function stops the watchdog.
+func (dog *watchdog) watch(wsConn *ws.Conn, productID string) func() {
+	now := dog.now()
+	dog.mu.Lock()
+	dog.lastHeartbeat, dog.reason = now, ""
+	dog.mu.Unlock()
+	dog.conn, dog.progress, dog.progressTime = wsConn, 0, now
+
+	wsConn.SetReadDeadline(now.Add(dog.config.ReadTimeout))
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(dog.now().Add(dog.config.ReadTimeout))
+	})
+
+	stop := make(chan struct{})
+	go func() {
+		pings := time.NewTicker(dog.config.PingInterval)
+		checks := time.NewTicker(dog.config.HeartbeatTimeout / 2)
+		defer pings.Stop()
+		defer checks.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-pings.C:
+				if err := wsConn.WriteControl(ws.PingMessage, nil, dog.now().Add(dog.config.PingInterval)); err != nil {
+					zap.L().Warn("Could not ping web socket", zap.String("product", productID), zap.Error(err))
+				}
+			case <-checks.C:
+				if dog.check(wsConn, productID) {
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		dog.conn = nil
+	}
+}
+
+// check closes the connection when no heartbeat arrived for HeartbeatTimeout, unless the watchdog
+// is holding, and reports whether it did.
+func (dog *watchdog) check(wsConn *ws.Conn, productID string) bool {
+	dog.mu.Lock()
+	since, holding := dog.now().Sub(dog.lastHeartbeat), dog.holding
+	dog.mu.Unlock()
+	if holding || since <= dog.config.HeartbeatTimeout {
+		return false
+	}
+	dog.kill(wsConn, productID, fmt.Sprintf("no heartbeat for %v", since.Round(time.Millisecond)))
+	return true
+}
+
+// hold pauses the watchdog while the handler goroutine is busy with a REST snapshot and reads
+// nothing, and restarts the clocks afterwards.
+func (dog *watchdog) hold(holding bool) {
+	now := dog.now()
+	dog.mu.Lock()
+	dog.holding = holding
+	if !holding {
+		dog.lastHeartbeat = now
+	}
+	dog.mu.Unlock()
+	if !holding && dog.conn != nil {
+		dog.progressTime = now
+		dog.conn.SetReadDeadline(now.Add(dog.config.ReadTimeout))
+	}
+}
+
+// heartbeat records a heartbeat message and, as the handler goroutine owns the sequence, checks
+// for a stalled feed.
+func (dog *watchdog) heartbeat(wsConn *ws.Conn, handler *Handler, message gdaxClient.Message, received time.Time) {
+	dog.mu.Lock()
+	dog.lastHeartbeat, dog.sequence = received, message.Sequence
+	dog.mu.Unlock()
+	wsConn.SetReadDeadline(received.Add(dog.config.ReadTimeout))
+
+	// only the full channel shares the heartbeat's sequence numbers
+	if handler.mode != FullFeed || handler.sequence != dog.progress || message.Sequence <= handler.sequence || handler.gaps.isStale() {
+		dog.progress, dog.progressTime = handler.sequence, received
+		return
+	}
+	if stalled := received.Sub(dog.progressTime); stalled > dog.config.SequenceStall {
+		dog.kill(wsConn, handler.book.ID, fmt.Sprintf("book stuck at sequence %d for %v while the feed is at %d",
+			handler.sequence, stalled.Round(time.Millisecond), message.Sequence))
+	}
+}
+
+func (dog *watchdog) kill(wsConn *ws.Conn, productID string, reason string) {
+	dog.mu.Lock()
+	dog.reason = reason
+	dog.mu.Unlock()
+	zap.L().Warn("Web socket looks dead. Reconnecting", zap.String("product", productID), zap.String("reason", reason))
+	metrics.WatchdogTrips.WithLabelValues(productID).Inc()
+	wsConn.Close()
+}
+
+// explain adds the watchdog's reason to a read error caused by it closing the connection.
+func (dog *watchdog) explain(err error) error {
+	dog.mu.Lock()
+	defer dog.mu.Unlock()
+	if dog.reason == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %v", dog.reason, err)
+}
@@ -0,0 +1,152 @@
+package gdax
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	ws "github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// fakeClock only moves when the test advances it. It starts at the real time, so read deadlines
+// set from it are never in the past.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) advance(d time.Duration) {
+	clock.mu.Lock()
+	clock.now = clock.now.Add(d)
+	clock.mu.Unlock()
+}
+
+func newWatchedHandler(t *testing.T, client *gdaxClient.Client) (*Handler, *fakeClock, *ws.Conn) {
+	handler := NewHandler(client, orderbook.NewBook("BTC-USD", nil), nil)
+	clock := newFakeClock()
+	handler.watchdog.now = clock.Now
+	conn := dialFeed(t, readUntilClosed)
+	t.Cleanup(handler.watchdog.watch(conn, "BTC-USD"))
+	return handler, clock, conn
+}
+
+// expectKilled checks that the watchdog closed the connection and says why.
+func expectKilled(t *testing.T, handler *Handler, conn *ws.Conn, reason string) {
+	_, _, err := conn.ReadMessage()
+	if err == nil {
+		t.Fatalf("connection is still open, want it closed for %q", reason)
+	}
+	if explained := handler.watchdog.explain(err); !strings.Contains(explained.Error(), reason) {
+		t.Fatalf("read failed with %q, want %q", explained, reason)
+	}
+}
+
+func TestWatchdogKillsWithoutHeartbeats(t *testing.T) {
+	handler, clock, conn := newWatchedHandler(t, nil)
+	dog := handler.watchdog
+	clock.advance(9 * time.Second)
+	dog.heartbeat(conn, handler, gdaxClient.Message{Type: "heartbeat"}, clock.Now())
+	clock.advance(9 * time.Second)
+	if dog.check(conn, "BTC-USD") {
+		t.Fatalf("killed 9s after a heartbeat")
+	}
+	if handler.Heartbeat().Stale {
+		t.Fatalf("heartbeat is stale 9s after the last one")
+	}
+	clock.advance(2 * time.Second)
+	if !handler.Heartbeat().Stale {
+		t.Fatalf("heartbeat is not stale 11s after the last one")
+	}
+	if !dog.check(conn, "BTC-USD") {
+		t.Fatalf("not killed 11s after a heartbeat")
+	}
+	expectKilled(t, handler, conn, "no heartbeat for 11s")
+}
+
+func TestWatchdogHoldsDuringSyncBook(t *testing.T) {
+	snapshots, client := newSnapshotServer(t, restBook{Sequence: 10})
+	handler, clock, conn := newWatchedHandler(t, client)
+	dog := handler.watchdog
+	killedDuringSync := true
+	snapshots.onSync = func() {
+		clock.advance(time.Minute)
+		killedDuringSync = dog.check(conn, "BTC-USD")
+	}
+	if err := handler.SyncBook(); err != nil {
+		t.Fatal(err)
+	}
+	if killedDuringSync {
+		t.Fatalf("killed while the snapshot was being fetched")
+	}
+	if dog.check(conn, "BTC-USD") {
+		t.Fatalf("killed right after the snapshot, want the clocks restarted")
+	}
+	clock.advance(11 * time.Second)
+	if !dog.check(conn, "BTC-USD") {
+		t.Fatalf("not killed 11s after the snapshot")
+	}
+	expectKilled(t, handler, conn, "no heartbeat")
+}
+
+func TestWatchdogKillsOnSequenceStall(t *testing.T) {
+	handler, clock, conn := newWatchedHandler(t, nil)
+	dog := handler.watchdog
+	handler.sequence = 5
+	heartbeat := func(after time.Duration, sequence int64) {
+		clock.advance(after)
+		dog.heartbeat(conn, handler, gdaxClient.Message{Type: "heartbeat", Sequence: sequence}, clock.Now())
+	}
+	heartbeat(0, 10)
+	heartbeat(20*time.Second, 10)
+	// the book moving on restarts the stall clock
+	handler.sequence = 6
+	heartbeat(5*time.Second, 10)
+	heartbeat(25*time.Second, 11)
+	if err := errors.New("read"); dog.explain(err) != err {
+		t.Fatalf("killed 25s after the book moved: %v", dog.explain(err))
+	}
+	heartbeat(6*time.Second, 12)
+	expectKilled(t, handler, conn, "book stuck at sequence 6 for 31s while the feed is at 12")
+}
+
+func TestPingsExtendTheReadDeadline(t *testing.T) {
+	handler := NewHandler(nil, orderbook.NewBook("BTC-USD", nil), nil)
+	handler.SetWatchdog(WatchdogConfig{ReadTimeout: 100 * time.Millisecond, PingInterval: 20 * time.Millisecond})
+	release := make(chan struct{})
+	answerFor := 300 * time.Millisecond
+	conn := dialFeed(t, func(conn *ws.Conn) {
+		// pings are only answered while reading
+		conn.SetReadDeadline(time.Now().Add(answerFor))
+		readUntilClosed(conn)
+		<-release
+	})
+	t.Cleanup(func() { close(release) })
+	start := time.Now()
+	err := handler.listenToSocket(conn)
+	if elapsed := time.Since(start); elapsed < answerFor {
+		t.Fatalf("read deadline hit after %v while pings were answered", elapsed)
+	}
+	if !isTimeout(errors.Cause(err)) {
+		t.Fatalf("listen ended with %v, want a read timeout once pongs stopped", err)
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
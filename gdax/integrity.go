@@ -0,0 +1,178 @@
+package gdax
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type AnomalyKind string
+
+const (
+	// AnomalyCrossed is a best bid above the best ask.
+	AnomalyCrossed AnomalyKind = "crossed"
+	// AnomalyLocked is a best bid equal to the best ask.
+	AnomalyLocked AnomalyKind = "locked"
+	// AnomalyStaleOrder is an order at the top of the book older than IntegrityConfig.StaleOrderAge,
+	// usually one whose done message was lost.
+	AnomalyStaleOrder AnomalyKind = "stale_order"
+	// AnomalyNegativeSize is a match that would leave the resting order with a negative size.
+	AnomalyNegativeSize AnomalyKind = "negative_size"
+	// AnomalyImpossibleChange is a change for an order the book does not hold at the old size.
+	AnomalyImpossibleChange AnomalyKind = "impossible_change"
+	// AnomalyUnknownMaker is a match whose maker is not first at its price level, usually because
+	// the open or done of an order ahead of it was lost.
+	AnomalyUnknownMaker AnomalyKind = "unknown_maker"
+	// AnomalyOffGrid is an open, change or match whose price or size is off the tick and lot grid
+	// of a fixed-point book, which means the product's scale is wrong.
+	AnomalyOffGrid AnomalyKind = "off_grid"
+)
+
+// AnomalyKinds are every kind of anomaly, the values integrity.resync accepts.
+var AnomalyKinds = []AnomalyKind{AnomalyCrossed, AnomalyLocked, AnomalyStaleOrder, AnomalyNegativeSize,
+	AnomalyImpossibleChange, AnomalyUnknownMaker, AnomalyOffGrid}
+
+// Anomaly is a sign that the book no longer matches the exchange's.
+type Anomaly struct {
+	Kind      AnomalyKind
+	ProductID string
+	Sequence  int64
+	Time      time.Time
+	OrderID   string
+	Side      common.Side
+	Price     decimal.Decimal
+	Size      decimal.Decimal
+	Detail    string
+}
+
+type AnomalyListener interface {
+	Anomaly(anomaly Anomaly)
+}
+
+// IntegrityConfig sets which anomalies the handler looks for beyond the ones the book reports
+// itself, and which of them make it rebuild the book from a REST snapshot.
+type IntegrityConfig struct {
+	// StaleOrderAge flags the first order of either top level once it is this old. Zero disables it.
+	StaleOrderAge time.Duration
+	Resync        []AnomalyKind
+}
+
+func (handler *Handler) AddAnomalyListener(listener AnomalyListener) {
+	handler.anomalyListeners = append(handler.anomalyListeners, listener)
+}
+
+// MonitorIntegrity replaces the integrity config. Without it anomalies are still logged and
+// counted, but never trigger a resync.
+func (handler *Handler) MonitorIntegrity(config IntegrityConfig) {
+	handler.integrity = config
+}
+
+// bookAnomalies maps the errors the book returns for inconsistent messages to anomaly kinds.
+var bookAnomalies = map[error]AnomalyKind{
+	orderbook.ErrImpossibleChange: AnomalyImpossibleChange,
+	orderbook.ErrOverfill:         AnomalyNegativeSize,
+	orderbook.ErrUnknownMaker:     AnomalyUnknownMaker,
+	orderbook.ErrOffGrid:          AnomalyOffGrid,
+}
+
+// bookAnomaly turns the errors the book returns for inconsistent messages into anomalies. Any
+// other error is returned unchanged.
+func (handler *Handler) bookAnomaly(err error, message gdaxClient.Message, orderID string, size decimal.Decimal) error {
+	if err == nil {
+		return nil
+	}
+	kind, found := bookAnomalies[errors.Cause(err)]
+	if !found {
+		return err
+	}
+	side, _ := ToSide(message.Side)
+	price, _ := decimal.NewFromString(message.Price)
+	handler.addAnomaly(message, Anomaly{Kind: kind, OrderID: orderID, Side: side, Price: price, Size: size, Detail: err.Error()})
+	return nil
+}
+
+// checkIntegrity looks at the top of the book after a message. Each crossed or locked episode
+// and each stale order is reported once.
+func (handler *Handler) checkIntegrity(message gdaxClient.Message) {
+	bid, bidErr := handler.book.Bid.GetTopLevel()
+	ask, askErr := handler.book.Ask.GetTopLevel()
+	kind := AnomalyKind("")
+	if bidErr == nil && askErr == nil {
+		if bid.Price.GreaterThan(ask.Price) {
+			kind = AnomalyCrossed
+		} else if bid.Price.Equal(ask.Price) {
+			kind = AnomalyLocked
+		}
+	}
+	if kind != "" && kind != handler.crossedKind {
+		handler.addAnomaly(message, Anomaly{Kind: kind, Price: bid.Price, Size: bid.GetSize(),
+			Detail: "best bid " + bid.Price.String() + " best ask " + ask.Price.String()})
+	}
+	handler.crossedKind = kind
+
+	if handler.integrity.StaleOrderAge <= 0 {
+		return
+	}
+	now := message.Time.Time()
+	for _, level := range []*orderbook.BookLevel{bid, ask} {
+		if level == nil {
+			continue
+		}
+		order, err := level.GetFirstOrder()
+		if err != nil || order.Time.IsZero() || now.Sub(order.Time) < handler.integrity.StaleOrderAge || handler.staleReported[order.ID] {
+			continue
+		}
+		if handler.staleReported == nil {
+			handler.staleReported = map[string]bool{}
+		}
+		handler.staleReported[order.ID] = true
+		handler.addAnomaly(message, Anomaly{Kind: AnomalyStaleOrder, OrderID: order.ID, Side: order.Side, Price: order.Price, Size: order.Size,
+			Detail: "resting since " + order.Time.Format(time.RFC3339Nano)})
+	}
+}
+
+func (handler *Handler) addAnomaly(message gdaxClient.Message, anomaly Anomaly) {
+	anomaly.ProductID = handler.book.ID
+	anomaly.Sequence = message.Sequence
+	anomaly.Time = message.Time.Time()
+	handler.anomalies = append(handler.anomalies, anomaly)
+}
+
+// flushAnomalies reports the anomalies of the last message outside the book lock and tells the
+// caller whether one of them calls for a resync.
+func (handler *Handler) flushAnomalies() bool {
+	resync := false
+	for _, anomaly := range handler.anomalies {
+		zap.L().Warn("Book anomaly",
+			zap.String("kind", string(anomaly.Kind)),
+			zap.String("product", anomaly.ProductID),
+			zap.Int64("sequence", anomaly.Sequence),
+			zap.String("order", anomaly.OrderID),
+			zap.String("price", anomaly.Price.String()),
+			zap.String("size", anomaly.Size.String()),
+			zap.String("detail", anomaly.Detail))
+		metrics.Anomalies.WithLabelValues(anomaly.ProductID, string(anomaly.Kind)).Inc()
+		for _, listener := range handler.anomalyListeners {
+			listener.Anomaly(anomaly)
+		}
+		for _, kind := range handler.integrity.Resync {
+			if kind == anomaly.Kind {
+				resync = true
+			}
+		}
+	}
+	handler.anomalies = handler.anomalies[:0]
+	return resync
+}
+
+// resetIntegrity forgets what was reported about a book that has just been rebuilt.
+func (handler *Handler) resetIntegrity() {
+	handler.crossedKind = ""
+	handler.staleReported = nil
+}
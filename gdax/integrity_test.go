@@ -0,0 +1,88 @@
+package gdax_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+type anomalyCounter map[gdax.AnomalyKind]int
+
+func (counter anomalyCounter) Anomaly(anomaly gdax.Anomaly) {
+	counter[anomaly.Kind] += 1
+}
+
+// applyWithAnomalies applies messages, renumbered so that dropped ones leave no sequence gap,
+// and counts the anomalies the handler reports.
+func applyWithAnomalies(t *testing.T, messages []gdaxClient.Message) anomalyCounter {
+	counter := anomalyCounter{}
+	handler := gdax.NewHandler(nil, orderbook.NewBook("BTC-USD", nil), nil)
+	handler.MonitorIntegrity(gdax.IntegrityConfig{StaleOrderAge: 10 * time.Minute})
+	handler.AddAnomalyListener(counter)
+	for i, message := range messages {
+		message.Sequence = int64(i + 1)
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return counter
+}
+
+func TestGeneratedFlowHasNoAnomalies(t *testing.T) {
+	if counter := applyWithAnomalies(t, synthetic.Stream(20000)); len(counter) != 0 {
+		t.Fatalf("anomalies reported: %v", counter)
+	}
+}
+
+func TestLostOpenIsAnUnknownMaker(t *testing.T) {
+	// losing the open of the first trade's maker leaves that match without its maker
+	stream := synthetic.Stream(2000)
+	maker := ""
+	for _, message := range stream {
+		if message.Type == "match" {
+			maker = message.MakerOrderId
+			break
+		}
+	}
+	messages := []gdaxClient.Message{}
+	for _, message := range stream {
+		if message.Type == "open" && message.OrderId == maker {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	if maker == "" || len(messages) == len(stream) {
+		t.Fatalf("generated stream has no match whose maker opened in it")
+	}
+	if counter := applyWithAnomalies(t, messages); counter[gdax.AnomalyUnknownMaker] == 0 {
+		t.Fatalf("no unknown maker reported after dropping the open of %s: %v", maker, counter)
+	}
+}
+
+func TestOffGridChangeAndMatchAreOffGridAnomalies(t *testing.T) {
+	counter := anomalyCounter{}
+	handler := gdax.NewHandler(nil, orderbook.NewFixedBook("BTC-USD", synthetic.DefaultScale, nil), nil)
+	handler.AddAnomalyListener(counter)
+	messages := []gdaxClient.Message{
+		{Type: "received", OrderId: "a", OrderType: "limit", Side: "sell", Price: "100.00", Size: "1"},
+		{Type: "open", OrderId: "a", Side: "sell", Price: "100.00", RemainingSize: "1"},
+		{Type: "change", OrderId: "a", Side: "sell", Price: "100.00", OldSize: "1", NewSize: "0.000000001"},
+		{Type: "match", MakerOrderId: "a", TakerOrderId: "b", TradeId: 1, Side: "sell", Price: "100.00", Size: "0.000000001"},
+	}
+	for i, message := range messages {
+		message.Sequence, message.ProductId = int64(i+1), "BTC-USD"
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(counter) != 1 || counter[gdax.AnomalyOffGrid] != 2 {
+		t.Fatalf("anomalies reported: %v, want 2 off_grid", counter)
+	}
+	if err := handler.Book().CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
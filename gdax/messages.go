@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/chrischris292/go-gdax-orderbook/common"
-	raven "github.com/getsentry/raven-go"
 	"github.com/pkg/errors"
 	gdaxClient "github.com/preichenberger/go-gdax"
 	"github.com/shopspring/decimal"
@@ -29,10 +28,63 @@ type Change struct {
 	ProductID string
 	NewSize   decimal.Decimal
 	OldSize   decimal.Decimal
+	NewFunds  decimal.Decimal
+	OldFunds  decimal.Decimal
 	Price     decimal.Decimal
 	Side      common.Side
 }
 
+const (
+	LimitOrderType  = "limit"
+	MarketOrderType = "market"
+)
+
+// Received is an order accepted by the matching engine that has not yet rested or been done.
+// Market orders carry Size, Funds or both and have no Price.
+type Received struct {
+	Time      gdaxClient.Time
+	Sequence  int64
+	OrderID   string
+	ProductID string
+	OrderType string
+	Size      decimal.Decimal
+	Funds     decimal.Decimal
+	Price     decimal.Decimal
+	Side      common.Side
+}
+
+type DoneReason string
+
+const (
+	DoneFilled   DoneReason = "filled"
+	DoneCanceled DoneReason = "canceled"
+)
+
+// Done ends an order's life. Market orders never rest so they arrive without a Price.
+type Done struct {
+	Time          gdaxClient.Time
+	Sequence      int64
+	OrderID       string
+	ProductID     string
+	Reason        DoneReason
+	RemainingSize decimal.Decimal
+	Price         decimal.Decimal
+	Side          common.Side
+	Market        bool
+}
+
+// Activate is sent when one of our stop orders triggers.
+type Activate struct {
+	Time      gdaxClient.Time
+	OrderID   string
+	ProductID string
+	StopType  string
+	StopPrice decimal.Decimal
+	Size      decimal.Decimal
+	Funds     decimal.Decimal
+	Side      common.Side
+}
+
 func NewMatchMessage(tradeID int, sequence int64, makerOrderID string, takerOrderID string,
 	time gdaxClient.Time, productID string, size string, price string, side string) (Match, error) {
 	orderSide, err := ToSide(side)
@@ -68,10 +120,10 @@ func (msg Match) ToString() string {
 		msg.Time, msg.ProductID, msg.Size.String(), msg.Price.String(), common.ToString(msg.MatchSide))
 }
 
-func NewChangeMessage(time gdaxClient.Time, sequence int64, orderID string, productID string, newSize string, oldSize string, price string, side string) (Change, error) {
+func NewChangeMessage(time gdaxClient.Time, sequence int64, orderID string, productID string, newSize string, oldSize string,
+	newFunds string, oldFunds string, price string, side string) (Change, error) {
 	orderSide, err := ToSide(side)
 	if err != nil {
-		raven.CaptureErrorAndWait(err, nil)
 		return Change{}, err
 	}
 	var priceDec decimal.Decimal
@@ -84,16 +136,26 @@ func NewChangeMessage(time gdaxClient.Time, sequence int64, orderID string, prod
 		}
 	}
 
-	newSizeDec, err := decimal.NewFromString(newSize)
+	newSizeDec, err := optionalDecimal(newSize)
 	if err != nil {
 		return Change{}, errors.Wrap(err, "Could not convert newSize to decimal")
 	}
 
-	oldSizeDec, err := decimal.NewFromString(oldSize)
+	oldSizeDec, err := optionalDecimal(oldSize)
 	if err != nil {
 		return Change{}, errors.Wrap(err, "Could not convert oldSize to decimal")
 	}
 
+	newFundsDec, err := optionalDecimal(newFunds)
+	if err != nil {
+		return Change{}, errors.Wrap(err, "Could not convert newFunds to decimal")
+	}
+
+	oldFundsDec, err := optionalDecimal(oldFunds)
+	if err != nil {
+		return Change{}, errors.Wrap(err, "Could not convert oldFunds to decimal")
+	}
+
 	return Change{
 		Time:      time,
 		Sequence:  sequence,
@@ -101,7 +163,122 @@ func NewChangeMessage(time gdaxClient.Time, sequence int64, orderID string, prod
 		ProductID: productID,
 		NewSize:   newSizeDec,
 		OldSize:   oldSizeDec,
+		NewFunds:  newFundsDec,
+		OldFunds:  oldFundsDec,
 		Price:     priceDec,
 		Side:      orderSide,
 	}, nil
 }
+
+// IsMarket reports whether the change is for a market order, which only changes funds.
+func (msg Change) IsMarket() bool {
+	return msg.Price.IsZero()
+}
+
+func NewReceivedMessage(time gdaxClient.Time, sequence int64, orderID string, productID string, orderType string,
+	size string, funds string, price string, side string) (Received, error) {
+	orderSide, err := ToSide(side)
+	if err != nil {
+		return Received{}, err
+	}
+	sizeDec, err := optionalDecimal(size)
+	if err != nil {
+		return Received{}, errors.Wrap(err, "Could not convert size to decimal")
+	}
+	fundsDec, err := optionalDecimal(funds)
+	if err != nil {
+		return Received{}, errors.Wrap(err, "Could not convert funds to decimal")
+	}
+	priceDec, err := optionalDecimal(price)
+	if err != nil {
+		return Received{}, errors.Wrap(err, "Could not convert Price to decimal")
+	}
+
+	return Received{
+		Time:      time,
+		Sequence:  sequence,
+		OrderID:   orderID,
+		ProductID: productID,
+		OrderType: orderType,
+		Size:      sizeDec,
+		Funds:     fundsDec,
+		Price:     priceDec,
+		Side:      orderSide,
+	}, nil
+}
+
+func (msg Received) IsMarket() bool {
+	return msg.OrderType == MarketOrderType
+}
+
+func NewDoneMessage(time gdaxClient.Time, sequence int64, orderID string, productID string, reason string,
+	remainingSize string, price string, side string) (Done, error) {
+	orderSide, err := ToSide(side)
+	if err != nil {
+		return Done{}, err
+	}
+	remainingSizeDec, err := optionalDecimal(remainingSize)
+	if err != nil {
+		return Done{}, errors.Wrap(err, "Could not convert remaining size to decimal")
+	}
+	priceDec, err := optionalDecimal(price)
+	if err != nil {
+		return Done{}, errors.Wrap(err, "Could not convert Price to decimal")
+	}
+
+	return Done{
+		Time:          time,
+		Sequence:      sequence,
+		OrderID:       orderID,
+		ProductID:     productID,
+		Reason:        DoneReason(reason),
+		RemainingSize: remainingSizeDec,
+		Price:         priceDec,
+		Side:          orderSide,
+		Market:        price == "",
+	}, nil
+}
+
+func (msg Done) ToString() string {
+	return fmt.Sprintf("OrderID: %s, Sequence: %v, Reason: %s, RemainingSize: %s, Price: %s, Side: %s, Market: %v",
+		msg.OrderID, msg.Sequence, msg.Reason, msg.RemainingSize.String(), msg.Price.String(), common.ToString(msg.Side), msg.Market)
+}
+
+func NewActivateMessage(time gdaxClient.Time, orderID string, productID string, stopType string, stopPrice string,
+	size string, funds string, side string) (Activate, error) {
+	orderSide, err := ToSide(side)
+	if err != nil {
+		return Activate{}, err
+	}
+	stopPriceDec, err := optionalDecimal(stopPrice)
+	if err != nil {
+		return Activate{}, errors.Wrap(err, "Could not convert stop price to decimal")
+	}
+	sizeDec, err := optionalDecimal(size)
+	if err != nil {
+		return Activate{}, errors.Wrap(err, "Could not convert size to decimal")
+	}
+	fundsDec, err := optionalDecimal(funds)
+	if err != nil {
+		return Activate{}, errors.Wrap(err, "Could not convert funds to decimal")
+	}
+
+	return Activate{
+		Time:      time,
+		OrderID:   orderID,
+		ProductID: productID,
+		StopType:  stopType,
+		StopPrice: stopPriceDec,
+		Size:      sizeDec,
+		Funds:     fundsDec,
+		Side:      orderSide,
+	}, nil
+}
+
+// optionalDecimal parses fields the feed omits depending on order type, treating "" as zero.
+func optionalDecimal(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.New(0, 0), nil
+	}
+	return decimal.NewFromString(value)
+}
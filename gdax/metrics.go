@@ -0,0 +1,35 @@
+package gdax
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// bookMetricsInterval limits how often the book gauges are refreshed since depth walks every level.
+const bookMetricsInterval = time.Second
+
+// QueuedConsumer is implemented by consumers that buffer updates so their backlog can be exported.
+type QueuedConsumer interface {
+	Name() string
+	QueueLen() int
+}
+
+func (handler *Handler) observeMessage(message gdaxClient.Message, received time.Time) {
+	metrics.Messages.WithLabelValues(handler.book.ID, message.Type).Inc()
+	metrics.ApplyLatency.WithLabelValues(handler.book.ID, message.Type).Observe(time.Since(received).Seconds())
+	if exchangeTime := message.Time.Time(); !exchangeTime.IsZero() {
+		metrics.ExchangeLatency.WithLabelValues(handler.book.ID).Observe(received.Sub(exchangeTime).Seconds())
+	}
+	if time.Since(handler.lastBookMetrics) < bookMetricsInterval {
+		return
+	}
+	handler.lastBookMetrics = time.Now()
+	handler.book.ExportMetrics()
+	for _, listener := range handler.bookListeners {
+		if queued, ok := listener.(QueuedConsumer); ok {
+			metrics.ConsumerQueueLag.WithLabelValues(handler.book.ID, queued.Name()).Set(float64(queued.QueueLen()))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package gdax
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+type OwnOrderStatus uint8
+
+const (
+	OwnOrderReceived OwnOrderStatus = iota
+	OwnOrderOpen
+	OwnOrderDone
+)
+
+func (status OwnOrderStatus) String() string {
+	switch status {
+	case OwnOrderReceived:
+		return "received"
+	case OwnOrderOpen:
+		return "open"
+	default:
+		return "done"
+	}
+}
+
+// OwnOrder is one of our orders as reported by the authenticated user channel.
+type OwnOrder struct {
+	ID            string
+	ProductID     string
+	Side          common.Side
+	Price         decimal.Decimal
+	Size          decimal.Decimal
+	RemainingSize decimal.Decimal
+	FilledSize    decimal.Decimal
+	Status        OwnOrderStatus
+	DoneReason    string
+	Resting       bool
+	Updated       time.Time
+}
+
+// OwnOrders tracks our live orders. It is safe for concurrent use so strategies
+// can read it while the handler applies user channel messages.
+type OwnOrders struct {
+	mu     sync.RWMutex
+	orders map[string]*OwnOrder
+}
+
+func NewOwnOrders() *OwnOrders {
+	return &OwnOrders{orders: map[string]*OwnOrder{}}
+}
+
+func (o *OwnOrders) Has(id string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, found := o.orders[id]
+	return found
+}
+
+// Get returns a copy of the order so callers never race with the handler.
+func (o *OwnOrders) Get(id string) (OwnOrder, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	order, found := o.orders[id]
+	if !found {
+		return OwnOrder{}, false
+	}
+	return *order, true
+}
+
+func (o *OwnOrders) Active() []OwnOrder {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	orders := make([]OwnOrder, 0, len(o.orders))
+	for _, order := range o.orders {
+		orders = append(orders, *order)
+	}
+	return orders
+}
+
+func (o *OwnOrders) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.orders = map[string]*OwnOrder{}
+}
+
+// Apply updates the store from a user channel message. It returns the order as
+// it stands after the message and whether the message referenced one of our orders.
+func (o *OwnOrders) Apply(message gdaxClient.Message) (OwnOrder, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch message.Type {
+	case "received":
+		side, err := ToSide(message.Side)
+		if err != nil {
+			return OwnOrder{}, false, errors.Wrap(err, "Could not parse received message side")
+		}
+		order := &OwnOrder{
+			ID:        message.OrderId,
+			ProductID: message.ProductId,
+			Side:      side,
+			Status:    OwnOrderReceived,
+			Updated:   message.Time.Time(),
+		}
+		if message.Price != "" {
+			if order.Price, err = decimal.NewFromString(message.Price); err != nil {
+				return OwnOrder{}, false, errors.Wrap(err, "Could not convert Price to decimal")
+			}
+		}
+		if message.Size != "" {
+			if order.Size, err = decimal.NewFromString(message.Size); err != nil {
+				return OwnOrder{}, false, errors.Wrap(err, "Could not convert size to decimal")
+			}
+		}
+		order.RemainingSize = order.Size
+		o.orders[order.ID] = order
+		return *order, true, nil
+	case "open":
+		order, found := o.orders[message.OrderId]
+		if !found {
+			return OwnOrder{}, false, nil
+		}
+		remaining, err := decimal.NewFromString(message.RemainingSize)
+		if err != nil {
+			return OwnOrder{}, false, errors.Wrap(err, "Could not convert remaining size to decimal")
+		}
+		order.RemainingSize = remaining
+		order.Status = OwnOrderOpen
+		order.Resting = true
+		order.Updated = message.Time.Time()
+		return *order, true, nil
+	case "match":
+		order, found := o.orders[message.MakerOrderId]
+		if !found {
+			order, found = o.orders[message.TakerOrderId]
+		}
+		if !found {
+			return OwnOrder{}, false, nil
+		}
+		size, err := decimal.NewFromString(message.Size)
+		if err != nil {
+			return OwnOrder{}, false, errors.Wrap(err, "Could not convert size to decimal")
+		}
+		order.FilledSize = order.FilledSize.Add(size)
+		order.RemainingSize = order.RemainingSize.Sub(size)
+		order.Updated = message.Time.Time()
+		return *order, true, nil
+	case "change":
+		order, found := o.orders[message.OrderId]
+		if !found {
+			return OwnOrder{}, false, nil
+		}
+		newSize, err := decimal.NewFromString(message.NewSize)
+		if err != nil {
+			return OwnOrder{}, false, errors.Wrap(err, "Could not convert newSize to decimal")
+		}
+		order.RemainingSize = newSize
+		order.Updated = message.Time.Time()
+		return *order, true, nil
+	case "done":
+		order, found := o.orders[message.OrderId]
+		if !found {
+			return OwnOrder{}, false, nil
+		}
+		order.Status = OwnOrderDone
+		order.DoneReason = message.Reason
+		order.Resting = false
+		order.Updated = message.Time.Time()
+		delete(o.orders, order.ID)
+		return *order, true, nil
+	}
+	return OwnOrder{}, false, nil
+}
+
+func (o *OwnOrders) Remove(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.orders, id)
+}
@@ -0,0 +1,22 @@
+package gdax
+
+import (
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// ProductScale builds a fixed-point scale from the product's quote_increment. The REST API does
+// not report a base increment for every product, so sizeLot may override the default lot.
+func ProductScale(client *gdaxClient.Client, productID string, sizeLot string) (common.Scale, error) {
+	products, err := client.GetProducts()
+	if err != nil {
+		return common.Scale{}, errors.Wrap(err, "Could not get products")
+	}
+	for _, product := range products {
+		if product.Id == productID {
+			return common.NewScale(product.QuoteIncrement, sizeLot)
+		}
+	}
+	return common.Scale{}, errors.Errorf("Product %s not found", productID)
+}
@@ -0,0 +1,115 @@
+package gdax
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+// ErrReplayGap is returned by ApplyRecorded when a recorded message does not follow the book's sequence.
+var ErrReplayGap = errors.New("recorded feed has a sequence gap")
+
+type snapshotState uint8
+
+const (
+	snapshotNone snapshotState = iota
+	snapshotLoading
+	snapshotSkipping
+)
+
+// ApplyRecorded applies one line of a recorded raw feed to the book, as the handler did when it
+// was live. Snapshots in the recording replace the book when they are newer than it. After a gap
+// the book is stale and every message is skipped until the next newer snapshot.
+func (handler *Handler) ApplyRecorded(line []byte) error {
+	message := gdaxClient.Message{}
+	if err := json.Unmarshal(line, &message); err != nil {
+		return errors.Wrap(err, "Could not unmarshal recorded message")
+	}
+	if handler.mode != FullFeed {
+		return handler.handleAggregated(message, line, message.Time.Time())
+	}
+	return handler.ApplyMessage(message)
+}
+
+// ApplyMessage is ApplyRecorded for a message that is already decoded, such as a generated one.
+// Like ApplyRecorded it does not lock the book, so only use it before the handler runs.
+func (handler *Handler) ApplyMessage(message gdaxClient.Message) error {
+	switch message.Type {
+	case SnapshotStartMessageType:
+		if message.Sequence <= handler.sequence && !handler.replayBroken {
+			handler.replaySnapshot = snapshotSkipping
+			return nil
+		}
+		handler.clearBook()
+		handler.resetIntegrity()
+		handler.sequence = message.Sequence
+		handler.book.Sequence = message.Sequence
+		handler.flushEvent(orderbook.Event{Type: orderbook.EventReset, Time: message.Time.Time()})
+		handler.replaySnapshot = snapshotLoading
+		handler.replayBroken = false
+		return nil
+	case SnapshotMessageType:
+		if handler.replaySnapshot != snapshotLoading {
+			return nil
+		}
+		order, err := parseSnapshotOrder(message)
+		if err != nil {
+			return err
+		}
+		if err := handler.book.Add(order); err != nil {
+			return err
+		}
+		handler.flushOrderEvent(orderbook.EventOpen, order, decimal.Decimal{}, order.Time)
+		return nil
+	}
+
+	handler.replaySnapshot = snapshotNone
+	if handler.replayBroken || message.Sequence <= handler.sequence {
+		return nil
+	}
+	if message.Sequence != handler.sequence+1 {
+		handler.replayBroken = true
+		return ErrReplayGap
+	}
+	handler.sequence = message.Sequence
+	handler.book.Sequence = message.Sequence
+	handler.book.Updated = message.Time.Time()
+	if err := handler.handleIncremental(message); err != nil {
+		return err
+	}
+	// a replay cannot resync, so anomalies are only reported
+	handler.checkIntegrity(message)
+	handler.flushAnomalies()
+	return nil
+}
+
+// ReplayBroken reports whether the book has been stale since a gap in the recording.
+func (handler *Handler) ReplayBroken() bool {
+	return handler.replayBroken
+}
+
+func parseSnapshotOrder(snapshot gdaxClient.Message) (*orderbook.Order, error) {
+	size, err := decimal.NewFromString(snapshot.Size)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not convert size to decimal")
+	}
+	price, err := decimal.NewFromString(snapshot.Price)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not convert Price to decimal")
+	}
+	var side common.Side
+	switch snapshot.Side {
+	case common.ToString(common.BidSide):
+		side = common.BidSide
+	case common.ToString(common.AskSide):
+		side = common.AskSide
+	default:
+		return nil, fmt.Errorf("Side %s is not supported in %s message", snapshot.Side, snapshot.Type)
+	}
+	return &orderbook.Order{ID: snapshot.OrderId, Size: size, Price: price, Side: side}, nil
+}
@@ -0,0 +1,161 @@
+package gdax
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+)
+
+// EnableCheckpoints writes a checkpoint of the book to dir every interval and lets the first
+// connection start from that checkpoint instead of a REST snapshot. Messages recorded in
+// journalDir after the checkpoint are replayed before listening, and again up to the first live
+// message; the live feed then either continues the sequence or triggers the usual resync.
+// journalDir may be empty.
+func (handler *Handler) EnableCheckpoints(dir string, interval time.Duration, journalDir string) {
+	handler.checkpointDir = dir
+	handler.checkpointInterval = interval
+	handler.journalDir = journalDir
+}
+
+func (handler *Handler) checkpointLoop() {
+	ticker := time.NewTicker(handler.checkpointInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := handler.WriteCheckpoint(); err != nil {
+			zap.L().Error("Could not write checkpoint", zap.String("product", handler.book.ID), zap.Error(err))
+			handler.reporter.Report(err, map[string]string{"product": handler.book.ID})
+		}
+	}
+}
+
+// KeepCheckpointHistory also keeps every checkpoint EnableCheckpoints writes under dir, where
+// history.Store finds them.
+func (handler *Handler) KeepCheckpointHistory(dir string) {
+	handler.historyDir = dir
+}
+
+// WriteCheckpoint saves the book if it has been synced. Call it on shutdown as well.
+func (handler *Handler) WriteCheckpoint() error {
+	if handler.checkpointDir == "" {
+		return nil
+	}
+	var checkpoint orderbook.Checkpoint
+	handler.book.View(func(book *orderbook.Book) {
+		checkpoint = book.Checkpoint()
+	})
+	if checkpoint.Sequence == 0 {
+		return nil
+	}
+	if err := orderbook.WriteCheckpoint(orderbook.CheckpointPath(handler.checkpointDir, handler.book.ID), checkpoint); err != nil {
+		return err
+	}
+	if handler.historyDir != "" {
+		if err := orderbook.WriteHistoryCheckpoint(handler.historyDir, checkpoint); err != nil {
+			return err
+		}
+	}
+	zap.L().Info("Wrote checkpoint", zap.String("product", handler.book.ID), zap.Int64("sequence", checkpoint.Sequence))
+	return nil
+}
+
+// restoreCheckpoint loads the checkpoint and catches up from the journal. It is only tried on
+// the first connection and reports whether the book was restored.
+func (handler *Handler) restoreCheckpoint() bool {
+	if handler.checkpointDir == "" || handler.restoreAttempted {
+		return false
+	}
+	handler.restoreAttempted = true
+
+	checkpoint, err := orderbook.ReadCheckpoint(orderbook.CheckpointPath(handler.checkpointDir, handler.book.ID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			zap.L().Error("Could not read checkpoint", zap.String("product", handler.book.ID), zap.Error(err))
+		}
+		return false
+	}
+
+	handler.book.Lock()
+	defer handler.book.Unlock()
+	if err := handler.Restore(checkpoint); err != nil {
+		zap.L().Error("Could not restore checkpoint", zap.String("product", handler.book.ID), zap.Error(err))
+		return false
+	}
+
+	if handler.journalDir != "" {
+		err = journal.Read(handler.journalDir, handler.book.ID, func(line []byte) error {
+			if err := handler.ApplyRecorded(line); err != nil && err != ErrReplayGap {
+				return err
+			}
+			return nil
+		})
+		if err != nil && err != io.EOF {
+			zap.L().Error("Could not replay journal", zap.String("product", handler.book.ID), zap.Error(err))
+			handler.replayBroken = true
+		}
+	}
+	if handler.replayBroken {
+		zap.L().Info("Journal could not bridge checkpoint, falling back to snapshot", zap.String("product", handler.book.ID))
+		handler.clearBook()
+		handler.sequence = 0
+		handler.replayBroken = false
+		return false
+	}
+	zap.L().Info("Restored book from checkpoint", zap.String("product", handler.book.ID),
+		zap.Int64("checkpointSequence", checkpoint.Sequence), zap.Int64("sequence", handler.sequence))
+	handler.catchUpPending = handler.journalDir != ""
+	return true
+}
+
+// catchUp replays what the journal recorded after the restored book and before live, the first
+// live sequence. The journal only grows while this handler is down if another process, such as
+// record, keeps writing it; otherwise the gap is left to the gap policy like any other.
+func (handler *Handler) catchUp(live int64) {
+	handler.catchUpPending = false
+	if live <= handler.sequence+1 {
+		return
+	}
+	handler.book.Lock()
+	defer handler.book.Unlock()
+	restored := handler.sequence
+	err := journal.Read(handler.journalDir, handler.book.ID, func(line []byte) error {
+		message := gdaxClient.Message{}
+		if err := json.Unmarshal(line, &message); err != nil {
+			return errors.Wrap(err, "Could not unmarshal recorded message")
+		}
+		if message.Sequence >= live {
+			return io.EOF
+		}
+		if err := handler.ApplyMessage(message); err != nil && err != ErrReplayGap {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("Could not replay journal", zap.String("product", handler.book.ID), zap.Error(err))
+	}
+	handler.replayBroken = false
+	zap.L().Info("Caught up from journal", zap.String("product", handler.book.ID), zap.Int64("restoredSequence", restored),
+		zap.Int64("sequence", handler.sequence), zap.Int64("liveSequence", live))
+}
+
+// Restore replaces the book with a checkpoint so that recorded messages after it can be applied.
+// Like ApplyRecorded it does not lock the book.
+func (handler *Handler) Restore(checkpoint orderbook.Checkpoint) error {
+	handler.clearBook()
+	if err := handler.book.Restore(checkpoint); err != nil {
+		handler.clearBook()
+		return err
+	}
+	handler.resetIntegrity()
+	handler.sequence = checkpoint.Sequence
+	handler.replayBroken = false
+	handler.flushBookEvents()
+	return nil
+}
@@ -0,0 +1,75 @@
+package gdax
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+func record(t *testing.T, writer *journal.Writer, from int64, to int64) {
+	for sequence := from; sequence <= to; sequence++ {
+		line, err := json.Marshal(open(sequence, fmt.Sprint(sequence), "100.00", "buy"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Message(string(line))
+	}
+}
+
+func TestRestoredBookCatchesUpFromJournal(t *testing.T) {
+	tests := []struct {
+		name string
+		// recordedWhileDown is the last sequence another process journaled after the restore
+		recordedWhileDown int64
+		syncs             int
+		orders            int
+	}{
+		{name: "journal kept by a recorder bridges the restart", recordedWhileDown: 16, orders: 16},
+		{name: "stale journal falls back to the gap policy", recordedWhileDown: 12, syncs: 1, orders: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkpointDir, journalDir := t.TempDir(), t.TempDir()
+			checkpointed := NewHandler(nil, orderbook.NewBook("BTC-USD", nil), nil)
+			checkpointed.EnableCheckpoints(checkpointDir, time.Hour, journalDir)
+			for sequence := int64(1); sequence <= 10; sequence++ {
+				if err := checkpointed.ApplyMessage(open(sequence, fmt.Sprint(sequence), "100.00", "buy")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := checkpointed.WriteCheckpoint(); err != nil {
+				t.Fatal(err)
+			}
+			writer, err := journal.NewWriter(journalDir, "BTC-USD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer writer.Close()
+			record(t, writer, 11, 12)
+
+			snapshots, client := newSnapshotServer(t, restBook{Sequence: 15})
+			handler := NewHandler(client, orderbook.NewBook("BTC-USD", nil), nil)
+			handler.EnableCheckpoints(checkpointDir, time.Hour, journalDir)
+			if !handler.restoreCheckpoint() || handler.sequence != 12 {
+				t.Fatalf("restored to sequence %d, want the checkpoint and the journal up to 12", handler.sequence)
+			}
+			record(t, writer, 13, test.recordedWhileDown)
+			if err := playFeed(t, handler, []gdaxClient.Message{
+				open(15, "15", "100.00", "buy"), open(16, "16", "100.00", "buy"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if snapshots.count() != test.syncs {
+				t.Fatalf("%d snapshots requested, want %d", snapshots.count(), test.syncs)
+			}
+			if handler.sequence != 16 || handler.book.NumOrders() != test.orders {
+				t.Fatalf("book at sequence %d with %d orders, want 16 with %d", handler.sequence, handler.book.NumOrders(), test.orders)
+			}
+		})
+	}
+}
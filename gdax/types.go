@@ -3,37 +3,42 @@ package gdax
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/chrischris292/go-gdax-orderbook/common"
-	raven "github.com/getsentry/raven-go"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
 	"github.com/pkg/errors"
 	gdaxClient "github.com/preichenberger/go-gdax"
 	"github.com/shopspring/decimal"
 )
 
-type Order struct {
-	ID    string
-	Size  decimal.Decimal
-	Price decimal.Decimal
-	Side  common.Side
-}
-
 const SnapshotMessageType = "snapshot"
 
+// SnapshotStartMessageType precedes the snapshot orders in the raw feed and carries the snapshot's sequence.
+const SnapshotStartMessageType = "snapshot_start"
+
 type HandlerConsumer interface {
 	BookUpdate(message gdaxClient.Message)
 	TradeTick(msg Match)
 	Clear()
 }
 
+// OrderFlowListener receives order lifecycle events that do not change the resting book
+// on their own: received orders, stop activations and done messages with their reason.
+type OrderFlowListener interface {
+	Received(msg Received)
+	Activate(msg Activate)
+	Done(msg Done)
+}
+
 type RawFeedListener interface {
 	Message(string)
 }
 
-func NewOrder(id string, size string, price string, side string) (*Order, error) {
+func NewOrder(id string, size string, price string, side string) (*orderbook.Order, error) {
 	orderSide, err := ToSide(side)
 	if err != nil {
-		return &Order{}, errors.Wrap(err, "could not convert side string to side")
+		return &orderbook.Order{}, errors.Wrap(err, "could not convert side string to side")
 	}
 	sizeDec, err := decimal.NewFromString(size)
 	if err != nil {
@@ -44,14 +49,11 @@ func NewOrder(id string, size string, price string, side string) (*Order, error)
 		return nil, errors.Wrap(err, "Could not convert Price to decimal")
 	}
 
-	return &Order{ID: id, Size: sizeDec, Price: priceDec, Side: orderSide}, nil
+	return &orderbook.Order{ID: id, Size: sizeDec, Price: priceDec, Side: orderSide}, nil
 }
 
-func (order *Order) ToString() string {
-	return fmt.Sprintf("ID: %s, Size: %s, Price: %s, Side: %s", order.ID, order.Size.String(), order.Price.String(), common.ToString(order.Side))
-}
-
-func (order *Order) ToJSON() string {
+// OrderJSON is how a snapshot order appears in the raw feed.
+func OrderJSON(order *orderbook.Order) string {
 	orderMap := map[string]string{
 		"order_id": order.ID,
 		"size":     order.Size.String(),
@@ -63,13 +65,24 @@ func (order *Order) ToJSON() string {
 	return string(jsonStr)
 }
 
-func NewOrderFromDecimal(id string, size decimal.Decimal, price decimal.Decimal, side string) (*Order, error) {
+// SnapshotStartJSON marks where a REST snapshot starts in the journal. at is when it was taken,
+// so historical queries by time know whether to apply it.
+func SnapshotStartJSON(productID string, sequence int64, at time.Time) string {
+	jsonStr, _ := json.Marshal(map[string]interface{}{
+		"type":       SnapshotStartMessageType,
+		"product_id": productID,
+		"sequence":   sequence,
+		"time":       at.UTC().Format(time.RFC3339Nano),
+	})
+	return string(jsonStr)
+}
+
+func NewOrderFromDecimal(id string, size decimal.Decimal, price decimal.Decimal, side string) (*orderbook.Order, error) {
 	orderSide, err := ToSide(side)
 	if err != nil {
-		raven.CaptureErrorAndWait(err, nil)
-		return &Order{}, err
+		return &orderbook.Order{}, err
 	}
-	return &Order{ID: id, Size: size, Price: price, Side: orderSide}, nil
+	return &orderbook.Order{ID: id, Size: size, Price: price, Side: orderSide}, nil
 }
 
 func ToSide(side string) (common.Side, error) {
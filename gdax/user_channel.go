@@ -0,0 +1,120 @@
+package gdax
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+)
+
+const (
+	userChannelVerifyPath = "/users/self/verify"
+	// ownSequenceMemory is how many of the latest own sequences are remembered to spot second copies.
+	ownSequenceMemory = 1024
+)
+
+type signedSubscription struct {
+	gdaxClient.Message
+	Key        string `json:"key"`
+	Passphrase string `json:"passphrase"`
+	Timestamp  string `json:"timestamp"`
+	Signature  string `json:"signature"`
+}
+
+// newSignedSubscription signs a subscribe message the same way the REST API signs a
+// GET of /users/self/verify, which is what the user channel expects.
+func newSignedSubscription(subscribe gdaxClient.Message, credentials Credentials) (signedSubscription, error) {
+	secret, err := base64.StdEncoding.DecodeString(credentials.Secret)
+	if err != nil {
+		return signedSubscription{}, errors.Wrap(err, "Could not decode secret")
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "GET" + userChannelVerifyPath))
+	return signedSubscription{
+		Message:    subscribe,
+		Key:        credentials.Key,
+		Passphrase: credentials.Passphrase,
+		Timestamp:  timestamp,
+		Signature:  base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// isUserMessage reports whether a message is tagged as one of our own. Once authenticated, the
+// full channel tags our orders' messages and the user channel repeats them with the same sequence.
+func isUserMessage(message gdaxClient.Message) bool {
+	return message.UserId != "" || message.ProfileId != ""
+}
+
+// ownSequences remembers the sequences of the latest tagged messages. Each arrives once on the
+// full channel and once on the user channel, usually but not always in sequence order, so only a
+// sequence seen before marks a copy; an older sequence than the last may still be new.
+type ownSequences struct {
+	seen  map[int64]bool
+	order []int64
+}
+
+// add records a sequence and reports whether it is new.
+func (sequences *ownSequences) add(sequence int64) bool {
+	if sequences.seen[sequence] {
+		return false
+	}
+	if sequences.seen == nil {
+		sequences.seen = map[int64]bool{}
+	}
+	if len(sequences.order) == ownSequenceMemory {
+		delete(sequences.seen, sequences.order[0])
+		sequences.order = sequences.order[1:]
+	}
+	sequences.seen[sequence] = true
+	sequences.order = append(sequences.order, sequence)
+	return true
+}
+
+// trackOwnOrder applies a tagged message to the own order store the first time its sequence is
+// seen and reports the second copy as a duplicate. The message still goes to the book.
+func (handler *Handler) trackOwnOrder(message gdaxClient.Message) (bool, error) {
+	if message.Sequence != 0 && !handler.ownSequences.add(message.Sequence) {
+		return true, nil
+	}
+	if handler.ownOrders == nil {
+		return false, nil
+	}
+	return false, handler.handleUserMessage(message)
+}
+
+func (handler *Handler) handleUserMessage(message gdaxClient.Message) error {
+	order, ok, err := handler.ownOrders.Apply(message)
+	if err != nil {
+		return errors.Wrap(err, "Could not apply user channel message")
+	}
+	if !ok {
+		return nil
+	}
+	zap.L().Debug("Own order update", zap.String("id", order.ID), zap.String("status", order.Status.String()))
+	if order.Status == OwnOrderOpen {
+		handler.book.Lock()
+		handler.book.MarkOwn(order.ID, order.Price, order.Side)
+		handler.book.Unlock()
+	}
+	return nil
+}
+
+// reconcileOwnOrders flags our resting orders after a snapshot and drops the ones
+// that were filled or canceled while we were not listening.
+func (handler *Handler) reconcileOwnOrders() {
+	for _, order := range handler.ownOrders.Active() {
+		if order.Status != OwnOrderOpen {
+			continue
+		}
+		if !handler.book.MarkOwn(order.ID, order.Price, order.Side) {
+			zap.L().Info("Own order missing from book after sync", zap.String("id", order.ID))
+			handler.ownOrders.Remove(order.ID)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package gdax
+
+import (
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+// own tags a message the way the authenticated feed tags our orders' messages.
+func own(message gdaxClient.Message) gdaxClient.Message {
+	message.UserId, message.ProfileId = "user", "profile"
+	return message
+}
+
+func feedMessage(sequence int64, message gdaxClient.Message) gdaxClient.Message {
+	message.Sequence, message.ProductId = sequence, "BTC-USD"
+	return message
+}
+
+func received(sequence int64, id string, price string, side string) gdaxClient.Message {
+	return feedMessage(sequence, gdaxClient.Message{Type: "received", OrderId: id, OrderType: "limit", Size: "1", Price: price, Side: side})
+}
+
+func open(sequence int64, id string, price string, side string) gdaxClient.Message {
+	return feedMessage(sequence, gdaxClient.Message{Type: "open", OrderId: id, RemainingSize: "1", Price: price, Side: side})
+}
+
+func newOwnOrderHandler(client *gdaxClient.Client) (*Handler, *OwnOrders) {
+	handler := NewHandler(client, orderbook.NewBook("BTC-USD", nil), nil)
+	store := NewOwnOrders()
+	handler.TrackOwnOrders(store)
+	return handler, store
+}
+
+func TestOwnOrderOnBothChannelsIsAppliedOnce(t *testing.T) {
+	handler, store := newOwnOrderHandler(nil)
+	match := own(feedMessage(4, gdaxClient.Message{Type: "match", TradeId: 1, MakerOrderId: "mine", TakerOrderId: "taker",
+		Size: "0.4", Price: "100.00", Side: "buy"}))
+	messages := []gdaxClient.Message{
+		own(received(1, "mine", "100.00", "buy")), own(received(1, "mine", "100.00", "buy")),
+		own(open(2, "mine", "100.00", "buy")), own(open(2, "mine", "100.00", "buy")),
+		open(3, "other", "101.00", "sell"),
+		// the user channel copy may also come first
+		match, match,
+	}
+	if err := playFeed(t, handler, messages); err != nil {
+		t.Fatal(err)
+	}
+	order, found := store.Get("mine")
+	if !found || order.Status != OwnOrderOpen || !order.FilledSize.Equal(decimal.RequireFromString("0.4")) ||
+		!order.RemainingSize.Equal(decimal.RequireFromString("0.6")) {
+		t.Fatalf("own order is %+v, want open with 0.4 filled and 0.6 remaining", order)
+	}
+	bids := handler.book.GetDepth(common.BidSide, 0)
+	if len(bids) != 1 || !bids[0].Size.Equal(decimal.RequireFromString("0.6")) || bids[0].OwnOrders != 1 {
+		t.Fatalf("bids are %+v, want our 0.6 at 100.00", bids)
+	}
+	if stats := handler.GapStats(); stats.Gaps != 0 || stats.Duplicates != 0 {
+		t.Fatalf("gap stats %+v, want the second copies dropped before they reach the book", stats)
+	}
+}
+
+func TestOwnSequenceArrivingOutOfOrderIsApplied(t *testing.T) {
+	snapshots, client := newSnapshotServer(t, restBook{})
+	handler, store := newOwnOrderHandler(client)
+	handler.SetGapPolicy(GapConfig{Policy: ResyncReorder})
+	messages := []gdaxClient.Message{
+		open(1, "other", "101.00", "sell"),
+		// 3 overtakes 2 on the full channel and the user channel copies follow
+		own(received(3, "later", "99.00", "buy")),
+		own(received(2, "earlier", "100.00", "buy")),
+		own(received(2, "earlier", "100.00", "buy")), own(received(3, "later", "99.00", "buy")),
+		own(open(4, "earlier", "100.00", "buy")), own(open(4, "earlier", "100.00", "buy")),
+	}
+	if err := playFeed(t, handler, messages); err != nil {
+		t.Fatal(err)
+	}
+	if order, found := store.Get("earlier"); !found || order.Status != OwnOrderOpen {
+		t.Fatalf("own order behind a later sequence is %+v, want open", order)
+	}
+	if order, found := store.Get("later"); !found || order.Status != OwnOrderReceived {
+		t.Fatalf("own order that overtook is %+v, want received", order)
+	}
+	if bids := handler.book.GetDepth(common.BidSide, 0); len(bids) != 1 || bids[0].OwnOrders != 1 {
+		t.Fatalf("bids are %+v, want our order at 100.00", bids)
+	}
+	if stats := handler.GapStats(); stats.Gaps != 1 || stats.Recovered != 1 || snapshots.count() != 0 {
+		t.Fatalf("gap stats %+v after %d syncs, want the gap bridged without a resync", stats, snapshots.count())
+	}
+}
+
+func TestOwnOrdersReconcileAfterResync(t *testing.T) {
+	// "canceled" was canceled while messages were lost, so only "kept" is in the snapshot
+	snapshots, client := newSnapshotServer(t, restBook{Sequence: 10,
+		Bids: [][]string{{"100.00", "1", "kept"}},
+		Asks: [][]string{{"101.00", "1", "other"}},
+	})
+	handler, store := newOwnOrderHandler(client)
+	messages := []gdaxClient.Message{
+		own(received(1, "kept", "100.00", "buy")), own(open(2, "kept", "100.00", "buy")),
+		own(received(3, "canceled", "99.00", "buy")), own(open(4, "canceled", "99.00", "buy")),
+		open(9, "lost", "98.00", "buy"),
+		open(11, "next", "102.00", "sell"),
+	}
+	if err := playFeed(t, handler, messages); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots.count() != 1 {
+		t.Fatalf("%d syncs, want one for the gap", snapshots.count())
+	}
+	if store.Has("canceled") {
+		t.Fatalf("own order missing from the snapshot is still tracked")
+	}
+	if order, found := store.Get("kept"); !found || order.Status != OwnOrderOpen {
+		t.Fatalf("own order in the snapshot is %+v, want open", order)
+	}
+	bids := handler.book.GetDepth(common.BidSide, 0)
+	if len(bids) != 1 || bids[0].OwnOrders != 1 || !bids[0].OwnSize.Equal(decimal.New(1, 0)) {
+		t.Fatalf("bids are %+v, want our order flagged at 100.00 after the resync", bids)
+	}
+	if handler.book.NumOrders() != 3 {
+		t.Fatalf("book has %d orders, want the snapshot and the order after it", handler.book.NumOrders())
+	}
+}
@@ -0,0 +1,152 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// Store rebuilds a product's book as it was at a past sequence number or time. It starts from
+// the nearest earlier checkpoint kept by gdax.Handler.KeepCheckpointHistory, or from the start
+// of the journal when there is none, and replays the journal forward.
+type Store struct {
+	checkpointDir string
+	journalDir    string
+}
+
+// NewStore reads kept checkpoints from checkpointDir, which may be empty, and the raw feed from journalDir.
+func NewStore(checkpointDir string, journalDir string) *Store {
+	return &Store{checkpointDir: checkpointDir, journalDir: journalDir}
+}
+
+// Query picks the moment to rebuild. Set either Sequence, to get the book right after that
+// message, or Time, to get the book after the last message at or before it.
+type Query struct {
+	Sequence int64
+	Time     time.Time
+}
+
+// State is a rebuilt book. Checkpoint is the checkpoint it started from, nil when it was built
+// from a snapshot in the journal. Replayed counts the journal messages applied after it.
+type State struct {
+	Book       *orderbook.Book
+	Checkpoint *orderbook.CheckpointInfo
+	Replayed   int
+}
+
+// recordedHeader is the part of a journal line that decides whether to stop before it.
+type recordedHeader struct {
+	Type     string          `json:"type"`
+	Sequence int64           `json:"sequence"`
+	Time     gdaxClient.Time `json:"time"`
+}
+
+// Checkpoints lists the kept checkpoints of product, oldest first.
+func (store *Store) Checkpoints(product string) ([]orderbook.CheckpointInfo, error) {
+	if store.checkpointDir == "" {
+		return []orderbook.CheckpointInfo{}, nil
+	}
+	return orderbook.ListCheckpoints(store.checkpointDir, product)
+}
+
+// BookAt rebuilds the full order book of product at the query. GetOrders on the result gives the
+// L3 book and Snapshot or GetDepth the L2 book.
+func (store *Store) BookAt(product string, query Query) (State, error) {
+	if (query.Sequence > 0) == !query.Time.IsZero() {
+		return State{}, errors.New("Query needs either a sequence or a time")
+	}
+	state := State{Book: orderbook.NewBook(product, nil)}
+	handler := gdax.NewHandler(nil, state.Book, nil)
+
+	checkpoints, err := store.Checkpoints(product)
+	if err != nil {
+		return State{}, err
+	}
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		if query.includes(checkpoints[i].Sequence, checkpoints[i].Time) {
+			state.Checkpoint = &checkpoints[i]
+			break
+		}
+	}
+	if state.Checkpoint != nil {
+		checkpoint, err := orderbook.ReadCheckpoint(state.Checkpoint.Path)
+		if err != nil {
+			return State{}, errors.Wrap(err, "Could not read checkpoint "+state.Checkpoint.Path)
+		}
+		if err := handler.Restore(checkpoint); err != nil {
+			return State{}, errors.Wrap(err, "Could not restore checkpoint "+state.Checkpoint.Path)
+		}
+	}
+
+	paths, err := journal.Files(store.journalDir, product)
+	if err != nil {
+		return State{}, errors.Wrap(err, "Could not list journal files")
+	}
+	for _, path := range paths {
+		// messages after a checkpoint were all written on or after its day
+		if state.Checkpoint != nil && journal.Day(path) < state.Checkpoint.Time.UTC().Format("2006-01-02") {
+			continue
+		}
+		err = journal.ReadFile(path, func(line []byte) error {
+			header := recordedHeader{}
+			if err := json.Unmarshal(line, &header); err != nil {
+				return errors.Wrap(err, "Could not unmarshal recorded message")
+			}
+			if header.Type != gdax.SnapshotMessageType && !query.includesMessage(header) {
+				return io.EOF
+			}
+			before := state.Book.Sequence
+			if err := handler.ApplyRecorded(line); err != nil && err != gdax.ErrReplayGap {
+				return err
+			}
+			if state.Book.Sequence != before && header.Type != gdax.SnapshotStartMessageType {
+				state.Replayed += 1
+			}
+			return nil
+		})
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return State{}, err
+		}
+	}
+
+	if handler.ReplayBroken() {
+		return State{}, fmt.Errorf("Journal has a gap after sequence %d and no later snapshot before the query", state.Book.Sequence)
+	}
+	if state.Book.Sequence == 0 {
+		return State{}, errors.New("No checkpoint or journal snapshot before the query")
+	}
+	if query.Sequence > 0 && state.Book.Sequence != query.Sequence {
+		return State{}, fmt.Errorf("Journal ends at sequence %d before %d", state.Book.Sequence, query.Sequence)
+	}
+	return state, nil
+}
+
+// includes reports whether a book at sequence and time is not past the query.
+func (query Query) includes(sequence int64, at time.Time) bool {
+	if query.Sequence > 0 {
+		return sequence <= query.Sequence
+	}
+	return !at.After(query.Time)
+}
+
+// includesMessage reports whether a recorded message belongs before the query. By time, messages
+// without one, such as snapshot starts in older journals, are applied.
+func (query Query) includesMessage(header recordedHeader) bool {
+	if query.Sequence > 0 {
+		return header.Sequence <= query.Sequence
+	}
+	if header.Time.Time().IsZero() {
+		return true
+	}
+	return query.includes(header.Sequence, header.Time.Time())
+}
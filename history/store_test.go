@@ -0,0 +1,110 @@
+package history_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/history"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// sameCheckpointOrders compares the resting orders of two checkpoints in queue order.
+func sameCheckpointOrders(got orderbook.Checkpoint, want orderbook.Checkpoint) error {
+	if got.Sequence != want.Sequence {
+		return fmt.Errorf("book is at sequence %d, want %d", got.Sequence, want.Sequence)
+	}
+	for i, orders := range [][]orderbook.CheckpointOrder{want.Bids, want.Asks} {
+		gotOrders := [][]orderbook.CheckpointOrder{got.Bids, got.Asks}[i]
+		if len(gotOrders) != len(orders) {
+			return fmt.Errorf("side %d has %d orders, want %d", i, len(gotOrders), len(orders))
+		}
+		for j, order := range orders {
+			if gotOrders[j].ID != order.ID || !gotOrders[j].Price.Equal(order.Price) || !gotOrders[j].Size.Equal(order.Size) {
+				return fmt.Errorf("order %d is %v, want %v", j, gotOrders[j], order)
+			}
+		}
+	}
+	return nil
+}
+
+// writeJournal records a generated session, led by an empty snapshot, as a journal in dir.
+func writeJournal(t *testing.T, dir string) (synthetic.Config, []gdaxClient.Message) {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	messages := append(generator.Generate(10000), generator.Flush()...)
+	writer, err := journal.NewWriter(dir, config.ProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.Message(gdax.SnapshotStartJSON(config.ProductID, messages[0].Sequence-1, messages[0].Time.Time()))
+	for _, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Message(string(line))
+	}
+	return config, messages
+}
+
+func TestBookAtPastSequencesAndTimes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orderbook-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journalDir, checkpointDir := dir+"/journal", dir+"/checkpoints"
+
+	config, messages := writeJournal(t, journalDir)
+	live := orderbook.NewBook(config.ProductID, nil)
+	handler := gdax.NewHandler(nil, live, nil)
+	if err := handler.ApplyRecorded([]byte(gdax.SnapshotStartJSON(config.ProductID, messages[0].Sequence-1, messages[0].Time.Time()))); err != nil {
+		t.Fatal(err)
+	}
+
+	// the book after a quarter, half and three quarters of the stream, the middle one kept as a checkpoint
+	wanted := map[int]orderbook.Checkpoint{len(messages) / 4: {}, len(messages) / 2: {}, 3 * len(messages) / 4: {}}
+	for i, message := range messages {
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+		if _, found := wanted[i]; found {
+			wanted[i] = live.Checkpoint()
+		}
+		if i == len(messages)/2 {
+			if err := orderbook.WriteHistoryCheckpoint(checkpointDir, live.Checkpoint()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	store := history.NewStore(checkpointDir, journalDir)
+	for i, want := range wanted {
+		queries := []history.Query{{Sequence: messages[i].Sequence}}
+		// a query by time stops after every message of that time
+		if i+1 == len(messages) || !messages[i+1].Time.Time().Equal(messages[i].Time.Time()) {
+			queries = append(queries, history.Query{Time: messages[i].Time.Time()})
+		}
+		for _, query := range queries {
+			state, err := store.BookAt(config.ProductID, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sameCheckpointOrders(state.Book.Checkpoint(), want); err != nil {
+				t.Fatalf("query %+v: %v", query, err)
+			}
+			if fromCheckpoint := state.Checkpoint != nil; fromCheckpoint != (i >= len(messages)/2) {
+				t.Fatalf("query %+v started from checkpoint %v", query, state.Checkpoint)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package journal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const fileSuffix = ".jsonl.gz"
+
+// Writer records the raw feed of one product as gzipped JSON lines, one file per UTC day,
+// at <dir>/<product>/<YYYY-MM-DD>.jsonl.gz. It is a gdax.RawFeedListener.
+type Writer struct {
+	mu      sync.Mutex
+	dir     string
+	product string
+	day     string
+	file    *os.File
+	closed  bool
+}
+
+func NewWriter(dir string, product string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Join(dir, product), 0755); err != nil {
+		return nil, errors.Wrap(err, "Could not create journal directory")
+	}
+	return &Writer{dir: dir, product: product}, nil
+}
+
+// Message records one raw feed message. Messages after Close are dropped, so the handler may
+// still be running when the writer is closed.
+func (writer *Writer) Message(message string) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if writer.closed {
+		return
+	}
+	day := time.Now().UTC().Format("2006-01-02")
+	if writer.file == nil || day != writer.day {
+		if err := writer.rotate(day); err != nil {
+			zap.L().Error("Could not rotate journal", zap.String("product", writer.product), zap.Error(err))
+			return
+		}
+	}
+	util.WriteCompressedLine(message, writer.file)
+}
+
+func (writer *Writer) rotate(day string) error {
+	if writer.file != nil {
+		writer.file.Close()
+	}
+	path := filepath.Join(writer.dir, writer.product, day+fileSuffix)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		writer.file = nil
+		return err
+	}
+	writer.file = file
+	writer.day = day
+	return nil
+}
+
+func (writer *Writer) Close() error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	writer.closed = true
+	if writer.file == nil {
+		return nil
+	}
+	err := writer.file.Close()
+	writer.file = nil
+	return err
+}
+
+// Files lists the journal files of product oldest first.
+func Files(dir string, product string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, product, "*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Read calls fn with every recorded line of product, oldest first, until fn returns an error.
+// io.EOF from fn stops reading without an error.
+func Read(dir string, product string, fn func(line []byte) error) error {
+	paths, err := Files(dir, product)
+	if err != nil {
+		return errors.Wrap(err, "Could not list journal files")
+	}
+	for _, path := range paths {
+		if err := ReadFile(path, fn); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadFile calls fn with every line of one journal file. A truncated final gzip member,
+// as left behind by a crash, ends the file without an error.
+func ReadFile(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "Could not open journal file")
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(bufio.NewReader(file))
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Could not read journal file "+path)
+	}
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && err != io.ErrUnexpectedEOF {
+		return errors.Wrap(err, "Could not read journal file "+path)
+	}
+	return nil
+}
+
+// Day returns the UTC day a journal file covers.
+func Day(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), fileSuffix)
+}
@@ -0,0 +1,33 @@
+package journal_test
+
+import (
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+)
+
+func TestMessageAfterCloseIsDropped(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := journal.NewWriter(dir, "BTC-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Message(`{"type":"open","sequence":1}`)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writer.Message(`{"type":"open","sequence":2}`)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{}
+	if err := journal.Read(dir, "BTC-USD", func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0] != `{"type":"open","sequence":1}` {
+		t.Fatalf("journal holds %q, want only the message before Close", lines)
+	}
+}
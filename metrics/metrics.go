@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orderbook"
+
+var (
+	Messages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_total",
+		Help:      "Feed messages applied, by product and message type.",
+	}, []string{"product", "type"})
+
+	ApplyLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "apply_latency_seconds",
+		Help:      "Time spent applying a feed message to the book and notifying consumers.",
+		Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 10),
+	}, []string{"product", "type"})
+
+	ExchangeLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "exchange_latency_seconds",
+		Help:      "Delay between the exchange timestamp on a message and when it was read locally.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"product"})
+
+	Resyncs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resyncs_total",
+		Help:      "Level 3 REST snapshots taken to (re)build the book.",
+	}, []string{"product"})
+
+	SequenceGaps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sequence_gaps_total",
+		Help:      "Out of order sequence numbers received from the feed.",
+	}, []string{"product"})
+
+	GapSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sequence_gap_size",
+		Help:      "Messages missing from the feed at each sequence gap.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"product"})
+
+	DuplicateMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_messages_total",
+		Help:      "Feed messages dropped because the book had already applied their sequence number.",
+	}, []string{"product"})
+
+	Anomalies = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "anomalies_total",
+		Help:      "Crossed books, stale orders and impossible messages found by the integrity checks.",
+	}, []string{"product", "kind"})
+
+	Reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconnects_total",
+		Help:      "Websocket reconnects after the feed failed.",
+	}, []string{"product"})
+
+	WatchdogTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "watchdog_trips_total",
+		Help:      "Connections dropped because heartbeats stopped or the sequence stalled.",
+	}, []string{"product"})
+
+	Arbitrage = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "arbitrage_opportunities_total",
+		Help:      "Times one venue's best bid rose above another venue's best ask in a consolidated book.",
+	}, []string{"product", "bid_venue", "ask_venue"})
+
+	ErrorReports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "error_reports_total",
+		Help:      "Errors handed to the error reporter, by whether they were queued or dropped and why.",
+	}, []string{"result"})
+
+	ConsumerQueueLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_queue_lag",
+		Help:      "Updates buffered by a consumer that it has not processed yet.",
+	}, []string{"product", "consumer"})
+
+	BookDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "book_depth",
+		Help:      "Total resting size per side of the book.",
+	}, []string{"product", "side"})
+
+	BookLevels = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "book_levels",
+		Help:      "Number of price levels per side of the book.",
+	}, []string{"product", "side"})
+
+	BestPrice = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "best_price",
+		Help:      "Best bid and best ask price. Absent while the side is empty.",
+	}, []string{"product", "side"})
+)
+
+func init() {
+	prometheus.MustRegister(Messages, ApplyLatency, ExchangeLatency, Resyncs, SequenceGaps, GapSize, DuplicateMessages, Anomalies, Reconnects, WatchdogTrips,
+		Arbitrage, ErrorReports, ConsumerQueueLag, BookDepth, BookLevels, BestPrice)
+}
+
+// Serve exposes the registered metrics at /metrics. It blocks like http.ListenAndServe.
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,133 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Adapter keeps a Book in sync with one venue's feed for one product and reports every change
+// it makes as an Event.
+type Adapter interface {
+	Venue() string
+	Book() *Book
+	AddEventListener(listener EventListener)
+	// Run follows the feed. Live adapters reconnect when it fails and never return.
+	Run()
+}
+
+// Decoder turns one line of a venue's recorded feed into events.
+type Decoder func(line []byte) ([]Event, error)
+
+// DecodeEvent reads lines holding a single JSON encoded Event, as written by EventWriter.
+func DecodeEvent(line []byte) ([]Event, error) {
+	event := Event{}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshal event")
+	}
+	return []Event{event}, nil
+}
+
+// RecordedAdapter feeds a book from a recorded file, which is how a venue can be added and
+// checked before it has a live connection.
+type RecordedAdapter struct {
+	venue     string
+	book      *Book
+	path      string
+	decode    Decoder
+	listeners []EventListener
+	err       error
+}
+
+func NewRecordedAdapter(venue string, book *Book, path string, decode Decoder) *RecordedAdapter {
+	return &RecordedAdapter{venue: venue, book: book, path: path, decode: decode, listeners: []EventListener{}}
+}
+
+func (adapter *RecordedAdapter) Venue() string {
+	return adapter.venue
+}
+
+func (adapter *RecordedAdapter) Book() *Book {
+	return adapter.book
+}
+
+func (adapter *RecordedAdapter) AddEventListener(listener EventListener) {
+	adapter.listeners = append(adapter.listeners, listener)
+}
+
+// Run applies the whole file and returns. Err reports what stopped it early.
+func (adapter *RecordedAdapter) Run() {
+	file, err := os.Open(adapter.path)
+	if err != nil {
+		adapter.err = errors.Wrap(err, "Could not open recorded feed")
+		return
+	}
+	defer file.Close()
+	adapter.err = adapter.Replay(file)
+	if adapter.err != nil {
+		zap.L().Error("Could not replay recorded feed", zap.String("venue", adapter.venue), zap.String("path", adapter.path), zap.Error(adapter.err))
+	}
+}
+
+func (adapter *RecordedAdapter) Err() error {
+	return adapter.err
+}
+
+// Replay applies every line read from r.
+func (adapter *RecordedAdapter) Replay(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		events, err := adapter.decode(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			event.Venue = adapter.venue
+			adapter.book.Lock()
+			err = adapter.book.Apply(event)
+			adapter.book.Unlock()
+			if err != nil {
+				return errors.Wrapf(err, "Could not apply %s event %d", event.Type, event.Sequence)
+			}
+			for _, listener := range adapter.listeners {
+				listener.Event(event)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// EventWriter records events as JSON lines that DecodeEvent reads back.
+type EventWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{encoder: json.NewEncoder(w)}
+}
+
+func (writer *EventWriter) Event(event Event) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if writer.err == nil {
+		writer.err = writer.encoder.Encode(event)
+	}
+}
+
+// Err reports the first write that failed. Later events are dropped.
+func (writer *EventWriter) Err() error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.err
+}
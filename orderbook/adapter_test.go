@@ -0,0 +1,58 @@
+package orderbook_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+)
+
+// sameOrders compares two books order by order, in queue order.
+func sameOrders(t *testing.T, got *orderbook.Book, want *orderbook.Book) {
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		gotOrders, wantOrders := got.GetOrders(side), want.GetOrders(side)
+		if len(gotOrders) != len(wantOrders) {
+			t.Fatalf("%s side has %d orders, want %d", common.ToString(side), len(gotOrders), len(wantOrders))
+		}
+		for i, order := range wantOrders {
+			if gotOrders[i].ID != order.ID || !gotOrders[i].Size.Equal(order.Size) || !gotOrders[i].Price.Equal(order.Price) {
+				t.Fatalf("order %d is %s, want %s", i, gotOrders[i].ToString(), order.ToString())
+			}
+		}
+	}
+}
+
+func TestRecordedAdapterReplaysCoinbaseEvents(t *testing.T) {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	live := orderbook.NewBook(config.ProductID, nil)
+	handler := gdax.NewHandler(nil, live, nil)
+	recording := bytes.Buffer{}
+	writer := orderbook.NewEventWriter(&recording)
+	handler.AddEventListener(writer)
+	for _, message := range append(generator.Generate(10000), generator.Flush()...) {
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := orderbook.NewBook(config.ProductID, nil)
+	adapter := orderbook.NewRecordedAdapter("recorded", replayed, "", orderbook.DecodeEvent)
+	if err := adapter.Replay(&recording); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Sequence != live.Sequence {
+		t.Fatalf("replayed book is at sequence %d, want %d", replayed.Sequence, live.Sequence)
+	}
+	sameOrders(t, replayed, live)
+	if err := replayed.CheckUncrossed(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,43 @@
+package orderbook
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/shopspring/decimal"
+)
+
+// An aggregated book, built from a feed of price levels rather than orders, holds each level as
+// a single order so depth queries and consumers work the same as for a full book.
+
+// levelOrderID names the order that stands for a whole level in an aggregated book.
+func levelOrderID(side common.Side, price decimal.Decimal) string {
+	return common.ToString(side) + "@" + price.String()
+}
+
+// SetLevel sets the total size at a price in an aggregated book, removing the level when size is zero.
+func (b *Book) SetLevel(side common.Side, price decimal.Decimal, size decimal.Decimal, updated time.Time) error {
+	return b.setLevelOrder(levelOrderID(side, price), side, price, size, updated)
+}
+
+// setLevelOrder replaces the order that stands for a level, or a venue's share of one.
+func (b *Book) setLevelOrder(id string, side common.Side, price decimal.Decimal, size decimal.Decimal, updated time.Time) error {
+	if _, found := b.orders[id]; found {
+		b.Remove(&Order{ID: id, Price: price, Side: side})
+	}
+	if !size.IsPositive() {
+		return nil
+	}
+	return b.Add(&Order{ID: id, Price: price, Size: size, Side: side, Time: updated})
+}
+
+// SetTop replaces a side of a book that only follows the best prices. A feed without sizes
+// leaves a level of size zero, so the best price is still known.
+func (b *Book) SetTop(side common.Side, price decimal.Decimal, size decimal.Decimal, updated time.Time) error {
+	for _, level := range b.bookSide(side).GetLevels(0) {
+		for _, order := range level.GetOrders() {
+			b.Remove(order)
+		}
+	}
+	return b.Add(&Order{ID: levelOrderID(side, price), Price: price, Size: size, Side: side, Time: updated})
+}
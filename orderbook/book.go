@@ -0,0 +1,391 @@
+package orderbook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/report"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// maxRecentTrades bounds how many matches a Book keeps for trade queries.
+const maxRecentTrades = 100
+
+var (
+	// ErrImpossibleChange is the cause of Change errors for changes the book cannot apply.
+	ErrImpossibleChange = errors.New("change is inconsistent with the book")
+	// ErrOverfill is the cause of Match errors for fills larger than the resting order.
+	ErrOverfill = errors.New("fill is larger than the resting order")
+	// ErrUnknownMaker is the cause of Match errors for makers that are not first at their level.
+	ErrUnknownMaker = errors.New("maker is not the first order at its level")
+	// ErrOffGrid is the cause of Add, Change and Match errors for orders and sizes a fixed-point
+	// book cannot hold because they are not a multiple of its tick or lot.
+	ErrOffGrid = errors.New("order is off the tick and lot grid")
+)
+
+// Book is written by a single adapter goroutine. The adapter holds Lock while it applies
+// an event, so other goroutines must read through the Snapshot methods which take a read lock.
+//
+// A Book built with NewFixedBook keys levels by int64 price ticks and sums sizes in int64 lots.
+// Orders and levels still carry decimals, so readers see the same values in either mode.
+type Book struct {
+	ID           string
+	Bid          *BookSide
+	Ask          *BookSide
+	Trades       []*Order
+	RecentTrades []Trade
+	Sequence     int64
+	Updated      time.Time
+
+	orders   map[string]*Order
+	scale    *common.Scale
+	reporter report.ErrorReporter
+	mu       sync.RWMutex
+}
+
+// NewBook creates an empty book. Orders it cannot apply go to reporter, which may be nil.
+func NewBook(id string, reporter report.ErrorReporter) *Book {
+	b := &Book{
+		ID:           id,
+		Trades:       []*Order{},
+		RecentTrades: []Trade{},
+		orders:       map[string]*Order{},
+		reporter:     report.OrNop(reporter),
+	}
+	b.Clear()
+	return b
+}
+
+// NewFixedBook creates a book that works in ticks and lots of the given scale, usually the
+// product's quote_increment and base_increment.
+func NewFixedBook(id string, scale common.Scale, reporter report.ErrorReporter) *Book {
+	b := NewBook(id, reporter)
+	b.scale = &scale
+	b.Clear()
+	return b
+}
+
+// Scale returns the tick and lot sizes of a fixed-point book.
+func (b *Book) Scale() (common.Scale, bool) {
+	if b.scale == nil {
+		return common.Scale{}, false
+	}
+	return *b.scale, true
+}
+
+func (b *Book) Clear() {
+	if b.scale != nil {
+		b.Bid = NewFixedBookSide(common.BidSide, b.scale)
+		b.Ask = NewFixedBookSide(common.AskSide, b.scale)
+	} else {
+		b.Bid = NewBookSide(common.BidSide)
+		b.Ask = NewBookSide(common.AskSide)
+	}
+	b.orders = map[string]*Order{}
+	b.Sequence = 0
+}
+
+// GetOrder finds a resting order by ID alone.
+func (b *Book) GetOrder(id string) (*Order, bool) {
+	order, found := b.orders[id]
+	return order, found
+}
+
+func (b *Book) NumOrders() int {
+	return len(b.orders)
+}
+
+// Lock is held by the adapter while it applies an event.
+func (b *Book) Lock() {
+	b.mu.Lock()
+}
+
+func (b *Book) Unlock() {
+	b.mu.Unlock()
+}
+
+// Add rests an order on the book. A fixed-point book rejects orders off its grid with ErrOffGrid,
+// since leaving them out would make it diverge from the venue's.
+func (b *Book) Add(order *Order) error {
+	if err := b.prepare(order); err != nil {
+		zap.L().Error("Could not add order", zap.String("order", order.ToString()), zap.Error(err))
+		b.reporter.Report(errors.Wrap(err, "Could not add order"), map[string]string{"product": b.ID})
+		return err
+	}
+	// an open for an order that is already resting replaces it rather than leaving it orphaned
+	if resting, found := b.orders[order.ID]; found {
+		zap.L().Warn("Order opened twice", zap.String("order", order.ToString()), zap.String("resting", resting.ToString()))
+		b.Remove(resting)
+	}
+	level, found := b.findLevel(order)
+	if found {
+		level.Add(order)
+	} else {
+		b.addLevel(order)
+	}
+	b.orders[order.ID] = order
+	return nil
+}
+
+// prepare fills in the tick and lot fields of an order for a fixed-point book.
+func (b *Book) prepare(order *Order) error {
+	if b.scale == nil {
+		return nil
+	}
+	ticks, err := b.scale.Ticks(order.Price)
+	if err != nil {
+		return errors.Wrapf(ErrOffGrid, "price %s is not a multiple of the price tick", order.Price.String())
+	}
+	lots, err := b.scale.Lots(order.Size)
+	if err != nil {
+		return errors.Wrapf(ErrOffGrid, "size %s is not a multiple of the size lot", order.Size.String())
+	}
+	order.PriceTicks, order.SizeLots = ticks, lots
+	return nil
+}
+
+// checkSize rejects a message size off the lot grid of a fixed-point book, before it can reach
+// a level total.
+func (b *Book) checkSize(size decimal.Decimal) error {
+	if b.lots(size) < 0 {
+		return errors.Wrapf(ErrOffGrid, "size %s is not a multiple of the size lot", size.String())
+	}
+	return nil
+}
+
+// lots converts a message size for a fixed-point book. A size off the lot grid returns -1 so
+// it can never equal a resting order.
+func (b *Book) lots(size decimal.Decimal) int64 {
+	if b.scale == nil {
+		return 0
+	}
+	lots, err := b.scale.Lots(size)
+	if err != nil {
+		return -1
+	}
+	return lots
+}
+
+// findLevel looks the level of a prepared order up by ticks when the book is fixed-point.
+func (b *Book) findLevel(order *Order) (*BookLevel, bool) {
+	if b.scale == nil {
+		return b.FindLevel(order.Price, order.Side)
+	}
+	if order.Side == common.BidSide {
+		return b.Bid.GetBookLevelTicks(order.PriceTicks)
+	}
+	return b.Ask.GetBookLevelTicks(order.PriceTicks)
+}
+
+// Remove takes a resting order off the book and reports whether it was resting. Done messages
+// for orders that never rested, or that the book has not seen, remove nothing.
+func (b *Book) Remove(order *Order) (bool, error) {
+	// the resting order knows its level, so look it up rather than search by the message price
+	resting, found := b.orders[order.ID]
+	if !found {
+		zap.L().Debug("Remove for unknown id", zap.String("order", order.ToString()))
+		return false, nil
+	}
+	order = resting
+	level := order.level
+	if level == nil {
+		zap.L().Debug("Remove for unknown level", zap.String("order", order.ToString()))
+		return false, nil
+	}
+
+	err := level.RemoveOrder(order)
+	if err != nil {
+		return false, fmt.Errorf("Could not remove order %v", order.ToString())
+	}
+	delete(b.orders, order.ID)
+
+	if level.Empty() {
+		b.removeLevel(order)
+	}
+	return true, nil
+}
+
+func (b *Book) FindLevel(price decimal.Decimal, side common.Side) (*BookLevel, bool) {
+	if side == common.BidSide {
+		return b.Bid.GetBookLevel(price)
+	} else {
+		return b.Ask.GetBookLevel(price)
+	}
+
+	return &BookLevel{}, false
+}
+
+// Match fills the order at the front of the maker's level.
+func (b *Book) Match(trade Trade) error {
+	if err := b.checkSize(trade.Size); err != nil {
+		return errors.Wrapf(err, "match for maker %s", trade.MakerOrderID)
+	}
+	b.AddTrade(trade)
+	bl, ok := b.FindLevel(trade.Price, trade.MakerSide)
+	if !ok {
+		return errors.Wrapf(ErrUnknownMaker, "no level at %s for maker %s", trade.Price.String(), trade.MakerOrderID)
+	}
+	order, err := bl.GetFirstOrder()
+	if err != nil {
+		return errors.Wrapf(ErrUnknownMaker, "level at %s is empty for maker %s", trade.Price.String(), trade.MakerOrderID)
+	}
+	if order.ID != trade.MakerOrderID {
+		return errors.Wrapf(ErrUnknownMaker, "maker %s is behind %s at %s", trade.MakerOrderID, order.ID, trade.Price.String())
+	}
+
+	switch b.compareSize(order, trade.Size) {
+	case 0:
+		b.Remove(order)
+		return nil
+	case -1:
+		return errors.Wrapf(ErrOverfill, "match of %s for order %s", trade.Size.String(), order.ToString())
+	}
+	// a partial fill leaves the maker at the front of the level with what remains
+	bl.Resize(order, order.Size.Sub(trade.Size), order.SizeLots-b.lots(trade.Size))
+	return nil
+}
+
+// Change resizes a resting order in place, keeping its queue position.
+func (b *Book) Change(orderID string, oldSize decimal.Decimal, newSize decimal.Decimal) error {
+	for _, size := range []decimal.Decimal{oldSize, newSize} {
+		if err := b.checkSize(size); err != nil {
+			return errors.Wrapf(err, "change of order %s", orderID)
+		}
+	}
+	order, found := b.orders[orderID]
+	if !found {
+		return errors.Wrapf(ErrImpossibleChange, "order %s is not on the book", orderID)
+	}
+	if b.compareSize(order, oldSize) != 0 {
+		return errors.Wrapf(ErrImpossibleChange, "old size %s does not match order %s", oldSize.String(), order.ToString())
+	}
+	if !newSize.IsPositive() {
+		return errors.Wrapf(ErrImpossibleChange, "new size %s of order %s is not positive", newSize.String(), order.ID)
+	}
+	order.level.Resize(order, newSize, b.lots(newSize))
+	return nil
+}
+
+// compareSize compares a resting order's size with a message size, in lots for a fixed-point book.
+func (b *Book) compareSize(order *Order, size decimal.Decimal) int {
+	if b.scale != nil {
+		lots := b.lots(size)
+		if lots < 0 {
+			return -1
+		}
+		if order.SizeLots < lots {
+			return -1
+		} else if order.SizeLots > lots {
+			return 1
+		}
+		return 0
+	}
+	return order.Size.Cmp(size)
+}
+
+func (b *Book) FindOrder(id string, price decimal.Decimal, side common.Side) (*Order, error) {
+	level, ok := b.FindLevel(price, side)
+	if !ok {
+		zap.L().Error("Could not find level", zap.String("price", price.String()))
+		return nil, errors.New("Could not find level")
+	}
+	order, err := level.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("Could not find order (%s) at price (%v) due to error (%s)", id, price, err.Error())
+	}
+	return order, nil
+}
+
+// AddTrade records a trade for trade queries. Match calls it, adapters whose feed has trades but
+// no orders call it directly.
+func (b *Book) AddTrade(trade Trade) {
+	if len(b.RecentTrades) == maxRecentTrades {
+		b.RecentTrades = append(b.RecentTrades[:0], b.RecentTrades[1:]...)
+	}
+	b.RecentTrades = append(b.RecentTrades, trade)
+}
+
+func (b *Book) addLevel(order *Order) {
+	level := newBookLevel(order.Price, order.PriceTicks, b.scale)
+	level.Add(order)
+	b.bookSide(order.Side).levels.insert(level)
+}
+
+func (b *Book) removeLevel(order *Order) {
+	b.bookSide(order.Side).levels.remove(order.Price, order.PriceTicks)
+}
+
+func (b *Book) bookSide(side common.Side) *BookSide {
+	if side == common.BidSide {
+		return b.Bid
+	}
+	return b.Ask
+}
+
+func (b *Book) PrintTopFive() {
+	askString, bidString := b.GetTopFive()
+	zap.L().Info(bidString)
+	zap.L().Info(askString)
+}
+
+func (b *Book) GetTopFive() (string, string) {
+	asks := []string{}
+	bids := []string{}
+	for _, level := range b.Ask.GetLevels(5) {
+		asks = append(asks, fmt.Sprintf("[Price: %s Size: %s Orders: %v]", level.Price.String(), level.GetSize().String(), level.GetNumOrders()))
+	}
+	for _, level := range b.Bid.GetLevels(5) {
+		bids = append(bids, fmt.Sprintf("[Price: %s Size: %s Orders: %v]", level.Price.String(), level.GetSize().String(), level.GetNumOrders()))
+	}
+	askString := "Asks: " + strings.Join(asks, " ")
+	bidString := "Bids: " + strings.Join(bids, " ")
+	return askString, bidString
+}
+
+// MarkOwn flags a resting order as one of ours so depth views can separate our liquidity.
+func (b *Book) MarkOwn(id string, price decimal.Decimal, side common.Side) bool {
+	order, found := b.orders[id]
+	if !found || order.Side != side || !order.Price.Equal(price) {
+		return false
+	}
+	order.Own = true
+	return true
+}
+
+type DepthLevel struct {
+	Price     decimal.Decimal
+	Size      decimal.Decimal
+	NumOrders int
+	OwnSize   decimal.Decimal
+	OwnOrders int
+}
+
+// GetDepth aggregates the top levels of a side, best price first, reporting our own resting size separately.
+func (b *Book) GetDepth(side common.Side, levels int) []DepthLevel {
+	bookSide := b.Ask
+	if side == common.BidSide {
+		bookSide = b.Bid
+	}
+	depth := []DepthLevel{}
+	for _, level := range bookSide.GetLevels(levels) {
+		depthLevel := DepthLevel{
+			Price:     level.Price,
+			Size:      level.GetSize(),
+			NumOrders: level.GetNumOrders(),
+			OwnSize:   decimal.New(0, 0),
+		}
+		for _, order := range level.GetOrders() {
+			if order.Own {
+				depthLevel.OwnSize = depthLevel.OwnSize.Add(order.Size)
+				depthLevel.OwnOrders += 1
+			}
+		}
+		depth = append(depth, depthLevel)
+	}
+	return depth
+}
@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/shopspring/decimal"
+)
+
+// BookLevel keeps its total in Size, or in SizeLots when it belongs to a fixed-point book.
+// Read it through GetSize either way.
+type BookLevel struct {
+	Price      decimal.Decimal
+	PriceTicks int64
+	Size       decimal.Decimal
+	SizeLots   int64
+
+	orders orderQueue
+	scale  *common.Scale
+}
+
+func newBookLevel(price decimal.Decimal, ticks int64, scale *common.Scale) *BookLevel {
+	return &BookLevel{Price: price, PriceTicks: ticks, Size: decimal.New(0, 0), scale: scale}
+}
+
+func (bl *BookLevel) Add(order *Order) {
+	bl.orders.pushBack(order)
+	order.level = bl
+	if bl.scale != nil {
+		bl.SizeLots += order.SizeLots
+		return
+	}
+	bl.Size = bl.Size.Add(order.Size)
+}
+
+// Resize changes the size of an order resting at this level and keeps the level total in step.
+func (bl *BookLevel) Resize(order *Order, size decimal.Decimal, lots int64) {
+	if bl.scale != nil {
+		bl.SizeLots += lots - order.SizeLots
+	} else {
+		bl.Size = bl.Size.Add(size.Sub(order.Size))
+	}
+	order.Size = size
+	order.SizeLots = lots
+}
+
+func (bl *BookLevel) Remove(id string) error {
+	order, err := bl.Get(id)
+	if err != nil {
+		return err
+	}
+	return bl.RemoveOrder(order)
+}
+
+// RemoveOrder removes a resting order without searching the level for it.
+func (bl *BookLevel) RemoveOrder(order *Order) error {
+	if order.level != bl {
+		return fmt.Errorf("Order %s is not at level %s", order.ID, bl.Price.String())
+	}
+	bl.orders.remove(order)
+	order.level = nil
+	if bl.scale != nil {
+		bl.SizeLots -= order.SizeLots
+		return nil
+	}
+	bl.Size = bl.Size.Sub(order.Size)
+	return nil
+}
+
+func (bl *BookLevel) GetSize() decimal.Decimal {
+	if bl.scale != nil {
+		return bl.scale.Size(bl.SizeLots)
+	}
+	return bl.Size
+}
+
+func (bl *BookLevel) GetNumOrders() int {
+	return bl.orders.size
+}
+
+func (bl *BookLevel) Empty() bool {
+	return bl.orders.size == 0
+}
+
+func (bl *BookLevel) Get(orderID string) (*Order, error) {
+	order := bl.orders.find(orderID)
+	if order == nil {
+		return nil, fmt.Errorf("OrderID not found")
+	}
+	return order, nil
+}
+
+func (bl *BookLevel) Has(orderID string) bool {
+	return bl.orders.find(orderID) != nil
+}
+
+func (bl *BookLevel) GetFirstOrder() (*Order, error) {
+	if bl.orders.head == nil {
+		return nil, fmt.Errorf("book level is empty")
+	}
+	return bl.orders.head, nil
+}
+
+func (bl *BookLevel) GetOrders() []*Order {
+	orders := make([]*Order, 0, bl.orders.size)
+	for order := bl.orders.head; order != nil; order = order.next {
+		orders = append(orders, order)
+	}
+	return orders
+}
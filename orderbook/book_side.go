@@ -0,0 +1,123 @@
+package orderbook
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// BookSide keeps its levels in a price ladder, compared by int64 ticks when it has a scale.
+type BookSide struct {
+	levels priceLadder
+	side   common.Side
+	scale  *common.Scale
+}
+
+func NewBookSide(side common.Side) *BookSide {
+	return &BookSide{levels: priceLadder{ask: side == common.AskSide}, side: side}
+}
+
+// NewFixedBookSide compares level prices as ticks so lookups compare integers instead of decimals.
+func NewFixedBookSide(side common.Side, scale *common.Scale) *BookSide {
+	return &BookSide{levels: priceLadder{ask: side == common.AskSide, fixed: true}, side: side, scale: scale}
+}
+
+// ticks converts a price for a fixed-point side. A price off the tick grid can never match a level.
+func (bookSide *BookSide) ticks(price decimal.Decimal) (int64, bool) {
+	if bookSide.scale == nil {
+		return 0, true
+	}
+	ticks, err := bookSide.scale.Ticks(price)
+	if err != nil {
+		return 0, false
+	}
+	return ticks, true
+}
+
+func (bookSide *BookSide) HasBookLevel(price decimal.Decimal) bool {
+	_, found := bookSide.GetBookLevel(price)
+	return found
+}
+
+func (bookSide *BookSide) AddBookLevel(price decimal.Decimal, level *BookLevel) error {
+	ticks, ok := bookSide.ticks(price)
+	if !ok {
+		return fmt.Errorf("BookSide %s cannot add book level %s as it is not a multiple of the price tick", common.ToString(bookSide.side), price.String())
+	}
+	level.Price, level.PriceTicks = price, ticks
+	if !bookSide.levels.insert(level) {
+		return fmt.Errorf("BookSide %s already has book level %s", common.ToString(bookSide.side), price.String())
+	}
+	return nil
+}
+
+func (bookSide *BookSide) GetBookLevel(price decimal.Decimal) (*BookLevel, bool) {
+	ticks, ok := bookSide.ticks(price)
+	if !ok {
+		return nil, false
+	}
+	return bookSide.levels.get(price, ticks)
+}
+
+// GetBookLevelTicks looks a level up by its ticks. Only valid on a fixed-point side.
+func (bookSide *BookSide) GetBookLevelTicks(ticks int64) (*BookLevel, bool) {
+	return bookSide.levels.get(decimal.Decimal{}, ticks)
+}
+
+func (bookSide *BookSide) RemoveBookLevel(price decimal.Decimal) error {
+	ticks, ok := bookSide.ticks(price)
+	if !ok || !bookSide.levels.remove(price, ticks) {
+		return fmt.Errorf("BookSide %s could not remove book level as book level at price %s does not exist", common.ToString(bookSide.side), price.String())
+	}
+	return nil
+}
+
+func (bookSide *BookSide) NumLevels() int {
+	return len(bookSide.levels.levels)
+}
+
+func (bookSide *BookSide) GetTopLevel() (*BookLevel, error) {
+	level, ok := bookSide.levels.best()
+	if !ok {
+		return nil, fmt.Errorf("BookSide %s is empty. Tried to get top level", common.ToString(bookSide.side))
+	}
+	return level, nil
+}
+
+func (bookSide *BookSide) FindOrder(id string, price decimal.Decimal) (*Order, error) {
+	level, ok := bookSide.GetBookLevel(price)
+	if !ok {
+		zap.L().Error("Could not find level", zap.String("price", price.String()))
+		return nil, errors.New("Could not find level")
+	}
+	order, err := level.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("Could not find order (%s) at price (%v) due to error (%s)", id, price, err.Error())
+	}
+	return order, nil
+
+}
+
+// GetLevels returns up to n levels ordered from the best price outwards. n <= 0 returns every level.
+func (bookSide *BookSide) GetLevels(n int) []*BookLevel {
+	all := bookSide.levels.levels
+	if n <= 0 || n > len(all) {
+		n = len(all)
+	}
+	levels := make([]*BookLevel, 0, n)
+	for i := len(all) - 1; i >= len(all)-n; i-- {
+		levels = append(levels, all[i])
+	}
+	return levels
+}
+
+func (bookSide *BookSide) GetTotalSize() decimal.Decimal {
+	total := decimal.New(0, 0)
+	for _, level := range bookSide.levels.levels {
+		total = total.Add(level.GetSize())
+	}
+	return total
+}
@@ -0,0 +1,235 @@
+package orderbook_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	mathRand "math/rand"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/journal"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	journalDir = flag.String("journal", "", "replay this journal directory in the session benchmarks instead of a generated session")
+	product    = flag.String("product", "BTC-USD", "product to replay from -journal")
+)
+
+const benchOrders = 50000
+
+func newUUID() string {
+	var raw [16]byte
+	rand.Read(raw[:])
+	return fmt.Sprintf("%x-%x-%x-%x-%x", raw[0:4], raw[4:6], raw[6:8], raw[8:10], raw[10:16])
+}
+
+// bookOp is a feed message already parsed into what the handler passes to the book, so the
+// apply benchmarks measure the book and not JSON or decimal parsing.
+type bookOp struct {
+	kind   string
+	order  orderbook.Order
+	change gdax.Change
+	match  orderbook.Trade
+}
+
+func newBookOps(messages []gdaxClient.Message) ([]bookOp, error) {
+	ops := make([]bookOp, 0, len(messages))
+	for _, message := range messages {
+		op := bookOp{kind: message.Type}
+		switch message.Type {
+		case gdax.SnapshotStartMessageType:
+			op.kind = "clear"
+		case "open", "done":
+			order, err := gdax.NewOrder(message.OrderId, message.RemainingSize, message.Price, message.Side)
+			if err != nil {
+				return nil, err
+			}
+			op.order = *order
+		case "change":
+			change, err := gdax.NewChangeMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.NewSize,
+				message.OldSize, message.NewFunds, message.OldFunds, message.Price, message.Side)
+			if err != nil {
+				return nil, err
+			}
+			op.change = change
+		case "match":
+			match, err := gdax.NewMatchMessage(message.TradeId, message.Sequence, message.MakerOrderId, message.TakerOrderId,
+				message.Time, message.ProductId, message.Size, message.Price, message.Side)
+			if err != nil {
+				return nil, err
+			}
+			op.match = match.Trade()
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func applyBookOps(book *orderbook.Book, ops []bookOp) {
+	for i := range ops {
+		op := &ops[i]
+		switch op.kind {
+		case "clear":
+			book.Clear()
+		case "open":
+			order := op.order
+			book.Add(&order)
+		case "done":
+			book.Remove(&op.order)
+		case "change":
+			book.Change(op.change.OrderID, op.change.OldSize, op.change.NewSize)
+		case "match":
+			book.Match(op.match)
+		}
+	}
+}
+
+// newSessionMessages loads the recorded session from -journal, with its snapshots turned into
+// opens, or else generates a session of resting orders within 2% of 10000.00 followed by a stream.
+func newSessionMessages() ([]gdaxClient.Message, error) {
+	messages := []gdaxClient.Message{}
+	if *journalDir == "" {
+		random := mathRand.New(mathRand.NewSource(1))
+		for i := 0; i < benchOrders; i++ {
+			side, ticks := "buy", int64(1000000-random.Intn(20000)-1)
+			if i%2 == 1 {
+				side, ticks = "sell", int64(1000000+random.Intn(20000)+1)
+			}
+			messages = append(messages, gdaxClient.Message{Type: "open", OrderId: newUUID(), Price: synthetic.DefaultScale.Price(ticks).String(),
+				RemainingSize: synthetic.DefaultScale.Size(int64(random.Intn(500000000) + 1)).String(), Side: side})
+		}
+		return append(messages, synthetic.Stream(synthetic.BenchmarkEvents)...), nil
+	}
+	err := journal.Read(*journalDir, *product, func(line []byte) error {
+		message := gdaxClient.Message{}
+		if err := json.Unmarshal(line, &message); err != nil {
+			return err
+		}
+		if message.Type == gdax.SnapshotMessageType {
+			side := "buy"
+			if message.Side == common.ToString(common.AskSide) {
+				side = "sell"
+			}
+			message = gdaxClient.Message{Type: "open", OrderId: message.OrderId, Price: message.Price, RemainingSize: message.Size, Side: side}
+		}
+		messages = append(messages, message)
+		return nil
+	})
+	return messages, err
+}
+
+func TestFixedBookMatchesDecimalBook(t *testing.T) {
+	ops, err := newBookOps(synthetic.Stream(20000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalBook, fixedBook := orderbook.NewBook("BTC-USD", nil), orderbook.NewFixedBook("BTC-USD", synthetic.DefaultScale, nil)
+	applyBookOps(decimalBook, ops)
+	applyBookOps(fixedBook, ops)
+	if decimalBook.NumOrders() != fixedBook.NumOrders() {
+		t.Fatalf("decimal book has %d orders, fixed book %d", decimalBook.NumOrders(), fixedBook.NumOrders())
+	}
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		decimalDepth, fixedDepth := decimalBook.GetDepth(side, 0), fixedBook.GetDepth(side, 0)
+		if len(decimalDepth) != len(fixedDepth) {
+			t.Fatalf("%s has %d decimal levels, %d fixed levels", common.ToString(side), len(decimalDepth), len(fixedDepth))
+		}
+		for i := range decimalDepth {
+			if !decimalDepth[i].Price.Equal(fixedDepth[i].Price) || !decimalDepth[i].Size.Equal(fixedDepth[i].Size) ||
+				decimalDepth[i].NumOrders != fixedDepth[i].NumOrders {
+				t.Fatalf("%s level %d differs: decimal %v fixed %v", common.ToString(side), i, decimalDepth[i], fixedDepth[i])
+			}
+		}
+	}
+}
+
+func TestFixedBookRejectsOffGridOrders(t *testing.T) {
+	book := orderbook.NewFixedBook("BTC-USD", synthetic.DefaultScale, nil)
+	order, err := gdax.NewOrder(newUUID(), "1", "100.005", "buy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Add(order); err == nil {
+		t.Fatalf("adding an order priced off the 0.01 grid succeeded")
+	}
+	if book.NumOrders() != 0 {
+		t.Fatalf("book has %d orders after rejecting the only one", book.NumOrders())
+	}
+}
+
+func TestFixedBookRejectsOffGridChangesAndMatches(t *testing.T) {
+	book := orderbook.NewFixedBook("BTC-USD", synthetic.DefaultScale, nil)
+	order, err := gdax.NewOrder(newUUID(), "1", "100.00", "sell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Add(order); err != nil {
+		t.Fatal(err)
+	}
+	offGrid := decimal.RequireFromString("0.000000001")
+	if err := book.Change(order.ID, order.Size, offGrid); errors.Cause(err) != orderbook.ErrOffGrid {
+		t.Fatalf("off-grid change returned %v, want ErrOffGrid", err)
+	}
+	if err := book.Change(order.ID, offGrid, decimal.New(2, 0)); errors.Cause(err) != orderbook.ErrOffGrid {
+		t.Fatalf("change from an off-grid old size returned %v, want ErrOffGrid", err)
+	}
+	trade := orderbook.Trade{MakerOrderID: order.ID, Price: order.Price, Size: offGrid, MakerSide: common.AskSide}
+	if err := book.Match(trade); errors.Cause(err) != orderbook.ErrOffGrid {
+		t.Fatalf("off-grid match returned %v, want ErrOffGrid", err)
+	}
+	if len(book.RecentTrades) != 0 {
+		t.Fatalf("off-grid match was recorded as a trade")
+	}
+	if err := book.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	if depth := book.GetDepth(common.AskSide, 0); len(depth) != 1 || !depth[0].Size.Equal(decimal.New(1, 0)) {
+		t.Fatalf("ask depth is %v after rejecting off-grid messages, want 1 at 100.00", depth)
+	}
+}
+
+// BenchmarkBookApply compares applying the same stream to a decimal book and a fixed-point book.
+func BenchmarkBookApply(b *testing.B) {
+	ops, err := newBookOps(synthetic.Stream(synthetic.BenchmarkEvents))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) { benchmarkBookOps(b, mode.NewBook, ops) })
+	}
+}
+
+// BenchmarkBookSession applies a whole session, a book full of resting orders and then a stream,
+// or the session recorded in the directory passed with -args -journal.
+func BenchmarkBookSession(b *testing.B) {
+	messages, err := newSessionMessages()
+	if err != nil {
+		b.Fatal(err)
+	}
+	ops, err := newBookOps(messages)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) { benchmarkBookOps(b, mode.NewBook, ops) })
+	}
+}
+
+func benchmarkBookOps(b *testing.B, newBook func() *orderbook.Book, ops []bookOp) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		applyBookOps(newBook(), ops)
+	}
+	b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*len(ops)), "ns/msg")
+}
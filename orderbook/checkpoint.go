@@ -0,0 +1,184 @@
+package orderbook
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const CheckpointVersion = 1
+
+const (
+	historySuffix     = ".checkpoint.json.gz"
+	historyTimeFormat = "20060102T150405.000000000Z"
+)
+
+type CheckpointOrder struct {
+	ID    string          `json:"id"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+// Checkpoint is every resting order of a book at Sequence. Orders are listed best price
+// first and in arrival order within a level so restoring them keeps queue priority. Updated is
+// when the book last changed, which older checkpoints do not record.
+type Checkpoint struct {
+	Version   int               `json:"version"`
+	ProductID string            `json:"product_id"`
+	Sequence  int64             `json:"sequence"`
+	Created   time.Time         `json:"created"`
+	Updated   time.Time         `json:"updated,omitempty"`
+	Bids      []CheckpointOrder `json:"bids"`
+	Asks      []CheckpointOrder `json:"asks"`
+}
+
+func CheckpointPath(dir string, product string) string {
+	return filepath.Join(dir, product+".checkpoint.json.gz")
+}
+
+// Checkpoint must be called with the book locked, e.g. from View.
+func (b *Book) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Version:   CheckpointVersion,
+		ProductID: b.ID,
+		Sequence:  b.Sequence,
+		Created:   time.Now(),
+		Updated:   b.Updated,
+		Bids:      newCheckpointOrders(b.GetOrders(common.BidSide)),
+		Asks:      newCheckpointOrders(b.GetOrders(common.AskSide)),
+	}
+}
+
+func newCheckpointOrders(orders []*Order) []CheckpointOrder {
+	checkpointOrders := make([]CheckpointOrder, 0, len(orders))
+	for _, order := range orders {
+		checkpointOrders = append(checkpointOrders, CheckpointOrder{ID: order.ID, Price: order.Price, Size: order.Size})
+	}
+	return checkpointOrders
+}
+
+// Restore replaces the contents of the book with the checkpoint. The caller must hold the book's lock.
+func (b *Book) Restore(checkpoint Checkpoint) error {
+	if checkpoint.Version != CheckpointVersion {
+		return fmt.Errorf("Checkpoint version %d is not supported", checkpoint.Version)
+	}
+	if checkpoint.ProductID != b.ID {
+		return fmt.Errorf("Checkpoint is for %s not %s", checkpoint.ProductID, b.ID)
+	}
+	b.Clear()
+	for _, order := range checkpoint.Bids {
+		if err := b.Add(&Order{ID: order.ID, Price: order.Price, Size: order.Size, Side: common.BidSide}); err != nil {
+			return errors.Wrap(err, "Could not restore checkpoint")
+		}
+	}
+	for _, order := range checkpoint.Asks {
+		if err := b.Add(&Order{ID: order.ID, Price: order.Price, Size: order.Size, Side: common.AskSide}); err != nil {
+			return errors.Wrap(err, "Could not restore checkpoint")
+		}
+	}
+	b.Sequence = checkpoint.Sequence
+	b.Updated = checkpoint.Time()
+	return nil
+}
+
+// Time is when the book was in the checkpoint's state.
+func (checkpoint Checkpoint) Time() time.Time {
+	if checkpoint.Updated.IsZero() {
+		return checkpoint.Created
+	}
+	return checkpoint.Updated
+}
+
+// WriteCheckpoint writes to a temporary file first so a crash never leaves a partial checkpoint behind.
+func WriteCheckpoint(path string, checkpoint Checkpoint) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.Wrap(err, "Could not create checkpoint file")
+	}
+	writer := gzip.NewWriter(file)
+	if err := json.NewEncoder(writer).Encode(checkpoint); err != nil {
+		file.Close()
+		return errors.Wrap(err, "Could not encode checkpoint")
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "Could not compress checkpoint")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "Could not close checkpoint file")
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	checkpoint := Checkpoint{}
+	file, err := os.Open(path)
+	if err != nil {
+		return checkpoint, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return checkpoint, errors.Wrap(err, "Could not decompress checkpoint")
+	}
+	defer reader.Close()
+	if err := json.NewDecoder(reader).Decode(&checkpoint); err != nil {
+		return checkpoint, errors.Wrap(err, "Could not decode checkpoint")
+	}
+	return checkpoint, nil
+}
+
+// CheckpointInfo describes a kept checkpoint without reading it.
+type CheckpointInfo struct {
+	ProductID string
+	Sequence  int64
+	Time      time.Time
+	Path      string
+}
+
+// WriteHistoryCheckpoint keeps a checkpoint next to the earlier ones of its product, at
+// <dir>/<product>/<sequence>-<time>.checkpoint.json.gz, for historical queries.
+func WriteHistoryCheckpoint(dir string, checkpoint Checkpoint) error {
+	if err := os.MkdirAll(filepath.Join(dir, checkpoint.ProductID), 0755); err != nil {
+		return errors.Wrap(err, "Could not create checkpoint history directory")
+	}
+	name := fmt.Sprintf("%020d-%s%s", checkpoint.Sequence, checkpoint.Time().UTC().Format(historyTimeFormat), historySuffix)
+	return WriteCheckpoint(filepath.Join(dir, checkpoint.ProductID, name), checkpoint)
+}
+
+// ListCheckpoints lists the kept checkpoints of product, oldest first.
+func ListCheckpoints(dir string, product string) ([]CheckpointInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, product, "*"+historySuffix))
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not list checkpoints")
+	}
+	infos := []CheckpointInfo{}
+	for _, path := range paths {
+		parts := strings.SplitN(strings.TrimSuffix(filepath.Base(path), historySuffix), "-", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		sequence, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		at, err := time.Parse(historyTimeFormat, parts[1])
+		if err != nil {
+			continue
+		}
+		infos = append(infos, CheckpointInfo{ProductID: product, Sequence: sequence, Time: at, Path: path})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Sequence < infos[j].Sequence })
+	return infos, nil
+}
@@ -0,0 +1,327 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+	"github.com/shopspring/decimal"
+)
+
+// VenueSize is one venue's share of a consolidated level.
+type VenueSize struct {
+	Venue string
+	Size  decimal.Decimal
+}
+
+// ConsolidatedLevel is the size resting at a price on every venue, with each venue's share.
+type ConsolidatedLevel struct {
+	Price  decimal.Decimal
+	Size   decimal.Decimal
+	Venues []VenueSize
+}
+
+// BBO is the best bid and ask over every venue. A side is nil while no venue quotes it.
+type BBO struct {
+	Bid *ConsolidatedLevel
+	Ask *ConsolidatedLevel
+}
+
+// Quote is one venue's best price on a side.
+type Quote struct {
+	Venue string
+	Price decimal.Decimal
+	Size  decimal.Decimal
+}
+
+// Arbitrage is one venue bidding above another venue's ask. Opened is when the books first crossed.
+type Arbitrage struct {
+	Bid    Quote
+	Ask    Quote
+	Opened time.Time
+}
+
+// Spread is what buying on the ask venue and selling on the bid venue makes per unit.
+func (arbitrage Arbitrage) Spread() decimal.Decimal {
+	return arbitrage.Bid.Price.Sub(arbitrage.Ask.Price)
+}
+
+// Size is how much can be traded at both quoted prices.
+func (arbitrage Arbitrage) Size() decimal.Decimal {
+	return decimal.Min(arbitrage.Bid.Size, arbitrage.Ask.Size)
+}
+
+// ConsolidatedSnapshot is a consistent copy of a Consolidated book.
+type ConsolidatedSnapshot struct {
+	ID        string
+	Sequence  int64
+	Updated   time.Time
+	Venues    []string
+	Bids      []ConsolidatedLevel
+	Asks      []ConsolidatedLevel
+	Arbitrage []Arbitrage
+}
+
+// ArbitrageListener hears about each cross-venue arbitrage when it opens. It is called on the
+// adapter goroutine that delivered the event, so it must not block.
+type ArbitrageListener interface {
+	Arbitrage(arbitrage Arbitrage)
+}
+
+type venuePair struct {
+	bid string
+	ask string
+}
+
+type venueOrder struct {
+	side  common.Side
+	price decimal.Decimal
+	size  decimal.Decimal
+}
+
+// venueBook is what the consolidated book knows of one venue: its size per level, and the
+// orders behind them for venues with an order feed.
+type venueBook struct {
+	levels *Book
+	orders map[string]venueOrder
+}
+
+// Consolidated merges the books of one instrument on several venues into a single ladder. It is
+// an EventListener: add it to every venue's adapter and it follows their events incrementally,
+// without reading or locking the venue books. Venues are told apart by Event.Venue, so products
+// may be named differently on each venue.
+//
+// The ladder is a Book holding one order per venue at each level, so the Snapshot methods and
+// everything that takes a Book work on it. Its sequence counts the events applied.
+type Consolidated struct {
+	book      *Book
+	venues    map[string]*venueBook
+	arbitrage map[venuePair]Arbitrage
+	listeners []ArbitrageListener
+}
+
+func NewConsolidated(id string) *Consolidated {
+	return &Consolidated{
+		book:      NewBook(id, nil),
+		venues:    map[string]*venueBook{},
+		arbitrage: map[venuePair]Arbitrage{},
+		listeners: []ArbitrageListener{},
+	}
+}
+
+// Book is the consolidated ladder. Read it through its Snapshot methods.
+func (c *Consolidated) Book() *Book {
+	return c.book
+}
+
+// AddArbitrageListener must be called before any adapter runs.
+func (c *Consolidated) AddArbitrageListener(listener ArbitrageListener) {
+	c.listeners = append(c.listeners, listener)
+}
+
+// Event applies one venue's event. Adapters on different goroutines may call it concurrently.
+func (c *Consolidated) Event(event Event) {
+	c.book.Lock()
+	c.apply(event)
+	opened := c.checkArbitrage(event.Time)
+	c.book.Unlock()
+	for _, arbitrage := range opened {
+		for _, listener := range c.listeners {
+			listener.Arbitrage(arbitrage)
+		}
+	}
+}
+
+func (c *Consolidated) apply(event Event) {
+	name := event.Venue
+	venue, found := c.venues[name]
+	if !found {
+		venue = &venueBook{levels: NewBook(name, nil), orders: map[string]venueOrder{}}
+		c.venues[name] = venue
+	}
+	switch event.Type {
+	case EventReset:
+		c.clearSide(name, venue, common.BidSide, event.Time)
+		c.clearSide(name, venue, common.AskSide, event.Time)
+		venue.orders = map[string]venueOrder{}
+	case EventOpen:
+		venue.orders[event.OrderID] = venueOrder{side: event.Side, price: event.Price, size: event.Size}
+		c.adjustLevel(name, venue, event.Side, event.Price, event.Size, event.Time)
+	case EventDone:
+		// a done event can be for an order that was never on the book, so trust only what was opened
+		if order, found := venue.orders[event.OrderID]; found {
+			delete(venue.orders, event.OrderID)
+			c.adjustLevel(name, venue, order.side, order.price, order.size.Neg(), event.Time)
+		}
+	case EventChange:
+		if order, found := venue.orders[event.OrderID]; found {
+			venue.orders[event.OrderID] = venueOrder{side: order.side, price: order.price, size: event.Size}
+			c.adjustLevel(name, venue, order.side, order.price, event.Size.Sub(order.size), event.Time)
+		}
+	case EventMatch:
+		if event.Trade == nil {
+			break
+		}
+		id := event.Trade.MakerOrderID
+		if order, found := venue.orders[id]; found {
+			fill := decimal.Min(event.Trade.Size, order.size)
+			if order.size = order.size.Sub(fill); order.size.IsPositive() {
+				venue.orders[id] = order
+			} else {
+				delete(venue.orders, id)
+			}
+			c.adjustLevel(name, venue, order.side, order.price, fill.Neg(), event.Time)
+		}
+	case EventLevel:
+		c.setLevel(name, venue, event.Side, event.Price, event.Size, event.Time)
+	case EventTop:
+		c.clearSide(name, venue, event.Side, event.Time)
+		// like SetTop, a quote without a size still marks the best price
+		venue.levels.SetTop(event.Side, event.Price, event.Size, event.Time)
+		c.book.Add(&Order{ID: venueLevelID(name, event.Side, event.Price), Price: event.Price, Size: event.Size, Side: event.Side, Time: event.Time})
+	}
+	c.book.Sequence += 1
+	if !event.Time.IsZero() {
+		c.book.Updated = event.Time
+	}
+}
+
+// venueLevelID names the order that stands for a venue's share of a consolidated level.
+func venueLevelID(venue string, side common.Side, price decimal.Decimal) string {
+	return venue + "/" + levelOrderID(side, price)
+}
+
+func (c *Consolidated) adjustLevel(name string, venue *venueBook, side common.Side, price decimal.Decimal, delta decimal.Decimal, at time.Time) {
+	size := delta
+	if level, found := venue.levels.FindLevel(price, side); found {
+		size = level.GetSize().Add(delta)
+	}
+	c.setLevel(name, venue, side, price, size, at)
+}
+
+func (c *Consolidated) setLevel(name string, venue *venueBook, side common.Side, price decimal.Decimal, size decimal.Decimal, at time.Time) {
+	venue.levels.SetLevel(side, price, size, at)
+	c.book.setLevelOrder(venueLevelID(name, side, price), side, price, size, at)
+}
+
+func (c *Consolidated) clearSide(name string, venue *venueBook, side common.Side, at time.Time) {
+	for _, level := range venue.levels.bookSide(side).GetLevels(0) {
+		c.setLevel(name, venue, side, level.Price, decimal.New(0, 0), at)
+	}
+}
+
+// bestQuote is a venue's best price on a side.
+func (venue *venueBook) bestQuote(name string, side common.Side) (Quote, bool) {
+	level, err := venue.levels.bookSide(side).GetTopLevel()
+	if err != nil {
+		return Quote{}, false
+	}
+	return Quote{Venue: name, Price: level.Price, Size: level.GetSize()}, true
+}
+
+// checkArbitrage compares every venue's best bid with every other venue's best ask and returns
+// the arbitrage that opened with the last event.
+func (c *Consolidated) checkArbitrage(now time.Time) []Arbitrage {
+	opened := []Arbitrage{}
+	for bidName, bidVenue := range c.venues {
+		bid, hasBid := bidVenue.bestQuote(bidName, common.BidSide)
+		for askName, askVenue := range c.venues {
+			if askName == bidName {
+				continue
+			}
+			pair := venuePair{bid: bidName, ask: askName}
+			ask, hasAsk := askVenue.bestQuote(askName, common.AskSide)
+			if !hasBid || !hasAsk || !bid.Price.GreaterThan(ask.Price) {
+				delete(c.arbitrage, pair)
+				continue
+			}
+			arbitrage, found := c.arbitrage[pair]
+			if !found {
+				arbitrage.Opened = now
+			}
+			arbitrage.Bid, arbitrage.Ask = bid, ask
+			c.arbitrage[pair] = arbitrage
+			if !found {
+				metrics.Arbitrage.WithLabelValues(c.book.ID, bidName, askName).Inc()
+				opened = append(opened, arbitrage)
+			}
+		}
+	}
+	return opened
+}
+
+// Snapshot copies the top levels of the ladder with each venue's share, and the open arbitrage,
+// under the read lock. levels <= 0 copies every level.
+func (c *Consolidated) Snapshot(levels int) ConsolidatedSnapshot {
+	c.book.mu.RLock()
+	defer c.book.mu.RUnlock()
+	snapshot := ConsolidatedSnapshot{
+		ID:        c.book.ID,
+		Sequence:  c.book.Sequence,
+		Updated:   c.book.Updated,
+		Venues:    c.venueNames(),
+		Bids:      c.levels(common.BidSide, levels),
+		Asks:      c.levels(common.AskSide, levels),
+		Arbitrage: c.openArbitrage(),
+	}
+	return snapshot
+}
+
+// BBO is the consolidated best bid and offer.
+func (c *Consolidated) BBO() BBO {
+	snapshot := c.Snapshot(1)
+	bbo := BBO{}
+	if len(snapshot.Bids) > 0 {
+		bbo.Bid = &snapshot.Bids[0]
+	}
+	if len(snapshot.Asks) > 0 {
+		bbo.Ask = &snapshot.Asks[0]
+	}
+	return bbo
+}
+
+// Arbitrage lists the venue pairs whose books are crossed, widest spread first.
+func (c *Consolidated) Arbitrage() []Arbitrage {
+	c.book.mu.RLock()
+	defer c.book.mu.RUnlock()
+	return c.openArbitrage()
+}
+
+func (c *Consolidated) venueNames() []string {
+	names := make([]string, 0, len(c.venues))
+	for name := range c.venues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Consolidated) levels(side common.Side, n int) []ConsolidatedLevel {
+	names := c.venueNames()
+	levels := []ConsolidatedLevel{}
+	for _, level := range c.book.bookSide(side).GetLevels(n) {
+		consolidated := ConsolidatedLevel{Price: level.Price, Size: level.GetSize(), Venues: []VenueSize{}}
+		for _, name := range names {
+			if venueLevel, found := c.venues[name].levels.FindLevel(level.Price, side); found {
+				consolidated.Venues = append(consolidated.Venues, VenueSize{Venue: name, Size: venueLevel.GetSize()})
+			}
+		}
+		levels = append(levels, consolidated)
+	}
+	return levels
+}
+
+func (c *Consolidated) openArbitrage() []Arbitrage {
+	open := make([]Arbitrage, 0, len(c.arbitrage))
+	for _, arbitrage := range c.arbitrage {
+		open = append(open, arbitrage)
+	}
+	sort.Slice(open, func(i, j int) bool {
+		if cmp := open[i].Spread().Cmp(open[j].Spread()); cmp != 0 {
+			return cmp > 0
+		}
+		return open[i].Bid.Venue+open[i].Ask.Venue < open[j].Bid.Venue+open[j].Ask.Venue
+	})
+	return open
+}
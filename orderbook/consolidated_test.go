@@ -0,0 +1,97 @@
+package orderbook_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	"github.com/shopspring/decimal"
+)
+
+// recordVenue runs a generated flow through the Coinbase handler and returns its events.
+func recordVenue(t *testing.T, seed int64) *bytes.Buffer {
+	config := synthetic.DefaultConfig()
+	config.Seed = seed
+	config.TargetOrders = 500
+	generator := synthetic.NewGenerator(config)
+	handler := gdax.NewHandler(nil, orderbook.NewBook(config.ProductID, nil), nil)
+	recording := &bytes.Buffer{}
+	writer := orderbook.NewEventWriter(recording)
+	handler.AddEventListener(writer)
+	for _, message := range append(generator.Generate(10000), generator.Flush()...) {
+		if err := handler.ApplyMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return recording
+}
+
+func TestConsolidatedBookMergesVenueBooks(t *testing.T) {
+	consolidated := orderbook.NewConsolidated("BTC-USD")
+	books := map[string]*orderbook.Book{}
+	for i, venue := range []string{"first", "second"} {
+		books[venue] = orderbook.NewBook("BTC-USD", nil)
+		adapter := orderbook.NewRecordedAdapter(venue, books[venue], "", orderbook.DecodeEvent)
+		adapter.AddEventListener(consolidated)
+		if err := adapter.Replay(recordVenue(t, int64(i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := consolidated.Snapshot(0)
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		want := map[string]map[string]string{}
+		for venue, book := range books {
+			for _, level := range book.GetDepth(side, 0) {
+				if want[level.Price.String()] == nil {
+					want[level.Price.String()] = map[string]string{}
+				}
+				want[level.Price.String()][venue] = level.Size.String()
+			}
+		}
+		levels := snapshot.Bids
+		if side == common.AskSide {
+			levels = snapshot.Asks
+		}
+		if len(levels) != len(want) {
+			t.Fatalf("%s side has %d consolidated levels, want %d", common.ToString(side), len(levels), len(want))
+		}
+		for _, level := range levels {
+			venues := want[level.Price.String()]
+			if len(level.Venues) != len(venues) {
+				t.Fatalf("%s level %s has %d venues, want %d", common.ToString(side), level.Price.String(), len(level.Venues), len(venues))
+			}
+			total := decimal.New(0, 0)
+			for _, share := range level.Venues {
+				if venues[share.Venue] != share.Size.String() {
+					t.Fatalf("%s level %s has %s from %s, want %s", common.ToString(side), level.Price.String(), share.Size.String(),
+						share.Venue, venues[share.Venue])
+				}
+				total = total.Add(share.Size)
+			}
+			if !total.Equal(level.Size) {
+				t.Fatalf("%s level %s totals %s, venues add up to %s", common.ToString(side), level.Price.String(), level.Size.String(), total.String())
+			}
+		}
+	}
+
+	arbitrage := 0
+	for bidVenue, bidBook := range books {
+		for askVenue, askBook := range books {
+			bid, bidErr := bidBook.Bid.GetTopLevel()
+			ask, askErr := askBook.Ask.GetTopLevel()
+			if bidVenue != askVenue && bidErr == nil && askErr == nil && bid.Price.GreaterThan(ask.Price) {
+				arbitrage += 1
+			}
+		}
+	}
+	if len(snapshot.Arbitrage) != arbitrage {
+		t.Fatalf("consolidated book reports %d arbitrage opportunities, want %d", len(snapshot.Arbitrage), arbitrage)
+	}
+}
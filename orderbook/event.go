@@ -0,0 +1,86 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/shopspring/decimal"
+)
+
+type EventType string
+
+const (
+	// EventReset empties the book, before a snapshot or after the feed was lost.
+	EventReset EventType = "reset"
+	// EventOpen rests a new order on the book.
+	EventOpen EventType = "open"
+	// EventDone takes an order off the book, filled or canceled.
+	EventDone EventType = "done"
+	// EventChange resizes a resting order from OldSize to Size.
+	EventChange EventType = "change"
+	// EventMatch fills the resting order Trade.MakerOrderID.
+	EventMatch EventType = "match"
+	// EventLevel sets the total size at a price in an aggregated book. Size zero removes the level.
+	EventLevel EventType = "level"
+	// EventTop replaces the best level of a side in a book that only follows the best prices.
+	EventTop EventType = "top"
+	// EventTrade records a trade whose resting order the feed does not carry.
+	EventTrade EventType = "trade"
+)
+
+// Event is one change to a venue's book. Adapters turn each venue's messages into events so
+// that everything downstream of the book is the same for every venue.
+type Event struct {
+	Type      EventType       `json:"type"`
+	Venue     string          `json:"venue"`
+	ProductID string          `json:"product_id"`
+	Sequence  int64           `json:"sequence"`
+	Time      time.Time       `json:"time"`
+	OrderID   string          `json:"order_id,omitempty"`
+	Side      common.Side     `json:"side"`
+	Price     decimal.Decimal `json:"price"`
+	Size      decimal.Decimal `json:"size"`
+	OldSize   decimal.Decimal `json:"old_size"`
+	Trade     *Trade          `json:"trade,omitempty"`
+}
+
+type EventListener interface {
+	Event(event Event)
+}
+
+// Apply changes the book by one event and advances its sequence. Like the other write methods it
+// does not lock; adapters hold Lock around it.
+func (b *Book) Apply(event Event) error {
+	var err error
+	switch event.Type {
+	case EventReset:
+		b.Clear()
+	case EventOpen:
+		err = b.Add(&Order{ID: event.OrderID, Size: event.Size, Price: event.Price, Side: event.Side, Time: event.Time})
+	case EventDone:
+		_, err = b.Remove(&Order{ID: event.OrderID, Price: event.Price, Side: event.Side})
+	case EventChange:
+		err = b.Change(event.OrderID, event.OldSize, event.Size)
+	case EventMatch, EventTrade:
+		if event.Trade == nil {
+			return fmt.Errorf("%s event %d has no trade", event.Type, event.Sequence)
+		}
+		if event.Type == EventTrade {
+			b.AddTrade(*event.Trade)
+		} else {
+			err = b.Match(*event.Trade)
+		}
+	case EventLevel:
+		err = b.SetLevel(event.Side, event.Price, event.Size, event.Time)
+	case EventTop:
+		err = b.SetTop(event.Side, event.Price, event.Size, event.Time)
+	default:
+		return fmt.Errorf("Event type %s is not supported", event.Type)
+	}
+	b.Sequence = event.Sequence
+	if !event.Time.IsZero() {
+		b.Updated = event.Time
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package orderbook
+
+import (
+	"fmt"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// CheckInvariants walks the whole book and reports the first broken invariant: levels strictly
+// ordered and never empty, level sizes equal to the sum of their orders, every order size positive
+// and every resting order in the ID index at the level it claims. It does not lock, so call it
+// inside View or from the handler goroutine.
+func (b *Book) CheckInvariants() error {
+	seen := 0
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		var previous *BookLevel
+		for _, level := range b.bookSide(side).GetLevels(0) {
+			if err := b.checkLevel(side, level); err != nil {
+				return err
+			}
+			if previous != nil && !b.isBetter(side, previous, level) {
+				return fmt.Errorf("%s level %s is not behind level %s", common.ToString(side), level.Price.String(), previous.Price.String())
+			}
+			previous = level
+			seen += level.GetNumOrders()
+		}
+	}
+	if seen != len(b.orders) {
+		return fmt.Errorf("%d orders rest on levels but %d are indexed", seen, len(b.orders))
+	}
+	return nil
+}
+
+func (b *Book) checkLevel(side common.Side, level *BookLevel) error {
+	if level.Empty() {
+		return fmt.Errorf("%s level %s is empty", common.ToString(side), level.Price.String())
+	}
+	size, lots, count := decimal.New(0, 0), int64(0), 0
+	for _, order := range level.GetOrders() {
+		count += 1
+		if indexed, found := b.orders[order.ID]; !found || indexed != order {
+			return fmt.Errorf("Order %s at %s is not indexed", order.ID, level.Price.String())
+		}
+		if order.level != level || order.Side != side || !order.Price.Equal(level.Price) {
+			return fmt.Errorf("Order %s does not belong to %s level %s", order.ToString(), common.ToString(side), level.Price.String())
+		}
+		if !order.Size.IsPositive() {
+			return fmt.Errorf("Order %s has a size that is not positive", order.ToString())
+		}
+		if b.scale != nil && (order.PriceTicks != level.PriceTicks || !b.scale.Size(order.SizeLots).Equal(order.Size)) {
+			return fmt.Errorf("Order %s ticks or lots do not match its price or size", order.ToString())
+		}
+		size, lots = size.Add(order.Size), lots+order.SizeLots
+	}
+	if count != level.GetNumOrders() {
+		return fmt.Errorf("%s level %s counts %d orders but holds %d", common.ToString(side), level.Price.String(), level.GetNumOrders(), count)
+	}
+	if b.scale != nil && lots != level.SizeLots {
+		return fmt.Errorf("%s level %s has %d lots but its orders sum to %d", common.ToString(side), level.Price.String(), level.SizeLots, lots)
+	}
+	if !size.Equal(level.GetSize()) {
+		return fmt.Errorf("%s level %s has size %s but its orders sum to %s", common.ToString(side), level.Price.String(), level.GetSize().String(), size.String())
+	}
+	return nil
+}
+
+func (b *Book) isBetter(side common.Side, level *BookLevel, than *BookLevel) bool {
+	if side == common.BidSide {
+		return level.Price.GreaterThan(than.Price)
+	}
+	return level.Price.LessThan(than.Price)
+}
+
+// Crossed reports whether the best bid is at or above the best ask, which only happens when
+// the book has missed messages.
+func (b *Book) Crossed() bool {
+	bid, bidErr := b.Bid.GetTopLevel()
+	ask, askErr := b.Ask.GetTopLevel()
+	return bidErr == nil && askErr == nil && !bid.Price.LessThan(ask.Price)
+}
+
+// CheckUncrossed is CheckInvariants for a book that has seen every message, which is never crossed.
+func (b *Book) CheckUncrossed() error {
+	if err := b.CheckInvariants(); err != nil {
+		return err
+	}
+	if b.Crossed() {
+		return errors.New("Book is crossed")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package orderbook_test
+
+import (
+	"fmt"
+	mathRand "math/rand"
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// applyChecked applies messages one at a time and checks the book after every step. Errors from
+// the handler are fine, as random messages are often invalid, but the book must stay consistent.
+func applyChecked(book *orderbook.Book, messages []gdaxClient.Message, uncrossed bool) error {
+	handler := gdax.NewHandler(nil, book, nil)
+	for i, message := range messages {
+		handler.ApplyMessage(message)
+		check := book.CheckInvariants
+		if uncrossed {
+			check = book.CheckUncrossed
+		}
+		if err := check(); err != nil {
+			return fmt.Errorf("after message %d (%s %s): %v", i, message.Type, message.OrderId, err)
+		}
+	}
+	return nil
+}
+
+// drain cancels every resting order, which must leave the book empty.
+func drain(book *orderbook.Book) error {
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		for _, order := range book.GetOrders(side) {
+			if _, err := book.Remove(&orderbook.Order{ID: order.ID, Price: order.Price, Size: order.Size, Side: order.Side}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := book.CheckInvariants(); err != nil {
+		return err
+	}
+	if book.NumOrders() != 0 || book.Bid.NumLevels() != 0 || book.Ask.NumLevels() != 0 {
+		return fmt.Errorf("%d orders on %d bid and %d ask levels remain after cancelling every order",
+			book.NumOrders(), book.Bid.NumLevels(), book.Ask.NumLevels())
+	}
+	return nil
+}
+
+// decodeMessages turns every three bytes into a message drawn from a handful of IDs, prices and
+// sizes, so that messages collide: opens of resting IDs, fills larger than the order, changes
+// with the wrong old size and so on.
+func decodeMessages(data []byte) []gdaxClient.Message {
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	prices := []string{"99.98", "99.99", "100.00", "100.01", "100.02"}
+	sizes := []string{"0.00000001", "0.5", "1", "1.5"}
+	messages := make([]gdaxClient.Message, 0, len(data)/3)
+	for i := 0; i+3 <= len(data); i += 3 {
+		kind, idByte, amounts := data[i], data[i+1], data[i+2]
+		sequence := int64(len(messages) + 1)
+		message := gdaxClient.Message{Sequence: sequence, ProductId: "BTC-USD", OrderId: ids[idByte%8],
+			Price: prices[amounts%5], Side: []string{"buy", "sell"}[kind/5%2]}
+		size, other := sizes[amounts/5%4], sizes[amounts/20%4]
+		switch kind % 5 {
+		case 0:
+			message.Type, message.OrderType, message.Size = "received", "limit", size
+		case 1:
+			message.Type, message.RemainingSize = "open", size
+		case 2:
+			message.Type, message.Reason, message.RemainingSize = "done", []string{"filled", "canceled"}[kind/10%2], size
+		case 3:
+			message.Type, message.OldSize, message.NewSize = "change", size, other
+		default:
+			message.Type, message.MakerOrderId, message.TakerOrderId = "match", message.OrderId, ids[idByte/8%8]
+			message.Size, message.TradeId = size, int(sequence)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestInvariantsHoldOnGeneratedFlow(t *testing.T) {
+	config := synthetic.DefaultConfig()
+	config.TargetOrders = 200
+	for _, mode := range synthetic.BookModes() {
+		generator := synthetic.NewGenerator(config)
+		book := mode.NewBook()
+		if err := applyChecked(book, append(generator.Generate(10000), generator.Flush()...), true); err != nil {
+			t.Fatalf("%s book: %v", mode.Name, err)
+		}
+		if err := drain(book); err != nil {
+			t.Fatalf("%s book: %v", mode.Name, err)
+		}
+	}
+}
+
+// FuzzInvariants checks the invariants after every message of an arbitrary sequence. The seed
+// corpus is random sequences of 500 messages, which plain go test runs too.
+func FuzzInvariants(f *testing.F) {
+	for seed := int64(1); seed <= 50; seed++ {
+		data := make([]byte, 3*500)
+		mathRand.New(mathRand.NewSource(seed)).Read(data)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		messages := decodeMessages(data)
+		for _, mode := range synthetic.BookModes() {
+			book := mode.NewBook()
+			if err := applyChecked(book, messages, false); err != nil {
+				t.Fatalf("%s book: %v", mode.Name, err)
+			}
+			if err := drain(book); err != nil {
+				t.Fatalf("%s book: %v", mode.Name, err)
+			}
+		}
+	})
+}
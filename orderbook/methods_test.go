@@ -0,0 +1,130 @@
+package orderbook_test
+
+import (
+	"testing"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/chrischris292/go-gdax-orderbook/synthetic"
+)
+
+var restingOrders []orderbook.Order
+
+// newRestingOrders applies a generated stream and returns what is left resting, best price first.
+// The result is kept for the next benchmark, which must not modify it.
+func newRestingOrders(b *testing.B) []orderbook.Order {
+	if restingOrders != nil {
+		return restingOrders
+	}
+	book := orderbook.NewBook("BTC-USD", nil)
+	handler := gdax.NewHandler(nil, book, nil)
+	for _, message := range synthetic.Stream(synthetic.BenchmarkEvents) {
+		if err := handler.ApplyMessage(message); err != nil {
+			b.Fatal(err)
+		}
+	}
+	orders := []orderbook.Order{}
+	for _, side := range []common.Side{common.BidSide, common.AskSide} {
+		for _, order := range book.GetOrders(side) {
+			orders = append(orders, orderbook.Order{ID: order.ID, Price: order.Price, Size: order.Size, Side: order.Side})
+		}
+	}
+	restingOrders = orders
+	return orders
+}
+
+func fillBook(book *orderbook.Book, orders []orderbook.Order) {
+	for i := range orders {
+		order := orders[i]
+		book.Add(&order)
+	}
+}
+
+// benchmarkChunks runs op b.N times in chunks of at most chunk, calling setup with the timer
+// stopped before each chunk so ops that consume the book always find it full.
+func benchmarkChunks(b *testing.B, chunk int, setup func(), op func(i int)) {
+	b.ReportAllocs()
+	b.StopTimer()
+	b.ResetTimer()
+	for done := 0; done < b.N; {
+		setup()
+		n := chunk
+		if b.N-done < n {
+			n = b.N - done
+		}
+		b.StartTimer()
+		for i := 0; i < n; i++ {
+			op(i)
+		}
+		b.StopTimer()
+		done += n
+	}
+}
+
+func BenchmarkBookAdd(b *testing.B) {
+	orders := newRestingOrders(b)
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) {
+			var book *orderbook.Book
+			benchmarkChunks(b, len(orders), func() { book = mode.NewBook() }, func(i int) {
+				order := orders[i]
+				book.Add(&order)
+			})
+		})
+	}
+}
+
+func BenchmarkBookRemove(b *testing.B) {
+	orders := newRestingOrders(b)
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) {
+			var book *orderbook.Book
+			benchmarkChunks(b, len(orders), func() { book = mode.NewBook(); fillBook(book, orders) }, func(i int) {
+				order := orders[i]
+				book.Remove(&order)
+			})
+		})
+	}
+}
+
+func BenchmarkBookChange(b *testing.B) {
+	orders := newRestingOrders(b)
+	// each order grows by a lot and then shrinks back, so every change is consistent
+	changes := make([]gdax.Change, 0, 2*len(orders))
+	for _, order := range orders {
+		grown := order.Size.Add(synthetic.DefaultScale.SizeLot)
+		changes = append(changes, gdax.Change{OrderID: order.ID, Price: order.Price, Side: order.Side, OldSize: order.Size, NewSize: grown})
+	}
+	for i := range orders {
+		grow := changes[i]
+		changes = append(changes, gdax.Change{OrderID: grow.OrderID, Price: grow.Price, Side: grow.Side, OldSize: grow.NewSize, NewSize: grow.OldSize})
+	}
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) {
+			var book *orderbook.Book
+			benchmarkChunks(b, len(changes), func() { book = mode.NewBook(); fillBook(book, orders) }, func(i int) {
+				book.Change(changes[i].OrderID, changes[i].OldSize, changes[i].NewSize)
+			})
+		})
+	}
+}
+
+func BenchmarkBookMatch(b *testing.B) {
+	orders := newRestingOrders(b)
+	// fill the asks from the best price out, each maker in one match
+	matches := []orderbook.Trade{}
+	for _, order := range orders {
+		if order.Side == common.AskSide {
+			matches = append(matches, orderbook.Trade{MakerOrderID: order.ID, Price: order.Price, Size: order.Size, MakerSide: common.AskSide})
+		}
+	}
+	for _, mode := range synthetic.BookModes() {
+		b.Run(mode.Name, func(b *testing.B) {
+			var book *orderbook.Book
+			benchmarkChunks(b, len(matches), func() { book = mode.NewBook(); fillBook(book, orders) }, func(i int) {
+				book.Match(matches[i])
+			})
+		})
+	}
+}
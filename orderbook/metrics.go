@@ -0,0 +1,23 @@
+package orderbook
+
+import (
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+)
+
+// ExportMetrics publishes depth, level count and best price for both sides of the book. An empty
+// side has no best price, so its series is removed rather than left at the last price.
+func (b *Book) ExportMetrics() {
+	for _, bookSide := range []*BookSide{b.Bid, b.Ask} {
+		side := common.ToString(bookSide.side)
+		size, _ := bookSide.GetTotalSize().Float64()
+		metrics.BookDepth.WithLabelValues(b.ID, side).Set(size)
+		metrics.BookLevels.WithLabelValues(b.ID, side).Set(float64(bookSide.NumLevels()))
+		if level, err := bookSide.GetTopLevel(); err == nil {
+			price, _ := level.Price.Float64()
+			metrics.BestPrice.WithLabelValues(b.ID, side).Set(price)
+		} else {
+			metrics.BestPrice.DeleteLabelValues(b.ID, side)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/shopspring/decimal"
+)
+
+type Order struct {
+	ID    string
+	Size  decimal.Decimal
+	Price decimal.Decimal
+	Side  common.Side
+	Own   bool
+	// Time is when the order opened, zero when unknown such as for orders restored from a checkpoint.
+	Time time.Time
+
+	// PriceTicks and SizeLots are filled in by a fixed-point Book when the order is added.
+	PriceTicks int64
+	SizeLots   int64
+
+	// links of the level's order queue while the order rests on the book
+	prev  *Order
+	next  *Order
+	level *BookLevel
+}
+
+func (order *Order) ToString() string {
+	return fmt.Sprintf("ID: %s, Size: %s, Price: %s, Side: %s", order.ID, order.Size.String(), order.Price.String(), common.ToString(order.Side))
+}
+
+// Trade is a fill against a resting order. MakerSide is the side of the resting order.
+type Trade struct {
+	TradeID      int
+	Sequence     int64
+	MakerOrderID string
+	TakerOrderID string
+	Time         time.Time
+	ProductID    string
+	Size         decimal.Decimal
+	Price        decimal.Decimal
+	MakerSide    common.Side
+}
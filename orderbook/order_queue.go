@@ -0,0 +1,46 @@
+package orderbook
+
+// orderQueue is an intrusive doubly linked list of the orders resting at one level, in arrival
+// order. The links live on Order, so queueing and removing an order allocates nothing.
+type orderQueue struct {
+	head *Order
+	tail *Order
+	size int
+}
+
+func (queue *orderQueue) pushBack(order *Order) {
+	order.prev, order.next = queue.tail, nil
+	if queue.tail != nil {
+		queue.tail.next = order
+	} else {
+		queue.head = order
+	}
+	queue.tail = order
+	queue.size += 1
+}
+
+// remove unlinks an order that is known to be in this queue.
+func (queue *orderQueue) remove(order *Order) {
+	if order.prev != nil {
+		order.prev.next = order.next
+	} else {
+		queue.head = order.next
+	}
+	if order.next != nil {
+		order.next.prev = order.prev
+	} else {
+		queue.tail = order.prev
+	}
+	order.prev, order.next = nil, nil
+	queue.size -= 1
+}
+
+// find walks the queue, so callers that already hold the order should use it directly.
+func (queue *orderQueue) find(id string) *Order {
+	for order := queue.head; order != nil; order = order.next {
+		if order.ID == id {
+			return order
+		}
+	}
+	return nil
+}
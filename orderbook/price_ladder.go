@@ -0,0 +1,91 @@
+package orderbook
+
+import "github.com/shopspring/decimal"
+
+// priceLadder keeps the levels of one side sorted with the best price last, so that the busy
+// top of the book sits at the end of the slice where inserts and removals move the fewest levels.
+// Bids are ascending and asks descending. Fixed-point ladders compare ticks, others decimals.
+type priceLadder struct {
+	levels []*BookLevel
+	ask    bool
+	fixed  bool
+}
+
+// compare orders a level against a price: negative when the level belongs before it.
+func (ladder *priceLadder) compare(level *BookLevel, price decimal.Decimal, ticks int64) int {
+	result := 0
+	if ladder.fixed {
+		if level.PriceTicks < ticks {
+			result = -1
+		} else if level.PriceTicks > ticks {
+			result = 1
+		}
+	} else {
+		result = level.Price.Cmp(price)
+	}
+	if ladder.ask {
+		return -result
+	}
+	return result
+}
+
+// search returns the index of the level at price, or where it would be inserted.
+func (ladder *priceLadder) search(price decimal.Decimal, ticks int64) (int, bool) {
+	n := len(ladder.levels)
+	// most updates are at the top of the book
+	if n > 0 {
+		if c := ladder.compare(ladder.levels[n-1], price, ticks); c == 0 {
+			return n - 1, true
+		} else if c < 0 {
+			return n, false
+		}
+	}
+	low, high := 0, n
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+		if ladder.compare(ladder.levels[mid], price, ticks) < 0 {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, low < n && ladder.compare(ladder.levels[low], price, ticks) == 0
+}
+
+func (ladder *priceLadder) get(price decimal.Decimal, ticks int64) (*BookLevel, bool) {
+	i, found := ladder.search(price, ticks)
+	if !found {
+		return nil, false
+	}
+	return ladder.levels[i], true
+}
+
+// insert adds a level unless one already exists at its price.
+func (ladder *priceLadder) insert(level *BookLevel) bool {
+	i, found := ladder.search(level.Price, level.PriceTicks)
+	if found {
+		return false
+	}
+	ladder.levels = append(ladder.levels, nil)
+	copy(ladder.levels[i+1:], ladder.levels[i:])
+	ladder.levels[i] = level
+	return true
+}
+
+func (ladder *priceLadder) remove(price decimal.Decimal, ticks int64) bool {
+	i, found := ladder.search(price, ticks)
+	if !found {
+		return false
+	}
+	copy(ladder.levels[i:], ladder.levels[i+1:])
+	ladder.levels[len(ladder.levels)-1] = nil
+	ladder.levels = ladder.levels[:len(ladder.levels)-1]
+	return true
+}
+
+func (ladder *priceLadder) best() (*BookLevel, bool) {
+	if len(ladder.levels) == 0 {
+		return nil, false
+	}
+	return ladder.levels[len(ladder.levels)-1], true
+}
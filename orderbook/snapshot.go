@@ -0,0 +1,69 @@
+package orderbook
+
+import (
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+)
+
+// BookSnapshot is a consistent copy of a Book that is safe to hand to other goroutines.
+type BookSnapshot struct {
+	ID       string
+	Sequence int64
+	Updated  time.Time
+	Bids     []DepthLevel
+	Asks     []DepthLevel
+}
+
+// Snapshot copies the top levels of both sides under the book's read lock. levels <= 0 copies every level.
+func (b *Book) Snapshot(levels int) BookSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return BookSnapshot{
+		ID:       b.ID,
+		Sequence: b.Sequence,
+		Updated:  b.Updated,
+		Bids:     b.GetDepth(common.BidSide, levels),
+		Asks:     b.GetDepth(common.AskSide, levels),
+	}
+}
+
+// SnapshotOrder returns a copy of a resting order.
+func (b *Book) SnapshotOrder(id string) (Order, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	order, found := b.GetOrder(id)
+	if !found {
+		return Order{}, false
+	}
+	return *order, true
+}
+
+func (b *Book) SnapshotTrades() []Trade {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	trades := make([]Trade, len(b.RecentTrades))
+	copy(trades, b.RecentTrades)
+	return trades
+}
+
+// View runs fn with the book read locked so a caller can take a snapshot that no
+// feed message can interleave with. fn must not retain the book after returning.
+func (b *Book) View(fn func(book *Book)) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	fn(b)
+}
+
+// GetOrders lists every resting order on a side, best price first and in arrival order within a level.
+func (b *Book) GetOrders(side common.Side) []*Order {
+	bookSide := b.Ask
+	if side == common.BidSide {
+		bookSide = b.Bid
+	}
+	orders := []*Order{}
+	for _, level := range bookSide.GetLevels(0) {
+		orders = append(orders, level.GetOrders()...)
+	}
+	return orders
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/metrics"
+)
+
+const (
+	defaultQueueSize    = 100
+	defaultMaxPerMinute = 30
+	defaultDedupWindow  = time.Minute
+)
+
+// AsyncConfig bounds what an Async sends. At most QueueSize reports wait to be sent and at most
+// MaxPerMinute are sent a minute. An error with the same message as one reported within
+// DedupWindow is dropped. Zero fields take the defaults.
+type AsyncConfig struct {
+	QueueSize    int
+	MaxPerMinute int
+	DedupWindow  time.Duration
+}
+
+// Async hands reports to another reporter on its own goroutine so Report never blocks. Reports
+// it cannot send are dropped and counted in metrics.ErrorReports.
+type Async struct {
+	reporter ErrorReporter
+	config   AsyncConfig
+	queue    chan queuedReport
+	done     chan struct{}
+
+	mu          sync.Mutex
+	closed      bool
+	windowStart time.Time
+	sent        int
+	lastSeen    map[string]time.Time
+}
+
+type queuedReport struct {
+	err  error
+	tags map[string]string
+}
+
+func NewAsync(reporter ErrorReporter, config AsyncConfig) *Async {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.MaxPerMinute <= 0 {
+		config.MaxPerMinute = defaultMaxPerMinute
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = defaultDedupWindow
+	}
+	async := &Async{
+		reporter: reporter,
+		config:   config,
+		queue:    make(chan queuedReport, config.QueueSize),
+		done:     make(chan struct{}),
+		lastSeen: map[string]time.Time{},
+	}
+	go async.run()
+	return async
+}
+
+func (async *Async) Report(err error, tags map[string]string) {
+	if err == nil {
+		return
+	}
+	async.mu.Lock()
+	defer async.mu.Unlock()
+	if async.closed {
+		metrics.ErrorReports.WithLabelValues("closed").Inc()
+		return
+	}
+	now := time.Now()
+	if now.Sub(async.windowStart) >= time.Minute {
+		async.windowStart = now
+		async.sent = 0
+		for message, seen := range async.lastSeen {
+			if now.Sub(seen) >= async.config.DedupWindow {
+				delete(async.lastSeen, message)
+			}
+		}
+	}
+	message := err.Error()
+	if seen, found := async.lastSeen[message]; found && now.Sub(seen) < async.config.DedupWindow {
+		metrics.ErrorReports.WithLabelValues("duplicate").Inc()
+		return
+	}
+	// only a report that goes out suppresses its repeats
+	if async.sent >= async.config.MaxPerMinute {
+		metrics.ErrorReports.WithLabelValues("rate_limited").Inc()
+		return
+	}
+	select {
+	case async.queue <- queuedReport{err: err, tags: tags}:
+		async.lastSeen[message] = now
+		async.sent++
+		metrics.ErrorReports.WithLabelValues("queued").Inc()
+	default:
+		metrics.ErrorReports.WithLabelValues("queue_full").Inc()
+	}
+}
+
+func (async *Async) run() {
+	defer close(async.done)
+	for queued := range async.queue {
+		async.reporter.Report(queued.err, queued.tags)
+	}
+}
+
+// Close sends what is queued and stops. Later reports are dropped.
+func (async *Async) Close() {
+	async.mu.Lock()
+	if async.closed {
+		async.mu.Unlock()
+		return
+	}
+	async.closed = true
+	close(async.queue)
+	async.mu.Unlock()
+	<-async.done
+}
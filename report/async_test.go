@@ -0,0 +1,64 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockedReporter holds every report until release is closed, like a Sentry that is down.
+type blockedReporter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     []string
+}
+
+func (reporter *blockedReporter) Report(err error, tags map[string]string) {
+	<-reporter.release
+	reporter.mu.Lock()
+	reporter.got = append(reporter.got, err.Error())
+	reporter.mu.Unlock()
+}
+
+func TestAsyncNeverBlocksAndDeduplicatesAndRateLimits(t *testing.T) {
+	blocked := &blockedReporter{release: make(chan struct{})}
+	async := NewAsync(blocked, AsyncConfig{QueueSize: 10, MaxPerMinute: 5, DedupWindow: time.Minute})
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		async.Report(fmt.Errorf("error %d", i%20), nil)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("reporting took %v while the reporter was blocked", elapsed)
+	}
+	close(blocked.release)
+	async.Close()
+	if len(blocked.got) != 5 {
+		t.Fatalf("sent %d reports, want 5: %v", len(blocked.got), blocked.got)
+	}
+	seen := map[string]bool{}
+	for _, message := range blocked.got {
+		if seen[message] {
+			t.Fatalf("%s was sent twice", message)
+		}
+		seen[message] = true
+	}
+}
+
+func TestAsyncSendsRateLimitedErrorInTheNextWindow(t *testing.T) {
+	reporter := &blockedReporter{release: make(chan struct{})}
+	close(reporter.release)
+	async := NewAsync(reporter, AsyncConfig{MaxPerMinute: 1, DedupWindow: time.Hour})
+	async.Report(errors.New("first"), nil)
+	async.Report(errors.New("second"), nil)
+	// start the next minute without waiting for it
+	async.mu.Lock()
+	async.windowStart = async.windowStart.Add(-time.Minute)
+	async.mu.Unlock()
+	async.Report(errors.New("second"), nil)
+	async.Close()
+	if len(reporter.got) != 2 || reporter.got[1] != "second" {
+		t.Fatalf("sent %v, want the rate limited error in the next minute", reporter.got)
+	}
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	raven "github.com/getsentry/raven-go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ErrorReporter sends errors to wherever someone will see them. Report is called from the
+// feed goroutines, so it must return quickly; wrap slow reporters in NewAsync.
+type ErrorReporter interface {
+	Report(err error, tags map[string]string)
+}
+
+// Nop drops every error.
+type Nop struct{}
+
+func (Nop) Report(err error, tags map[string]string) {}
+
+// Log writes errors to the zap logger.
+type Log struct{}
+
+func (Log) Report(err error, tags map[string]string) {
+	fields := []zap.Field{zap.Error(err)}
+	for key, value := range tags {
+		fields = append(fields, zap.String(key, value))
+	}
+	zap.L().Error("Reported error", fields...)
+}
+
+// Sentry sends errors to Sentry. Report waits until the error is sent.
+type Sentry struct {
+	client *raven.Client
+}
+
+func NewSentry(dsn string, environment string) (*Sentry, error) {
+	client, err := raven.New(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not create Sentry client")
+	}
+	client.SetEnvironment(environment)
+	return &Sentry{client: client}, nil
+}
+
+func (sentry *Sentry) Report(err error, tags map[string]string) {
+	sentry.client.CaptureErrorAndWait(err, tags)
+}
+
+// Named picks a reporter by name: "none", "log" or "sentry", which needs a DSN.
+func Named(name string, dsn string, environment string) (ErrorReporter, error) {
+	switch strings.ToLower(name) {
+	case "none":
+		return Nop{}, nil
+	case "log":
+		return Log{}, nil
+	case "sentry":
+		return NewSentry(dsn, environment)
+	}
+	return nil, fmt.Errorf("Error reporter %q is not none, log or sentry", name)
+}
+
+// OrNop returns reporter, or Nop if it is nil.
+func OrNop(reporter ErrorReporter) ErrorReporter {
+	if reporter == nil {
+		return Nop{}
+	}
+	return reporter
+}
+
+// CapturePanic runs f and reports a panic in it, which it returns instead of crashing.
+func CapturePanic(reporter ErrorReporter, f func()) (recovered interface{}) {
+	defer func() {
+		if recovered = recover(); recovered != nil {
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+			reporter.Report(errors.WithStack(err), map[string]string{"panic": "true"})
+		}
+	}()
+	f()
+	return nil
+}
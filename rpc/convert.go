@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	"github.com/pkg/errors"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toSide(side common.Side) Side {
+	if side == common.BidSide {
+		return Side_BID
+	}
+	return Side_ASK
+}
+
+func toOrder(order *orderbook.Order) *Order {
+	return &Order{
+		Id:    order.ID,
+		Price: order.Price.String(),
+		Size:  order.Size.String(),
+		Side:  toSide(order.Side),
+		Own:   order.Own,
+	}
+}
+
+func toBookLevels(levels []*orderbook.BookLevel) []*BookLevel {
+	converted := make([]*BookLevel, 0, len(levels))
+	for _, level := range levels {
+		orders := level.GetOrders()
+		bookLevel := &BookLevel{
+			Price:  level.Price.String(),
+			Size:   level.GetSize().String(),
+			Orders: make([]*Order, 0, len(orders)),
+		}
+		for _, order := range orders {
+			bookLevel.Orders = append(bookLevel.Orders, toOrder(order))
+		}
+		converted = append(converted, bookLevel)
+	}
+	return converted
+}
+
+// toSnapshot must be called with the book read locked.
+func toSnapshot(book *orderbook.Book, depth int) *BookSnapshot {
+	return &BookSnapshot{
+		ProductId: book.ID,
+		Sequence:  book.Sequence,
+		Bids:      toBookLevels(book.Bid.GetLevels(depth)),
+		Asks:      toBookLevels(book.Ask.GetLevels(depth)),
+	}
+}
+
+// toUpdate converts a feed message into a typed update. It returns nil for messages
+// that do not change the book.
+func toUpdate(message gdaxClient.Message) (*BookUpdate, error) {
+	update := &BookUpdate{
+		ProductId: message.ProductId,
+		Sequence:  message.Sequence,
+		Time:      timestamppb.New(message.Time.Time()),
+	}
+	switch message.Type {
+	case "open":
+		order, err := gdax.NewOrder(message.OrderId, message.RemainingSize, message.Price, message.Side)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not convert open message")
+		}
+		update.Event = &BookUpdate_Open{Open: &Open{Order: toOrder(order)}}
+	case "done":
+		done, err := gdax.NewDoneMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.Reason,
+			message.RemainingSize, message.Price, message.Side)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not convert done message")
+		}
+		update.Event = &BookUpdate_Done{Done: &Done{
+			OrderId:       done.OrderID,
+			Price:         message.Price,
+			RemainingSize: done.RemainingSize.String(),
+			Side:          toSide(done.Side),
+			Reason:        string(done.Reason),
+			Market:        done.Market,
+		}}
+	case "change":
+		change, err := gdax.NewChangeMessage(message.Time, message.Sequence, message.OrderId, message.ProductId, message.NewSize, message.OldSize,
+			message.NewFunds, message.OldFunds, message.Price, message.Side)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not convert change message")
+		}
+		if change.IsMarket() {
+			return nil, nil
+		}
+		update.Event = &BookUpdate_Change{Change: &Change{
+			OrderId: change.OrderID,
+			Price:   change.Price.String(),
+			NewSize: change.NewSize.String(),
+			OldSize: change.OldSize.String(),
+			Side:    toSide(change.Side),
+		}}
+	case "match":
+		match, err := gdax.NewMatchMessage(message.TradeId, message.Sequence, message.MakerOrderId, message.TakerOrderId, message.Time,
+			message.ProductId, message.Size, message.Price, message.Side)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not convert match message")
+		}
+		update.Event = &BookUpdate_Match{Match: &Match{
+			TradeId:      int64(match.TradeID),
+			MakerOrderId: match.MakerOrderID,
+			TakerOrderId: match.TakerOrderID,
+			Size:         match.Size.String(),
+			Price:        match.Price.String(),
+			Side:         toSide(match.MatchSide),
+		}}
+	default:
+		return nil, nil
+	}
+	return update, nil
+}
@@ -0,0 +1,985 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: orderbook.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Side mirrors common.Side.
+type Side int32
+
+const (
+	Side_BID Side = 0
+	Side_ASK Side = 1
+)
+
+// Enum value maps for Side.
+var (
+	Side_name = map[int32]string{
+		0: "BID",
+		1: "ASK",
+	}
+	Side_value = map[string]int32{
+		"BID": 0,
+		"ASK": 1,
+	}
+)
+
+func (x Side) Enum() *Side {
+	p := new(Side)
+	*p = x
+	return p
+}
+
+func (x Side) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Side) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[0].Descriptor()
+}
+
+func (Side) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[0]
+}
+
+func (x Side) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Side.Descriptor instead.
+func (Side) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{0}
+}
+
+// Order mirrors orderbook.Order.
+type Order struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Price         string                 `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+	Size          string                 `protobuf:"bytes,3,opt,name=size,proto3" json:"size,omitempty"`
+	Side          Side                   `protobuf:"varint,4,opt,name=side,proto3,enum=orderbook.Side" json:"side,omitempty"`
+	Own           bool                   `protobuf:"varint,5,opt,name=own,proto3" json:"own,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_orderbook_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Order) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *Order) GetSide() Side {
+	if x != nil {
+		return x.Side
+	}
+	return Side_BID
+}
+
+func (x *Order) GetOwn() bool {
+	if x != nil {
+		return x.Own
+	}
+	return false
+}
+
+// BookLevel mirrors orderbook.BookLevel. Orders are in arrival order.
+type BookLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	Size          string                 `protobuf:"bytes,2,opt,name=size,proto3" json:"size,omitempty"`
+	Orders        []*Order               `protobuf:"bytes,3,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookLevel) Reset() {
+	*x = BookLevel{}
+	mi := &file_orderbook_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookLevel) ProtoMessage() {}
+
+func (x *BookLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookLevel.ProtoReflect.Descriptor instead.
+func (*BookLevel) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BookLevel) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *BookLevel) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *BookLevel) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+// Match mirrors gdax.Match. Side is the maker's side.
+type Match struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TradeId       int64                  `protobuf:"varint,1,opt,name=trade_id,json=tradeId,proto3" json:"trade_id,omitempty"`
+	MakerOrderId  string                 `protobuf:"bytes,2,opt,name=maker_order_id,json=makerOrderId,proto3" json:"maker_order_id,omitempty"`
+	TakerOrderId  string                 `protobuf:"bytes,3,opt,name=taker_order_id,json=takerOrderId,proto3" json:"taker_order_id,omitempty"`
+	Size          string                 `protobuf:"bytes,4,opt,name=size,proto3" json:"size,omitempty"`
+	Price         string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	Side          Side                   `protobuf:"varint,6,opt,name=side,proto3,enum=orderbook.Side" json:"side,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_orderbook_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Match) GetTradeId() int64 {
+	if x != nil {
+		return x.TradeId
+	}
+	return 0
+}
+
+func (x *Match) GetMakerOrderId() string {
+	if x != nil {
+		return x.MakerOrderId
+	}
+	return ""
+}
+
+func (x *Match) GetTakerOrderId() string {
+	if x != nil {
+		return x.TakerOrderId
+	}
+	return ""
+}
+
+func (x *Match) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *Match) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Match) GetSide() Side {
+	if x != nil {
+		return x.Side
+	}
+	return Side_BID
+}
+
+type Open struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Open) Reset() {
+	*x = Open{}
+	mi := &file_orderbook_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Open) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Open) ProtoMessage() {}
+
+func (x *Open) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Open.ProtoReflect.Descriptor instead.
+func (*Open) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Open) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+// Done mirrors gdax.Done. Market orders never rest, so they have no price.
+type Done struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Price         string                 `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+	RemainingSize string                 `protobuf:"bytes,3,opt,name=remaining_size,json=remainingSize,proto3" json:"remaining_size,omitempty"`
+	Side          Side                   `protobuf:"varint,4,opt,name=side,proto3,enum=orderbook.Side" json:"side,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Market        bool                   `protobuf:"varint,6,opt,name=market,proto3" json:"market,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Done) Reset() {
+	*x = Done{}
+	mi := &file_orderbook_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Done) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Done) ProtoMessage() {}
+
+func (x *Done) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Done.ProtoReflect.Descriptor instead.
+func (*Done) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Done) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Done) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Done) GetRemainingSize() string {
+	if x != nil {
+		return x.RemainingSize
+	}
+	return ""
+}
+
+func (x *Done) GetSide() Side {
+	if x != nil {
+		return x.Side
+	}
+	return Side_BID
+}
+
+func (x *Done) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Done) GetMarket() bool {
+	if x != nil {
+		return x.Market
+	}
+	return false
+}
+
+type Change struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Price         string                 `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+	NewSize       string                 `protobuf:"bytes,3,opt,name=new_size,json=newSize,proto3" json:"new_size,omitempty"`
+	OldSize       string                 `protobuf:"bytes,4,opt,name=old_size,json=oldSize,proto3" json:"old_size,omitempty"`
+	Side          Side                   `protobuf:"varint,5,opt,name=side,proto3,enum=orderbook.Side" json:"side,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_orderbook_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Change) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Change) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Change) GetNewSize() string {
+	if x != nil {
+		return x.NewSize
+	}
+	return ""
+}
+
+func (x *Change) GetOldSize() string {
+	if x != nil {
+		return x.OldSize
+	}
+	return ""
+}
+
+func (x *Change) GetSide() Side {
+	if x != nil {
+		return x.Side
+	}
+	return Side_BID
+}
+
+type GetBookRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Levels per side, 0 for the whole book.
+	Depth         int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookRequest) Reset() {
+	*x = GetBookRequest{}
+	mi := &file_orderbook_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookRequest) ProtoMessage() {}
+
+func (x *GetBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookRequest.ProtoReflect.Descriptor instead.
+func (*GetBookRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetBookRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *GetBookRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type BookSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Sequence      int64                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Bids          []*BookLevel           `protobuf:"bytes,3,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*BookLevel           `protobuf:"bytes,4,rep,name=asks,proto3" json:"asks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookSnapshot) Reset() {
+	*x = BookSnapshot{}
+	mi := &file_orderbook_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookSnapshot) ProtoMessage() {}
+
+func (x *BookSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookSnapshot.ProtoReflect.Descriptor instead.
+func (*BookSnapshot) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BookSnapshot) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *BookSnapshot) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *BookSnapshot) GetBids() []*BookLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *BookSnapshot) GetAsks() []*BookLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+type StreamUpdatesRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Updates after from_sequence are replayed if they are still buffered. Otherwise, or when
+	// from_sequence is 0, the stream starts with a full snapshot.
+	FromSequence  int64 `protobuf:"varint,2,opt,name=from_sequence,json=fromSequence,proto3" json:"from_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamUpdatesRequest) Reset() {
+	*x = StreamUpdatesRequest{}
+	mi := &file_orderbook_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamUpdatesRequest) ProtoMessage() {}
+
+func (x *StreamUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamUpdatesRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StreamUpdatesRequest) GetFromSequence() int64 {
+	if x != nil {
+		return x.FromSequence
+	}
+	return 0
+}
+
+// BookUpdate carries the feed sequence of the message it was built from. Received
+// messages do not change the book and are not streamed, so sequences can skip.
+type BookUpdate struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Sequence  int64                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*BookUpdate_Snapshot
+	//	*BookUpdate_Open
+	//	*BookUpdate_Done
+	//	*BookUpdate_Change
+	//	*BookUpdate_Match
+	Event         isBookUpdate_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookUpdate) Reset() {
+	*x = BookUpdate{}
+	mi := &file_orderbook_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookUpdate) ProtoMessage() {}
+
+func (x *BookUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookUpdate.ProtoReflect.Descriptor instead.
+func (*BookUpdate) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BookUpdate) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *BookUpdate) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *BookUpdate) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetEvent() isBookUpdate_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetSnapshot() *BookSnapshot {
+	if x != nil {
+		if x, ok := x.Event.(*BookUpdate_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetOpen() *Open {
+	if x != nil {
+		if x, ok := x.Event.(*BookUpdate_Open); ok {
+			return x.Open
+		}
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetDone() *Done {
+	if x != nil {
+		if x, ok := x.Event.(*BookUpdate_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetChange() *Change {
+	if x != nil {
+		if x, ok := x.Event.(*BookUpdate_Change); ok {
+			return x.Change
+		}
+	}
+	return nil
+}
+
+func (x *BookUpdate) GetMatch() *Match {
+	if x != nil {
+		if x, ok := x.Event.(*BookUpdate_Match); ok {
+			return x.Match
+		}
+	}
+	return nil
+}
+
+type isBookUpdate_Event interface {
+	isBookUpdate_Event()
+}
+
+type BookUpdate_Snapshot struct {
+	Snapshot *BookSnapshot `protobuf:"bytes,4,opt,name=snapshot,proto3,oneof"`
+}
+
+type BookUpdate_Open struct {
+	Open *Open `protobuf:"bytes,5,opt,name=open,proto3,oneof"`
+}
+
+type BookUpdate_Done struct {
+	Done *Done `protobuf:"bytes,6,opt,name=done,proto3,oneof"`
+}
+
+type BookUpdate_Change struct {
+	Change *Change `protobuf:"bytes,7,opt,name=change,proto3,oneof"`
+}
+
+type BookUpdate_Match struct {
+	Match *Match `protobuf:"bytes,8,opt,name=match,proto3,oneof"`
+}
+
+func (*BookUpdate_Snapshot) isBookUpdate_Event() {}
+
+func (*BookUpdate_Open) isBookUpdate_Event() {}
+
+func (*BookUpdate_Done) isBookUpdate_Event() {}
+
+func (*BookUpdate_Change) isBookUpdate_Event() {}
+
+func (*BookUpdate_Match) isBookUpdate_Event() {}
+
+var File_orderbook_proto protoreflect.FileDescriptor
+
+const file_orderbook_proto_rawDesc = "" +
+	"\n" +
+	"\x0forderbook.proto\x12\torderbook\x1a\x1fgoogle/protobuf/timestamp.proto\"x\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\tR\x05price\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\tR\x04size\x12#\n" +
+	"\x04side\x18\x04 \x01(\x0e2\x0f.orderbook.SideR\x04side\x12\x10\n" +
+	"\x03own\x18\x05 \x01(\bR\x03own\"_\n" +
+	"\tBookLevel\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\tR\x05price\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\tR\x04size\x12(\n" +
+	"\x06orders\x18\x03 \x03(\v2\x10.orderbook.OrderR\x06orders\"\xbd\x01\n" +
+	"\x05Match\x12\x19\n" +
+	"\btrade_id\x18\x01 \x01(\x03R\atradeId\x12$\n" +
+	"\x0emaker_order_id\x18\x02 \x01(\tR\fmakerOrderId\x12$\n" +
+	"\x0etaker_order_id\x18\x03 \x01(\tR\ftakerOrderId\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\tR\x04size\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\tR\x05price\x12#\n" +
+	"\x04side\x18\x06 \x01(\x0e2\x0f.orderbook.SideR\x04side\".\n" +
+	"\x04Open\x12&\n" +
+	"\x05order\x18\x01 \x01(\v2\x10.orderbook.OrderR\x05order\"\xb3\x01\n" +
+	"\x04Done\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\tR\x05price\x12%\n" +
+	"\x0eremaining_size\x18\x03 \x01(\tR\rremainingSize\x12#\n" +
+	"\x04side\x18\x04 \x01(\x0e2\x0f.orderbook.SideR\x04side\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06market\x18\x06 \x01(\bR\x06market\"\x94\x01\n" +
+	"\x06Change\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\tR\x05price\x12\x19\n" +
+	"\bnew_size\x18\x03 \x01(\tR\anewSize\x12\x19\n" +
+	"\bold_size\x18\x04 \x01(\tR\aoldSize\x12#\n" +
+	"\x04side\x18\x05 \x01(\x0e2\x0f.orderbook.SideR\x04side\"E\n" +
+	"\x0eGetBookRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\"\x9d\x01\n" +
+	"\fBookSnapshot\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12(\n" +
+	"\x04bids\x18\x03 \x03(\v2\x14.orderbook.BookLevelR\x04bids\x12(\n" +
+	"\x04asks\x18\x04 \x03(\v2\x14.orderbook.BookLevelR\x04asks\"Z\n" +
+	"\x14StreamUpdatesRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12#\n" +
+	"\rfrom_sequence\x18\x02 \x01(\x03R\ffromSequence\"\xdc\x02\n" +
+	"\n" +
+	"BookUpdate\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x125\n" +
+	"\bsnapshot\x18\x04 \x01(\v2\x17.orderbook.BookSnapshotH\x00R\bsnapshot\x12%\n" +
+	"\x04open\x18\x05 \x01(\v2\x0f.orderbook.OpenH\x00R\x04open\x12%\n" +
+	"\x04done\x18\x06 \x01(\v2\x0f.orderbook.DoneH\x00R\x04done\x12+\n" +
+	"\x06change\x18\a \x01(\v2\x11.orderbook.ChangeH\x00R\x06change\x12(\n" +
+	"\x05match\x18\b \x01(\v2\x10.orderbook.MatchH\x00R\x05matchB\a\n" +
+	"\x05event*\x18\n" +
+	"\x04Side\x12\a\n" +
+	"\x03BID\x10\x00\x12\a\n" +
+	"\x03ASK\x10\x012\x95\x01\n" +
+	"\tOrderBook\x12=\n" +
+	"\aGetBook\x12\x19.orderbook.GetBookRequest\x1a\x17.orderbook.BookSnapshot\x12I\n" +
+	"\rStreamUpdates\x12\x1f.orderbook.StreamUpdatesRequest\x1a\x15.orderbook.BookUpdate0\x01B0Z.github.com/chrischris292/go-gdax-orderbook/rpcb\x06proto3"
+
+var (
+	file_orderbook_proto_rawDescOnce sync.Once
+	file_orderbook_proto_rawDescData []byte
+)
+
+func file_orderbook_proto_rawDescGZIP() []byte {
+	file_orderbook_proto_rawDescOnce.Do(func() {
+		file_orderbook_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_orderbook_proto_rawDesc), len(file_orderbook_proto_rawDesc)))
+	})
+	return file_orderbook_proto_rawDescData
+}
+
+var file_orderbook_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_orderbook_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_orderbook_proto_goTypes = []any{
+	(Side)(0),                     // 0: orderbook.Side
+	(*Order)(nil),                 // 1: orderbook.Order
+	(*BookLevel)(nil),             // 2: orderbook.BookLevel
+	(*Match)(nil),                 // 3: orderbook.Match
+	(*Open)(nil),                  // 4: orderbook.Open
+	(*Done)(nil),                  // 5: orderbook.Done
+	(*Change)(nil),                // 6: orderbook.Change
+	(*GetBookRequest)(nil),        // 7: orderbook.GetBookRequest
+	(*BookSnapshot)(nil),          // 8: orderbook.BookSnapshot
+	(*StreamUpdatesRequest)(nil),  // 9: orderbook.StreamUpdatesRequest
+	(*BookUpdate)(nil),            // 10: orderbook.BookUpdate
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_orderbook_proto_depIdxs = []int32{
+	0,  // 0: orderbook.Order.side:type_name -> orderbook.Side
+	1,  // 1: orderbook.BookLevel.orders:type_name -> orderbook.Order
+	0,  // 2: orderbook.Match.side:type_name -> orderbook.Side
+	1,  // 3: orderbook.Open.order:type_name -> orderbook.Order
+	0,  // 4: orderbook.Done.side:type_name -> orderbook.Side
+	0,  // 5: orderbook.Change.side:type_name -> orderbook.Side
+	2,  // 6: orderbook.BookSnapshot.bids:type_name -> orderbook.BookLevel
+	2,  // 7: orderbook.BookSnapshot.asks:type_name -> orderbook.BookLevel
+	11, // 8: orderbook.BookUpdate.time:type_name -> google.protobuf.Timestamp
+	8,  // 9: orderbook.BookUpdate.snapshot:type_name -> orderbook.BookSnapshot
+	4,  // 10: orderbook.BookUpdate.open:type_name -> orderbook.Open
+	5,  // 11: orderbook.BookUpdate.done:type_name -> orderbook.Done
+	6,  // 12: orderbook.BookUpdate.change:type_name -> orderbook.Change
+	3,  // 13: orderbook.BookUpdate.match:type_name -> orderbook.Match
+	7,  // 14: orderbook.OrderBook.GetBook:input_type -> orderbook.GetBookRequest
+	9,  // 15: orderbook.OrderBook.StreamUpdates:input_type -> orderbook.StreamUpdatesRequest
+	8,  // 16: orderbook.OrderBook.GetBook:output_type -> orderbook.BookSnapshot
+	10, // 17: orderbook.OrderBook.StreamUpdates:output_type -> orderbook.BookUpdate
+	16, // [16:18] is the sub-list for method output_type
+	14, // [14:16] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_orderbook_proto_init() }
+func file_orderbook_proto_init() {
+	if File_orderbook_proto != nil {
+		return
+	}
+	file_orderbook_proto_msgTypes[9].OneofWrappers = []any{
+		(*BookUpdate_Snapshot)(nil),
+		(*BookUpdate_Open)(nil),
+		(*BookUpdate_Done)(nil),
+		(*BookUpdate_Change)(nil),
+		(*BookUpdate_Match)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orderbook_proto_rawDesc), len(file_orderbook_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_orderbook_proto_goTypes,
+		DependencyIndexes: file_orderbook_proto_depIdxs,
+		EnumInfos:         file_orderbook_proto_enumTypes,
+		MessageInfos:      file_orderbook_proto_msgTypes,
+	}.Build()
+	File_orderbook_proto = out.File
+	file_orderbook_proto_goTypes = nil
+	file_orderbook_proto_depIdxs = nil
+}
@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: orderbook.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OrderBook_GetBook_FullMethodName       = "/orderbook.OrderBook/GetBook"
+	OrderBook_StreamUpdates_FullMethodName = "/orderbook.OrderBook/StreamUpdates"
+)
+
+// OrderBookClient is the client API for OrderBook service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OrderBookClient interface {
+	GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*BookSnapshot, error)
+	StreamUpdates(ctx context.Context, in *StreamUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BookUpdate], error)
+}
+
+type orderBookClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderBookClient(cc grpc.ClientConnInterface) OrderBookClient {
+	return &orderBookClient{cc}
+}
+
+func (c *orderBookClient) GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*BookSnapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BookSnapshot)
+	err := c.cc.Invoke(ctx, OrderBook_GetBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) StreamUpdates(ctx context.Context, in *StreamUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BookUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBook_ServiceDesc.Streams[0], OrderBook_StreamUpdates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamUpdatesRequest, BookUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamUpdatesClient = grpc.ServerStreamingClient[BookUpdate]
+
+// OrderBookServer is the server API for OrderBook service.
+// All implementations must embed UnimplementedOrderBookServer
+// for forward compatibility.
+type OrderBookServer interface {
+	GetBook(context.Context, *GetBookRequest) (*BookSnapshot, error)
+	StreamUpdates(*StreamUpdatesRequest, grpc.ServerStreamingServer[BookUpdate]) error
+	mustEmbedUnimplementedOrderBookServer()
+}
+
+// UnimplementedOrderBookServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderBookServer struct{}
+
+func (UnimplementedOrderBookServer) GetBook(context.Context, *GetBookRequest) (*BookSnapshot, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedOrderBookServer) StreamUpdates(*StreamUpdatesRequest, grpc.ServerStreamingServer[BookUpdate]) error {
+	return status.Error(codes.Unimplemented, "method StreamUpdates not implemented")
+}
+func (UnimplementedOrderBookServer) mustEmbedUnimplementedOrderBookServer() {}
+func (UnimplementedOrderBookServer) testEmbeddedByValue()                   {}
+
+// UnsafeOrderBookServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderBookServer will
+// result in compilation errors.
+type UnsafeOrderBookServer interface {
+	mustEmbedUnimplementedOrderBookServer()
+}
+
+func RegisterOrderBookServer(s grpc.ServiceRegistrar, srv OrderBookServer) {
+	// If the following call panics, it indicates UnimplementedOrderBookServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderBook_ServiceDesc, srv)
+}
+
+func _OrderBook_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_GetBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).GetBook(ctx, req.(*GetBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_StreamUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServer).StreamUpdates(m, &grpc.GenericServerStream[StreamUpdatesRequest, BookUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamUpdatesServer = grpc.ServerStreamingServer[BookUpdate]
+
+// OrderBook_ServiceDesc is the grpc.ServiceDesc for OrderBook service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderBook_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderbook.OrderBook",
+	HandlerType: (*OrderBookServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBook",
+			Handler:    _OrderBook_GetBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamUpdates",
+			Handler:       _OrderBook_StreamUpdates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "orderbook.proto",
+}
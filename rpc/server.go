@@ -0,0 +1,217 @@
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative orderbook.proto
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultReplayBuffer is how many updates per product are kept for StreamUpdates to resume from.
+	DefaultReplayBuffer = 10000
+	subscriberBuffer    = 4096
+)
+
+// Server implements OrderBookServer over the books of this process. Each book's
+// handler feeds it through the HandlerConsumer returned by Consumer.
+type Server struct {
+	UnimplementedOrderBookServer
+
+	mu           sync.Mutex
+	books        map[string]*orderbook.Book
+	replay       map[string][]*BookUpdate
+	subscribers  map[string]map[*subscriber]struct{}
+	replayBuffer int
+}
+
+type subscriber struct {
+	updates chan *BookUpdate
+	// err is set before updates is closed to end the stream with an error
+	err error
+}
+
+func NewServer(replayBuffer int, books ...*orderbook.Book) *Server {
+	if replayBuffer <= 0 {
+		replayBuffer = DefaultReplayBuffer
+	}
+	server := &Server{
+		books:        map[string]*orderbook.Book{},
+		replay:       map[string][]*BookUpdate{},
+		subscribers:  map[string]map[*subscriber]struct{}{},
+		replayBuffer: replayBuffer,
+	}
+	for _, book := range books {
+		server.books[book.ID] = book
+		server.subscribers[book.ID] = map[*subscriber]struct{}{}
+	}
+	return server
+}
+
+func (server *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	RegisterOrderBookServer(grpcServer, server)
+	zap.L().Info("Starting gRPC server", zap.String("addr", addr))
+	return grpcServer.Serve(listener)
+}
+
+func (server *Server) GetBook(ctx context.Context, request *GetBookRequest) (*BookSnapshot, error) {
+	book, found := server.books[request.ProductId]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "unknown product %s", request.ProductId)
+	}
+	var snapshot *BookSnapshot
+	book.View(func(book *orderbook.Book) {
+		snapshot = toSnapshot(book, int(request.Depth))
+	})
+	return snapshot, nil
+}
+
+func (server *Server) StreamUpdates(request *StreamUpdatesRequest, stream OrderBook_StreamUpdatesServer) error {
+	book, found := server.books[request.ProductId]
+	if !found {
+		return status.Errorf(codes.NotFound, "unknown product %s", request.ProductId)
+	}
+	sub := &subscriber{updates: make(chan *BookUpdate, subscriberBuffer)}
+	var backlog []*BookUpdate
+	// the book read lock keeps the handler from applying a message until the subscriber is registered
+	book.View(func(book *orderbook.Book) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		backlog = server.backlog(book, request.FromSequence)
+		server.subscribers[book.ID][sub] = struct{}{}
+	})
+	defer server.unsubscribe(book.ID, sub)
+
+	for _, update := range backlog {
+		if err := stream.Send(update); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case update, ok := <-sub.updates:
+			if !ok {
+				return sub.err
+			}
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// backlog returns buffered updates after fromSequence, or a snapshot when they are not all buffered.
+// Must be called with the book read locked and server.mu held.
+func (server *Server) backlog(book *orderbook.Book, fromSequence int64) []*BookUpdate {
+	replay := server.replay[book.ID]
+	if fromSequence > 0 && fromSequence <= book.Sequence && len(replay) > 0 && replay[0].Sequence <= fromSequence+1 {
+		backlog := []*BookUpdate{}
+		for _, update := range replay {
+			if update.Sequence > fromSequence {
+				backlog = append(backlog, update)
+			}
+		}
+		return backlog
+	}
+	snapshot := toSnapshot(book, 0)
+	return []*BookUpdate{{
+		ProductId: book.ID,
+		Sequence:  snapshot.Sequence,
+		Event:     &BookUpdate_Snapshot{Snapshot: snapshot},
+	}}
+}
+
+func (server *Server) unsubscribe(product string, sub *subscriber) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	delete(server.subscribers[product], sub)
+}
+
+// publish must be called with server.mu held.
+func (server *Server) publish(product string, update *BookUpdate) {
+	replay := append(server.replay[product], update)
+	if len(replay) > server.replayBuffer {
+		replay = replay[len(replay)-server.replayBuffer:]
+	}
+	server.replay[product] = replay
+
+	for sub := range server.subscribers[product] {
+		select {
+		case sub.updates <- update:
+		default:
+			server.drop(product, sub, status.Error(codes.ResourceExhausted, "subscriber fell too far behind"))
+		}
+	}
+}
+
+// reset ends every stream of product after the book was rebuilt. Must be called with server.mu held.
+func (server *Server) reset(product string) {
+	delete(server.replay, product)
+	for sub := range server.subscribers[product] {
+		server.drop(product, sub, status.Errorf(codes.Aborted, "book %s was reset, resubscribe for a new snapshot", product))
+	}
+}
+
+func (server *Server) drop(product string, sub *subscriber, err error) {
+	delete(server.subscribers[product], sub)
+	sub.err = err
+	close(sub.updates)
+}
+
+// Consumer returns the HandlerConsumer to register on the handler for product.
+func (server *Server) Consumer(product string) gdax.HandlerConsumer {
+	return &bookConsumer{server: server, product: product}
+}
+
+type bookConsumer struct {
+	server       *Server
+	product      string
+	lastSequence int64
+}
+
+func (consumer *bookConsumer) BookUpdate(message gdaxClient.Message) {
+	server := consumer.server
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if consumer.lastSequence != 0 && message.Sequence != consumer.lastSequence+1 {
+		server.reset(consumer.product)
+	}
+	consumer.lastSequence = message.Sequence
+
+	update, err := toUpdate(message)
+	if err != nil {
+		zap.L().Error("Could not convert message for gRPC stream", zap.Error(err))
+		return
+	}
+	if update == nil {
+		return
+	}
+	server.publish(consumer.product, update)
+}
+
+func (consumer *bookConsumer) TradeTick(msg gdax.Match) {}
+
+func (consumer *bookConsumer) Clear() {
+	server := consumer.server
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	consumer.lastSequence = 0
+	server.reset(consumer.product)
+}
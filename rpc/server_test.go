@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/gdax"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// feed is a BTC-USD book whose handler publishes to a Server served over an in-memory connection.
+type feed struct {
+	book     *orderbook.Book
+	handler  *gdax.Handler
+	server   *Server
+	consumer gdax.HandlerConsumer
+	client   OrderBookClient
+}
+
+func newFeed(t *testing.T, replayBuffer int) *feed {
+	book := orderbook.NewBook("BTC-USD", nil)
+	server := NewServer(replayBuffer, book)
+	handler := gdax.NewHandler(nil, book, nil)
+	consumer := server.Consumer(book.ID)
+	handler.AddHandlerConsumer(consumer)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	RegisterOrderBookServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	// fixed windows keep flow control from growing to fit a client that does not read
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithInitialWindowSize(1<<16), grpc.WithInitialConnWindowSize(1<<16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &feed{book: book, handler: handler, server: server, consumer: consumer, client: NewOrderBookClient(conn)}
+}
+
+// apply opens a bid for each sequence from first to last.
+func (feed *feed) apply(t *testing.T, first int64, last int64) {
+	for sequence := first; sequence <= last; sequence++ {
+		feed.book.Lock()
+		err := feed.handler.ApplyMessage(gdaxClient.Message{Type: "open", Sequence: sequence, ProductId: feed.book.ID,
+			OrderId: fmt.Sprint(sequence), RemainingSize: "1", Price: "100.00", Side: "buy"})
+		feed.book.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (feed *feed) stream(t *testing.T, fromSequence int64) OrderBook_StreamUpdatesClient {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	stream, err := feed.client.StreamUpdates(ctx, &StreamUpdatesRequest{ProductId: feed.book.ID, FromSequence: fromSequence})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// waitSubscribed waits for the server to register the streams requested so far.
+func (feed *feed) waitSubscribed(t *testing.T, streams int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for feed.server.numSubscribers() != streams {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d streams registered, want %d", feed.server.numSubscribers(), streams)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, stream OrderBook_StreamUpdatesClient) *BookUpdate {
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return update
+}
+
+// expectEnded reads what is left of stream and checks that it ends with code.
+func expectEnded(t *testing.T, stream OrderBook_StreamUpdatesClient, code codes.Code) {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if status.Code(err) != code {
+				t.Fatalf("stream ended with %v, want %v", err, code)
+			}
+			return
+		}
+	}
+}
+
+func TestStreamUpdatesResumesOrSnapshots(t *testing.T) {
+	tests := []struct {
+		name         string
+		fromSequence int64
+		// snapshot is whether the stream starts with a snapshot rather than the updates after fromSequence
+		snapshot bool
+	}{
+		{name: "resume inside the replay buffer", fromSequence: 15},
+		{name: "resume from the oldest buffered update", fromSequence: 10},
+		{name: "nothing to replay", fromSequence: 20},
+		{name: "too old for the replay buffer", fromSequence: 5, snapshot: true},
+		{name: "ahead of the book", fromSequence: 25, snapshot: true},
+		{name: "no sequence", snapshot: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			feed := newFeed(t, 10)
+			feed.apply(t, 1, 20)
+			stream := feed.stream(t, test.fromSequence)
+			feed.waitSubscribed(t, 1)
+			// updates applied once the stream is registered follow its backlog
+			feed.apply(t, 21, 22)
+			next := test.fromSequence + 1
+			if test.snapshot {
+				update := receive(t, stream)
+				snapshot := update.GetSnapshot()
+				if snapshot == nil || update.Sequence != 20 || len(snapshot.Bids) != 1 || len(snapshot.Bids[0].Orders) != 20 {
+					t.Fatalf("first update is %v, want a snapshot of the 20 orders at sequence 20 it was registered at", update)
+				}
+				next = 21
+			}
+			for ; next <= 22; next++ {
+				update := receive(t, stream)
+				if update.Sequence != next || update.GetOpen().GetOrder().GetId() != fmt.Sprint(next) {
+					t.Fatalf("got %v, want the open of sequence %d", update, next)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamUpdatesEndsOnOverflow(t *testing.T) {
+	feed := newFeed(t, 10)
+	stream := feed.stream(t, 0)
+	receive(t, stream)
+
+	// the client reads nothing, so once flow control stops the sends its queue overflows
+	sequence := int64(1)
+	for ; sequence <= 100*subscriberBuffer && feed.server.numSubscribers() > 0; sequence++ {
+		feed.apply(t, sequence, sequence)
+	}
+	if feed.server.numSubscribers() != 0 {
+		t.Fatalf("subscriber still streaming after %d updates it never read", sequence-1)
+	}
+	expectEnded(t, stream, codes.ResourceExhausted)
+}
+
+func TestStreamUpdatesEndsOnReset(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset func(feed *feed)
+	}{
+		{name: "sequence gap", reset: func(feed *feed) {
+			feed.consumer.BookUpdate(gdaxClient.Message{Type: "open", Sequence: 30, ProductId: feed.book.ID,
+				OrderId: "30", RemainingSize: "1", Price: "100.00", Side: "buy"})
+		}},
+		{name: "cleared book", reset: func(feed *feed) { feed.consumer.Clear() }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			feed := newFeed(t, 10)
+			feed.apply(t, 1, 5)
+			stream := feed.stream(t, 0)
+			receive(t, stream)
+			test.reset(feed)
+			expectEnded(t, stream, codes.Aborted)
+
+			// the replay buffer went with the old book, so resuming needs a new snapshot
+			update := receive(t, feed.stream(t, 3))
+			if update.GetSnapshot() == nil || update.Sequence != 5 {
+				t.Fatalf("resumed with %v, want a snapshot at sequence 5", update)
+			}
+		})
+	}
+}
+
+func (server *Server) numSubscribers() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	count := 0
+	for _, subscribers := range server.subscribers {
+		count += len(subscribers)
+	}
+	return count
+}
@@ -0,0 +1,33 @@
+package synthetic
+
+import (
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	"github.com/chrischris292/go-gdax-orderbook/orderbook"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// BenchmarkEvents is the length of the stream the book, handler and codec benchmarks apply.
+const BenchmarkEvents = 100000
+
+// DefaultScale is the 0.01 tick and 0.00000001 lot of DefaultConfig.
+var DefaultScale, _ = common.NewScale("0.01", "0.00000001")
+
+// BookMode builds an empty book of one kind, so tests and benchmarks can run against each.
+type BookMode struct {
+	Name    string
+	NewBook func() *orderbook.Book
+}
+
+// BookModes are a decimal book and a fixed-point book on DefaultScale for DefaultConfig's product.
+func BookModes() []BookMode {
+	product := DefaultConfig().ProductID
+	return []BookMode{
+		{"decimal", func() *orderbook.Book { return orderbook.NewBook(product, nil) }},
+		{"fixed", func() *orderbook.Book { return orderbook.NewFixedBook(product, DefaultScale, nil) }},
+	}
+}
+
+// Stream is the first n messages of DefaultConfig's flow, a cancel-heavy stream with partial fills.
+func Stream(n int) []gdaxClient.Message {
+	return NewGenerator(DefaultConfig()).Generate(n)
+}
@@ -0,0 +1,322 @@
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/chrischris292/go-gdax-orderbook/common"
+	gdaxClient "github.com/preichenberger/go-gdax"
+)
+
+// Config shapes the generated order flow. The ratios are relative weights of the events
+// that follow each other in the stream and need not add up to one.
+type Config struct {
+	ProductID string
+	Seed      int64
+	Scale     common.Scale
+	Start     time.Time
+
+	// MidTicks is the opening mid price. DriftTicks is the standard deviation of its random
+	// walk per event, DepthTicks the mean distance of new orders from the touch.
+	MidTicks   int64
+	DriftTicks float64
+	DepthTicks float64
+
+	// MaxLots bounds the size of new orders.
+	MaxLots int64
+
+	// ArrivalRate is the mean number of events per second. Arrivals are a Poisson process.
+	ArrivalRate float64
+
+	PlaceRatio  float64
+	CancelRatio float64
+	TradeRatio  float64
+	ChangeRatio float64
+
+	// TargetOrders is the book size at which cancels reach CancelRatio. Each resting order is
+	// equally likely to be cancelled, so the book settles around a size set by the ratios.
+	TargetOrders int
+}
+
+// DefaultConfig is a cancel-heavy BTC-USD like flow around 10000.00 with 0.01 ticks.
+func DefaultConfig() Config {
+	return Config{
+		ProductID:    "BTC-USD",
+		Seed:         1,
+		Scale:        DefaultScale,
+		Start:        time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		MidTicks:     1000000,
+		DriftTicks:   0.5,
+		DepthTicks:   200,
+		MaxLots:      500000000,
+		ArrivalRate:  100,
+		PlaceRatio:   0.45,
+		CancelRatio:  0.4,
+		TradeRatio:   0.1,
+		ChangeRatio:  0.05,
+		TargetOrders: 20000,
+	}
+}
+
+type order struct {
+	id    string
+	side  common.Side
+	ticks int64
+	lots  int64
+	index int
+}
+
+// Generator produces a well formed L3 "full" channel stream: every open follows its received,
+// matches fill resting orders in price-time priority and every order ends with exactly one done.
+// It keeps its own model of the book so the stream never crosses.
+type Generator struct {
+	config   Config
+	random   *rand.Rand
+	now      time.Time
+	mid      float64
+	sequence int64
+	tradeID  int
+
+	levels [2]map[int64][]*order
+	orders []*order
+	queue  []gdaxClient.Message
+}
+
+func NewGenerator(config Config) *Generator {
+	return &Generator{
+		config: config,
+		random: rand.New(rand.NewSource(config.Seed)),
+		now:    config.Start,
+		mid:    float64(config.MidTicks),
+		levels: [2]map[int64][]*order{{}, {}},
+	}
+}
+
+// Generate returns the next n messages.
+func (generator *Generator) Generate(n int) []gdaxClient.Message {
+	messages := make([]gdaxClient.Message, 0, n)
+	for len(messages) < n {
+		messages = append(messages, generator.Next())
+	}
+	return messages
+}
+
+// Next returns the next message. Messages of one event share a time and have consecutive sequences.
+func (generator *Generator) Next() gdaxClient.Message {
+	for len(generator.queue) == 0 {
+		generator.event()
+	}
+	message := generator.queue[0]
+	generator.queue = generator.queue[1:]
+	generator.sequence += 1
+	message.Sequence = generator.sequence
+	return message
+}
+
+// Flush returns the rest of the current event, so that a stream can end with the model and
+// any book built from the stream agreeing.
+func (generator *Generator) Flush() []gdaxClient.Message {
+	messages := []gdaxClient.Message{}
+	for len(generator.queue) > 0 {
+		messages = append(messages, generator.Next())
+	}
+	return messages
+}
+
+// NumOrders is the number of orders resting in the generator's model of the book.
+func (generator *Generator) NumOrders() int {
+	return len(generator.orders)
+}
+
+func (generator *Generator) event() {
+	config := generator.config
+	generator.now = generator.now.Add(time.Duration(generator.random.ExpFloat64() / config.ArrivalRate * float64(time.Second)))
+	generator.mid += generator.random.NormFloat64() * config.DriftTicks
+
+	if len(generator.orders) == 0 {
+		generator.place()
+		return
+	}
+	place := config.PlaceRatio
+	cancel := config.CancelRatio * float64(len(generator.orders)) / float64(config.TargetOrders)
+	pick := generator.random.Float64() * (place + cancel + config.TradeRatio + config.ChangeRatio)
+	switch {
+	case pick < place:
+		generator.place()
+	case pick < place+cancel:
+		generator.cancel(generator.orders[generator.random.Intn(len(generator.orders))])
+	case pick < place+cancel+config.TradeRatio:
+		generator.trade()
+	default:
+		generator.change(generator.orders[generator.random.Intn(len(generator.orders))])
+	}
+}
+
+func (generator *Generator) place() {
+	side := common.Side(generator.random.Intn(2))
+	distance := int64(generator.random.ExpFloat64() * generator.config.DepthTicks)
+	ticks := int64(math.Round(generator.mid)) - 1 - distance
+	if side == common.AskSide {
+		ticks = int64(math.Round(generator.mid)) + 1 + distance
+	}
+	// never cross the other side
+	if best, ok := generator.best(1 - side); ok {
+		if side == common.BidSide && ticks >= best {
+			ticks = best - 1
+		} else if side == common.AskSide && ticks <= best {
+			ticks = best + 1
+		}
+	}
+	if ticks < 1 {
+		ticks = 1
+	}
+	o := &order{id: generator.newID(), side: side, ticks: ticks, lots: generator.lots()}
+	generator.queue = append(generator.queue,
+		generator.message("received", o, gdaxClient.Message{OrderType: "limit", Size: generator.size(o.lots)}),
+		generator.message("open", o, gdaxClient.Message{RemainingSize: generator.size(o.lots)}))
+	generator.rest(o)
+}
+
+func (generator *Generator) cancel(o *order) {
+	generator.queue = append(generator.queue,
+		generator.message("done", o, gdaxClient.Message{Reason: "canceled", RemainingSize: generator.size(o.lots)}))
+	generator.unrest(o)
+}
+
+// change shrinks an order, as self-trade prevention does.
+func (generator *Generator) change(o *order) {
+	if o.lots < 2 {
+		generator.cancel(o)
+		return
+	}
+	newLots := 1 + generator.random.Int63n(o.lots-1)
+	generator.queue = append(generator.queue,
+		generator.message("change", o, gdaxClient.Message{OldSize: generator.size(o.lots), NewSize: generator.size(newLots)}))
+	o.lots = newLots
+}
+
+// trade sends a taker through the best levels of one side. Makers it does not fill completely
+// stay on the book with their remaining size.
+func (generator *Generator) trade() {
+	makerSide := common.Side(generator.random.Intn(2))
+	if _, ok := generator.best(makerSide); !ok {
+		makerSide = 1 - makerSide
+	}
+	best, _ := generator.best(makerSide)
+	limit := best + 5
+	if makerSide == common.BidSide {
+		limit = best - 5
+	}
+	taker := &order{id: generator.newID(), side: 1 - makerSide, ticks: best, lots: generator.lots()}
+	remaining := taker.lots
+	fills := []gdaxClient.Message{}
+	for remaining > 0 {
+		ticks, ok := generator.best(makerSide)
+		if !ok || (makerSide == common.AskSide && ticks > limit) || (makerSide == common.BidSide && ticks < limit) {
+			break
+		}
+		maker := generator.levels[makerSide][ticks][0]
+		fill := remaining
+		if maker.lots < fill {
+			fill = maker.lots
+		}
+		generator.tradeID += 1
+		fills = append(fills, gdaxClient.Message{Type: "match", ProductId: generator.config.ProductID, Time: gdaxClient.Time(generator.now),
+			TradeId: generator.tradeID, MakerOrderId: maker.id, TakerOrderId: taker.id, Size: generator.size(fill),
+			Price: generator.price(maker.ticks), Side: sideString(makerSide)})
+		remaining -= fill
+		maker.lots -= fill
+		taker.ticks = ticks
+		if maker.lots == 0 {
+			fills = append(fills, generator.message("done", maker, gdaxClient.Message{Reason: "filled", RemainingSize: generator.size(0)}))
+			generator.unrest(maker)
+		}
+	}
+	// the taker's limit is the worst price it reached, so it never rests
+	taker.lots -= remaining
+	generator.queue = append(generator.queue, generator.message("received", taker, gdaxClient.Message{OrderType: "limit", Size: generator.size(taker.lots)}))
+	generator.queue = append(generator.queue, fills...)
+	generator.queue = append(generator.queue, generator.message("done", taker, gdaxClient.Message{Reason: "filled", RemainingSize: generator.size(0)}))
+}
+
+func (generator *Generator) rest(o *order) {
+	o.index = len(generator.orders)
+	generator.orders = append(generator.orders, o)
+	generator.levels[o.side][o.ticks] = append(generator.levels[o.side][o.ticks], o)
+}
+
+func (generator *Generator) unrest(o *order) {
+	last := generator.orders[len(generator.orders)-1]
+	last.index = o.index
+	generator.orders[o.index] = last
+	generator.orders = generator.orders[:len(generator.orders)-1]
+
+	level := generator.levels[o.side][o.ticks]
+	for i := range level {
+		if level[i] == o {
+			level = append(level[:i], level[i+1:]...)
+			break
+		}
+	}
+	if len(level) == 0 {
+		delete(generator.levels[o.side], o.ticks)
+	} else {
+		generator.levels[o.side][o.ticks] = level
+	}
+}
+
+// best scans the side's levels. The model only needs it when placing and trading.
+func (generator *Generator) best(side common.Side) (int64, bool) {
+	best, found := int64(0), false
+	for ticks := range generator.levels[side] {
+		if !found || (side == common.BidSide && ticks > best) || (side == common.AskSide && ticks < best) {
+			best, found = ticks, true
+		}
+	}
+	return best, found
+}
+
+func (generator *Generator) lots() int64 {
+	// most orders are small with a long tail of large ones
+	lots := int64(generator.random.ExpFloat64() * float64(generator.config.MaxLots) / 20)
+	if lots < 1 {
+		lots = 1
+	}
+	if lots > generator.config.MaxLots {
+		lots = generator.config.MaxLots
+	}
+	return lots
+}
+
+func (generator *Generator) message(messageType string, o *order, message gdaxClient.Message) gdaxClient.Message {
+	message.Type = messageType
+	message.ProductId = generator.config.ProductID
+	message.Time = gdaxClient.Time(generator.now)
+	message.OrderId = o.id
+	message.Price = generator.price(o.ticks)
+	message.Side = sideString(o.side)
+	return message
+}
+
+func (generator *Generator) price(ticks int64) string {
+	return generator.config.Scale.Price(ticks).StringFixed(-generator.config.Scale.PriceTick.Exponent())
+}
+
+func (generator *Generator) size(lots int64) string {
+	return generator.config.Scale.Size(lots).StringFixed(-generator.config.Scale.SizeLot.Exponent())
+}
+
+func (generator *Generator) newID() string {
+	var raw [16]byte
+	generator.random.Read(raw[:])
+	return fmt.Sprintf("%x-%x-%x-%x-%x", raw[0:4], raw[4:6], raw[6:8], raw[8:10], raw[10:16])
+}
+
+func sideString(side common.Side) string {
+	if side == common.BidSide {
+		return "buy"
+	}
+	return "sell"
+}